@@ -1,80 +1,372 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/memory"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/migrate"
 	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/signature"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/slackapi"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/sqlite"
 	"github.com/hboyadzhieva/slack-bot-to-do-list/tododo"
 	"github.com/nlopes/slack"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 )
 
 const (
-	port     = ":80"
-	dialect  = "mysql"
-	dsn      = "myuser:mypassword@tcp(127.0.0.1:3306)/slack"
-	idleConn = 10
-	maxConn  = 10
+	port          = ":80"
+	dialect       = "mysql"
+	dsn           = "myuser:mypassword@tcp(127.0.0.1:3306)/slack?parseTime=true&clientFoundRows=true"
+	sqlitePath    = "tododo.db"
+	idleConn      = 10
+	maxConn       = 10
+	storageMySQL  = "mysql"
+	storageSQLite = "sqlite"
+	storageMemory = "memory"
+	shutdownWait  = 30 * time.Second
+	triggerWait   = 2 * time.Second
 )
 
-var db *sql.DB
 var commandHandler tododo.CommandHandlerInterface
-var slackVerToken string
+var verifier *signature.Verifier
+var dispatcher *tododo.Dispatcher
+var events *tododo.EventDeduplicator
 
 func main() {
 
-	token, exists := os.LookupEnv("SLACK_VERIFICATION_TOKEN")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Migrate error: %s", err)
+		}
+		return
+	}
+
+	secret, exists := os.LookupEnv("SLACK_SIGNING_SECRET")
 	if !exists {
-		log.Fatalf("Slack verification token not set in environment")
+		log.Fatalf("Slack signing secret not set in environment")
 	}
-	slackVerToken = token
+	verifier = signature.NewVerifier(secret)
 
-	db, err := sql.Open(dialect, dsn)
+	store, err := openStorage()
 	if err != nil {
-		log.Fatalf("Can't open DB: %s", err)
+		log.Fatalf("Can't open storage: %s", err)
+	}
+	if store.db != nil {
+		defer store.db.Close()
+		m, err := migrate.New(store.db, store.name)
+		if err != nil {
+			log.Fatalf("Can't load migrations: %s", err)
+		}
+		err = m.Check()
+		if err != nil {
+			log.Fatalf("Can't serve against database schema: %s", err)
+		}
 	}
 
-	db.SetMaxIdleConns(idleConn)
-	db.SetMaxOpenConns(maxConn)
+	handler := &tododo.CommandHandler{
+		Repository: tododo.NewPolicyRepository(store.tasks, store.channels),
+		Settings:   store.settings,
+		Channels:   store.channels,
+	}
+	token, exists := os.LookupEnv("SLACK_BOT_TOKEN")
+	if exists {
+		handler.Slack = slackapi.NewClient(token)
+	} else {
+		fmt.Println("[INFO] Slack bot token not set in environment, reactions to task messages are ignored, the task form and the Home tab are not available")
+	}
+	commandHandler = handler
+	dispatcher = tododo.NewDispatcher(tododo.NewResponseSender(), tododo.DefaultWorkers, tododo.DefaultQueueSize)
+	events = tododo.NewEventDeduplicator()
 
-	err = db.Ping()
+	http.Handle("/tododo", verifier.Middleware(http.HandlerFunc(requestHandler)))
+	http.Handle("/tododo/interactive", verifier.Middleware(http.HandlerFunc(interactiveHandler)))
+	http.Handle("/tododo/events", verifier.Middleware(http.HandlerFunc(eventHandler)))
+	server := &http.Server{Addr: port}
+	go func() {
+		fmt.Println("[INFO] Server listening")
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	fmt.Println("[INFO] Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownWait)
+	defer cancel()
+	err = server.Shutdown(ctx)
 	if err != nil {
-		log.Fatalf("Ping DB error: %s", err)
+		fmt.Printf("[ERROR] Shutdown: %s\n", err)
+	}
+	dispatcher.Stop(ctx)
+}
+
+// storage holds the repositories of the backend set in TODODO_STORAGE.
+type storage struct {
+	name     string
+	db       *sql.DB
+	tasks    mysql.TaskRepositoryInterface
+	settings mysql.UserSettingRepositoryInterface
+	channels mysql.ChannelSettingRepositoryInterface
+}
+
+// openStorage opens the storage backend set in TODODO_STORAGE - "mysql"(default), "sqlite" or "memory".
+// TODODO_DSN overrides the MySQL DSN or the SQLite database file path. The database is nil for memory storage.
+func openStorage() (*storage, error) {
+	name, exists := os.LookupEnv("TODODO_STORAGE")
+	if !exists {
+		name = storageMySQL
 	}
+	source, exists := os.LookupEnv("TODODO_DSN")
 
-	defer db.Close()
+	switch name {
+	case storageMySQL:
+		if !exists {
+			source = dsn
+		}
+		db, err := sql.Open(dialect, source)
+		if err != nil {
+			return nil, err
+		}
+		db.SetMaxIdleConns(idleConn)
+		db.SetMaxOpenConns(maxConn)
+		err = db.Ping()
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return &storage{
+			name:     name,
+			db:       db,
+			tasks:    &mysql.TaskRepository{DB: db},
+			settings: &mysql.UserSettingRepository{DB: db},
+			channels: &mysql.ChannelSettingRepository{DB: db},
+		}, nil
+	case storageSQLite:
+		if !exists {
+			source = sqlitePath
+		}
+		repo, err := sqlite.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		return &storage{
+			name:     name,
+			db:       repo.DB,
+			tasks:    repo,
+			settings: sqlite.NewUserSettingRepository(repo),
+			channels: sqlite.NewChannelSettingRepository(repo),
+		}, nil
+	case storageMemory:
+		return &storage{
+			name:     name,
+			tasks:    memory.NewTaskRepository(),
+			settings: memory.NewUserSettingRepository(),
+			channels: memory.NewChannelSettingRepository(),
+		}, nil
+	}
+	return nil, fmt.Errorf("Unknown storage %s", name)
+}
 
-	commandHandler = &tododo.CommandHandler{
-		Repository: &mysql.TaskRepository{DB: db},
+// runMigrate handles the migrate subcommand: migrate status|up|down|to [version].
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Usage: migrate status|up|down|to [version]")
+	}
+	store, err := openStorage()
+	if err != nil {
+		return err
+	}
+	if store.db == nil {
+		return fmt.Errorf("Storage %s has no schema to migrate", store.name)
+	}
+	defer store.db.Close()
+	m, err := migrate.New(store.db, store.name)
+	if err != nil {
+		return err
 	}
 
-	go http.HandleFunc("/tododo", requestHandler)
-	fmt.Println("[INFO] Server listening")
-	log.Fatal(http.ListenAndServe(port, nil))
+	switch args[0] {
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = "applied"
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down()
+	case "to":
+		if len(args) != 2 {
+			return fmt.Errorf("Usage: migrate to [version]")
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("Bad version %s", args[1])
+		}
+		err = m.To(version)
+	default:
+		return fmt.Errorf("Unknown migrate command %s", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[INFO] Schema at version %d of %d\n", current, m.Latest())
+	return nil
 }
 
+// requestHandler acknowledges the slash command right away and runs it on the dispatcher, which sends the result to response_url.
+// A command opening the task modal is handled before it is acknowledged, because its trigger ID expires, and answered in the body.
 func requestHandler(w http.ResponseWriter, r *http.Request) {
 	s, err := slack.SlashCommandParse(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if tododo.IsModalCommand(&s) {
+		ctx, cancel := context.WithTimeout(r.Context(), triggerWait)
+		defer cancel()
+		response, err := commandHandler.HandleCommand(ctx, &s)
+		if err != nil {
+			fmt.Printf("[ERROR] Can't open modal: %s, %s\n", s.Command, err)
+			response, err = tododo.ErrorResponse()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		writeResponse(w, response)
+		return
+	}
+
+	err = dispatcher.Submit(s.ResponseURL, func(ctx context.Context) ([]byte, error) {
+		return commandHandler.HandleCommand(ctx, &s)
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] Can't run command: %s, %s\n", s.Command, err)
+		writeBusy(w)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// interactiveHandler acknowledges the interaction right away and runs it on the dispatcher, which sends the result to response_url.
+// A submission of a modal has no response_url, it is handled right away and answered in the body, e.g. with the errors of its inputs.
+// An interaction opening the task modal is handled before it is acknowledged, because its trigger ID expires.
+func interactiveHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := tododo.ParseInteractionPayload(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if payload.Type == tododo.InteractionViewSubmission {
+		response, err := commandHandler.HandleViewSubmission(r.Context(), payload)
+		if err != nil {
+			fmt.Printf("[ERROR] Can't handle view submission: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeResponse(w, response)
+		return
+	}
+	if tododo.IsModalAction(payload) {
+		ctx, cancel := context.WithTimeout(r.Context(), triggerWait)
+		defer cancel()
+		_, err = commandHandler.HandleInteraction(ctx, payload)
+		if err != nil {
+			fmt.Printf("[ERROR] Can't open modal: %s\n", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	err = dispatcher.Submit(payload.ResponseURL, func(ctx context.Context) ([]byte, error) {
+		return commandHandler.HandleInteraction(ctx, payload)
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] Can't run interaction: %s, %s\n", payload.Type, err)
+		writeBusy(w)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// eventHandler answers the url_verification challenge and acknowledges events right away, running them on the dispatcher.
+// An event Slack delivers again, because it wasn't acknowledged in time, is handled once.
+func eventHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := tododo.ParseEventPayload(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	if !s.ValidateToken(slackVerToken) {
-		fmt.Printf("Cant validate token %s", slackVerToken)
-		w.WriteHeader(http.StatusUnauthorized)
+	if payload.Type == tododo.EventURLVerification {
+		response, err := tododo.ChallengeResponse(payload)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(response)
+		return
+	}
+	if events.Seen(payload.EventID) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	err = dispatcher.Submit("", func(ctx context.Context) ([]byte, error) {
+		return nil, commandHandler.HandleEvent(ctx, payload)
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] Can't run event: %s, %s\n", payload.EventID, err)
+		events.Forget(payload.EventID)
+		w.WriteHeader(http.StatusServiceUnavailable)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
+}
 
-	response, err := commandHandler.HandleCommand(&s)
+// writeBusy answers with a message visible only to the user when the dispatcher can't take the command.
+func writeBusy(w http.ResponseWriter) {
+	response, err := tododo.BusyResponse()
 	if err != nil {
-		fmt.Printf("Error handling command: %s, %s", s.Command, err)
 		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeResponse(w, response)
+}
+
+// writeResponse answers with the json response, with an empty body if it is nil.
+func writeResponse(w http.ResponseWriter, response []byte) {
+	if response == nil {
+		w.WriteHeader(http.StatusOK)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Write(response)
-
 }
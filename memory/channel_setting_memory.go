@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"sort"
+	"sync"
+)
+
+// ChannelSettingRepository implements mysql.ChannelSettingRepositoryInterface by keeping settings in a map guarded by a mutex
+type ChannelSettingRepository struct {
+	mu           sync.RWMutex
+	visibilities map[string]string
+	policies     map[string]string
+	admins       map[string]map[string]bool
+	workflows    map[string]*mysql.Workflow
+}
+
+// NewChannelSettingRepository constructs an empty repository.
+func NewChannelSettingRepository() *ChannelSettingRepository {
+	repo := ChannelSettingRepository{}
+	repo.visibilities = make(map[string]string)
+	repo.policies = make(map[string]string)
+	repo.admins = make(map[string]map[string]bool)
+	repo.workflows = make(map[string]*mysql.Workflow)
+	return &repo
+}
+
+// GetVisibility returns the visibility set for the channel or mysql.VisibilityDefault if not set.
+func (repo *ChannelSettingRepository) GetVisibility(ctx context.Context, channelID string) (string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	visibility, ok := repo.visibilities[channelID]
+	if !ok {
+		return mysql.VisibilityDefault, nil
+	}
+	return visibility, nil
+}
+
+// SetVisibility saves the visibility of the channel, replacing the previous one.
+func (repo *ChannelSettingRepository) SetVisibility(ctx context.Context, channelID string, visibility string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.visibilities[channelID] = visibility
+	return nil
+}
+
+// GetPolicy returns the permission policy set for the channel or mysql.PolicyOpen if not set.
+func (repo *ChannelSettingRepository) GetPolicy(ctx context.Context, channelID string) (string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	policy, ok := repo.policies[channelID]
+	if !ok {
+		return mysql.PolicyOpen, nil
+	}
+	return policy, nil
+}
+
+// SetPolicy saves the permission policy of the channel, replacing the previous one.
+func (repo *ChannelSettingRepository) SetPolicy(ctx context.Context, channelID string, policy string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.policies[channelID] = policy
+	return nil
+}
+
+// GetAdmins returns the task admins of the channel ordered by user ID.
+func (repo *ChannelSettingRepository) GetAdmins(ctx context.Context, channelID string) ([]string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	admins := make([]string, 0)
+	for userID := range repo.admins[channelID] {
+		admins = append(admins, userID)
+	}
+	sort.Strings(admins)
+	return admins, nil
+}
+
+// AddAdmin makes the user a task admin of the channel.
+func (repo *ChannelSettingRepository) AddAdmin(ctx context.Context, channelID string, userID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.admins[channelID] == nil {
+		repo.admins[channelID] = make(map[string]bool)
+	}
+	repo.admins[channelID][userID] = true
+	return nil
+}
+
+// RemoveAdmin removes the user from the task admins of the channel.
+func (repo *ChannelSettingRepository) RemoveAdmin(ctx context.Context, channelID string, userID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.admins[channelID], userID)
+	return nil
+}
+
+// GetWorkflow returns a copy of the workflow of the channel with the transitions ordered by state and target, nil if not set.
+func (repo *ChannelSettingRepository) GetWorkflow(ctx context.Context, channelID string) (*mysql.Workflow, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	workflow, ok := repo.workflows[channelID]
+	if !ok {
+		return nil, nil
+	}
+	return copyWorkflow(workflow), nil
+}
+
+// SetWorkflow saves a copy of the workflow of the channel, replacing the previous one. A nil workflow removes it.
+func (repo *ChannelSettingRepository) SetWorkflow(ctx context.Context, channelID string, workflow *mysql.Workflow) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if workflow == nil {
+		delete(repo.workflows, channelID)
+		return nil
+	}
+	repo.workflows[channelID] = copyWorkflow(workflow)
+	return nil
+}
+
+// copyWorkflow returns a deep copy of workflow, so callers can't change the stored one.
+func copyWorkflow(workflow *mysql.Workflow) *mysql.Workflow {
+	res := mysql.Workflow{States: make([]*mysql.WorkflowState, 0), Transitions: make([]*mysql.WorkflowTransition, 0)}
+	for _, state := range workflow.States {
+		copied := *state
+		res.States = append(res.States, &copied)
+	}
+	for _, transition := range workflow.Transitions {
+		copied := *transition
+		res.Transitions = append(res.Transitions, &copied)
+	}
+	sort.Slice(res.Transitions, func(i, j int) bool {
+		if res.Transitions[i].From != res.Transitions[j].From {
+			return res.Transitions[i].From < res.Transitions[j].From
+		}
+		return res.Transitions[i].To < res.Transitions[j].To
+	})
+	return &res
+}
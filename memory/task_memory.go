@@ -0,0 +1,434 @@
+// Package memory provides an in-memory, concurrency-safe implementation of mysql.TaskRepositoryInterface for demos and tests.
+// Tasks are lost when the process exits.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// taskKey identifies a task by channel and number
+type taskKey struct {
+	channelID string
+	number    int
+}
+
+// messageKey identifies a bot message by channel and timestamp
+type messageKey struct {
+	channelID string
+	ts        string
+}
+
+// TaskRepository implements mysql.TaskRepositoryInterface by keeping tasks in a map guarded by a mutex
+type TaskRepository struct {
+	mu          sync.RWMutex
+	tasks       map[taskKey]*mysql.Task
+	messages    map[messageKey]int
+	sequences   map[string]int
+	events      map[int][]*mysql.TaskEvent
+	lastID      int
+	lastEventID int
+}
+
+// NewTaskRepository constructs an empty repository.
+func NewTaskRepository() *TaskRepository {
+	repo := TaskRepository{}
+	repo.tasks = make(map[taskKey]*mysql.Task)
+	repo.sequences = make(map[string]int)
+	repo.events = make(map[int][]*mysql.TaskEvent)
+	repo.messages = make(map[messageKey]int)
+	return &repo
+}
+
+// PersistTask saves a copy of task in memory and records its creation by t.CreatorID.
+// Task id is automatically incremented and set to t.ID, the next number in the channel is set to t.Number. Creation and update time are set to now.
+// The ordered set of labels is set to t.Labels.
+func (repo *TaskRepository) PersistTask(ctx context.Context, t *mysql.Task) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.lastID++
+	repo.sequences[t.ChannelID]++
+	now := mysql.Now()
+	updated := now
+	t.ID = repo.lastID
+	t.Number = repo.sequences[t.ChannelID]
+	t.CreatedAt = &now
+	t.UpdatedAt = &updated
+	t.Labels = mysql.ApplyLabels(nil, t.Labels, nil)
+	repo.tasks[taskKey{t.ChannelID, t.Number}] = copyTask(t)
+	repo.record(t.ID, t.CreatorID, mysql.FieldCreated, "", t.Title)
+	if len(t.Labels) > 0 {
+		repo.record(t.ID, t.CreatorID, mysql.FieldLabels, "", strings.Join(t.Labels, " "))
+	}
+	return nil
+}
+
+// GetTask returns a copy of the task with this number in the channel, deleted tasks included.
+// Return sql.ErrNoRows if there is no such task.
+func (repo *TaskRepository) GetTask(ctx context.Context, channelID string, number int) (*mysql.Task, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	stored, ok := repo.tasks[taskKey{channelID, number}]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyTask(stored), nil
+}
+
+// GetAllInChannel accepts channel ID and returns copies of all tasks in the specified channel ordered by number. Deleted tasks are not returned.
+func (repo *TaskRepository) GetAllInChannel(ctx context.Context, channelID string) ([]*mysql.Task, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	tasks := make([]*mysql.Task, 0)
+	for _, stored := range repo.tasks {
+		if stored.ChannelID == channelID && !stored.Deleted {
+			tasks = append(tasks, copyTask(stored))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Number < tasks[j].Number })
+	return tasks, nil
+}
+
+// GetAllAssignedTo returns copies of the tasks assigned to assigneeID in all channels ordered by channel and number. Deleted tasks are not returned.
+func (repo *TaskRepository) GetAllAssignedTo(ctx context.Context, assigneeID string) ([]*mysql.Task, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	tasks := make([]*mysql.Task, 0)
+	for _, stored := range repo.tasks {
+		if stored.AsigneeID == assigneeID && !stored.Deleted {
+			tasks = append(tasks, copyTask(stored))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].ChannelID != tasks[j].ChannelID {
+			return tasks[i].ChannelID < tasks[j].ChannelID
+		}
+		return tasks[i].Number < tasks[j].Number
+	})
+	return tasks, nil
+}
+
+// FindTasks returns copies of the tasks matching filter, refer to mysql.TaskFilter. Deleted tasks are not returned.
+func (repo *TaskRepository) FindTasks(ctx context.Context, filter *mysql.TaskFilter) ([]*mysql.Task, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	tasks := make([]*mysql.Task, 0)
+	for _, stored := range repo.tasks {
+		if matches(stored, filter) {
+			tasks = append(tasks, copyTask(stored))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if filter.Sort == mysql.SortPriority && tasks[i].Priority != tasks[j].Priority {
+			if tasks[i].Priority == mysql.PriorityNone || tasks[j].Priority == mysql.PriorityNone {
+				return tasks[j].Priority == mysql.PriorityNone
+			}
+			return tasks[i].Priority < tasks[j].Priority
+		}
+		if filter.Sort == mysql.SortDue && !sameDue(tasks[i].DueDate, tasks[j].DueDate) {
+			if tasks[i].DueDate == nil || tasks[j].DueDate == nil {
+				return tasks[j].DueDate == nil
+			}
+			return tasks[i].DueDate.Before(*tasks[j].DueDate)
+		}
+		return tasks[i].Number < tasks[j].Number
+	})
+	if filter.Limit == 0 {
+		return tasks, nil
+	}
+	if filter.Offset > len(tasks) {
+		return []*mysql.Task{}, nil
+	}
+	tasks = tasks[filter.Offset:]
+	if filter.Limit < len(tasks) {
+		tasks = tasks[:filter.Limit]
+	}
+	return tasks, nil
+}
+
+// matches reports whether the stored task is selected by filter
+func matches(t *mysql.Task, filter *mysql.TaskFilter) bool {
+	if t.ChannelID != filter.ChannelID || t.Deleted {
+		return false
+	}
+	if len(filter.Statuses) > 0 {
+		found := false
+		for _, status := range filter.Statuses {
+			found = found || t.Status == status
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.AsigneeID != "" && t.AsigneeID != filter.AsigneeID {
+		return false
+	}
+	for _, label := range filter.Labels {
+		if !hasLabel(t, label) {
+			return false
+		}
+	}
+	if filter.CompletedSince != nil && (t.CompletedAt == nil || t.CompletedAt.Before(*filter.CompletedSince)) {
+		return false
+	}
+	return true
+}
+
+// hasLabel reports whether the task has label
+func hasLabel(t *mysql.Task, label string) bool {
+	for _, l := range t.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// sameDue reports whether both due dates are missing or equal
+func sameDue(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// AssignTaskTo sets the assigneeID to assigneeID of the task with this number in the channel. Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted.
+func (repo *TaskRepository) AssignTaskTo(ctx context.Context, channelID string, number int, assigneeID string, actorID string) error {
+	return repo.update(channelID, number, false, actorID, mysql.FieldAssignee, func(t *mysql.Task) { t.AsigneeID = assigneeID })
+}
+
+// SetStatus sets the status to status of the task with this number in the channel. Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted.
+// Completion time is set to now when the task reaches a terminal status for the first time and cleared when it is reopened.
+func (repo *TaskRepository) SetStatus(ctx context.Context, channelID string, number int, status string, terminal bool, actorID string) error {
+	return repo.update(channelID, number, false, actorID, mysql.FieldStatus, func(t *mysql.Task) {
+		t.Status = status
+		if !terminal {
+			t.CompletedAt = nil
+		} else if t.CompletedAt == nil {
+			now := mysql.Now()
+			t.CompletedAt = &now
+		}
+	})
+}
+
+// UpdateTitle sets the title to title of the task with this number in the channel. Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted.
+func (repo *TaskRepository) UpdateTitle(ctx context.Context, channelID string, number int, title string, actorID string) error {
+	return repo.update(channelID, number, false, actorID, mysql.FieldTitle, func(t *mysql.Task) { t.Title = title })
+}
+
+// SetPriority sets the priority to priority of the task with this number in the channel. Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted.
+func (repo *TaskRepository) SetPriority(ctx context.Context, channelID string, number int, priority int, actorID string) error {
+	return repo.update(channelID, number, false, actorID, mysql.FieldPriority, func(t *mysql.Task) { t.Priority = priority })
+}
+
+// UpdateLabels removes the labels in removed from the task with this number in the channel and adds the labels in added, refer to mysql.ApplyLabels.
+// Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted.
+func (repo *TaskRepository) UpdateLabels(ctx context.Context, channelID string, number int, added []string, removed []string, actorID string) error {
+	return repo.update(channelID, number, false, actorID, mysql.FieldLabels, func(t *mysql.Task) { t.Labels = mysql.ApplyLabels(t.Labels, added, removed) })
+}
+
+// UpdateDescription sets the description to description of the task with this number in the channel. Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted.
+func (repo *TaskRepository) UpdateDescription(ctx context.Context, channelID string, number int, description string, actorID string) error {
+	return repo.update(channelID, number, false, actorID, mysql.FieldDescription, func(t *mysql.Task) { t.Description = description })
+}
+
+// SetDueDate sets the due date to a copy of due of the task with this number in the channel, nil removes it.
+// Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted.
+func (repo *TaskRepository) SetDueDate(ctx context.Context, channelID string, number int, due *time.Time, actorID string) error {
+	return repo.update(channelID, number, false, actorID, mysql.FieldDue, func(t *mysql.Task) { t.DueDate = copyTime(due) })
+}
+
+// UpdateTask changes the fields of update of the task with this number in the channel at once, every field which gets a different value is recorded.
+// Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted.
+func (repo *TaskRepository) UpdateTask(ctx context.Context, channelID string, number int, update *mysql.TaskUpdate, actorID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, ok := repo.tasks[taskKey{channelID, number}]
+	if !ok || stored.Deleted {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	changes := update.Changes(stored)
+	if update.Title != nil {
+		stored.Title = *update.Title
+	}
+	if update.AsigneeID != nil {
+		stored.AsigneeID = *update.AsigneeID
+	}
+	if update.SetDue {
+		stored.DueDate = copyTime(update.DueDate)
+	}
+	if update.Priority != nil {
+		stored.Priority = *update.Priority
+	}
+	if update.Description != nil {
+		stored.Description = *update.Description
+	}
+	now := mysql.Now()
+	stored.UpdatedAt = &now
+	for _, c := range changes {
+		repo.record(stored.ID, actorID, c.Field, c.OldValue, c.NewValue)
+	}
+	return nil
+}
+
+// GetLabelCounts returns the labels of the tasks in the channel with the number of tasks having each label, ordered by label. Deleted tasks are not counted.
+func (repo *TaskRepository) GetLabelCounts(ctx context.Context, channelID string) ([]*mysql.LabelCount, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, stored := range repo.tasks {
+		if stored.ChannelID != channelID || stored.Deleted {
+			continue
+		}
+		for _, label := range stored.Labels {
+			counts[label]++
+		}
+	}
+	res := make([]*mysql.LabelCount, 0)
+	for label, count := range counts {
+		res = append(res, &mysql.LabelCount{Label: label, Count: count})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Label < res[j].Label })
+	return res, nil
+}
+
+// DeleteTask marks the task with this number in the channel as deleted. Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is already deleted.
+func (repo *TaskRepository) DeleteTask(ctx context.Context, channelID string, number int, actorID string) error {
+	return repo.update(channelID, number, false, actorID, mysql.FieldDeleted, func(t *mysql.Task) { t.Deleted = true })
+}
+
+// RestoreTask undoes DeleteTask. Returns mysql.ErrNoRowOrMoreThanOne if there is no deleted task with this number in the channel.
+func (repo *TaskRepository) RestoreTask(ctx context.Context, channelID string, number int, actorID string) error {
+	return repo.update(channelID, number, true, actorID, mysql.FieldDeleted, func(t *mysql.Task) { t.Deleted = false })
+}
+
+// GetTaskEvents returns copies of the changes of the task with this number in the channel, deleted tasks included, oldest first.
+// Returns empty list if there is no such task.
+func (repo *TaskRepository) GetTaskEvents(ctx context.Context, channelID string, number int) ([]*mysql.TaskEvent, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	events := make([]*mysql.TaskEvent, 0)
+	stored, ok := repo.tasks[taskKey{channelID, number}]
+	if !ok {
+		return events, nil
+	}
+	for _, event := range repo.events[stored.ID] {
+		copied := *event
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// AddTaskMessage records that the bot message with timestamp ts in the channel shows the task with this number.
+// Returns mysql.ErrNoRowOrMoreThanOne if there is no such task.
+func (repo *TaskRepository) AddTaskMessage(ctx context.Context, channelID string, number int, ts string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	_, ok := repo.tasks[taskKey{channelID, number}]
+	if !ok {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	repo.messages[messageKey{channelID, ts}] = number
+	return nil
+}
+
+// GetTaskByMessage returns a copy of the task shown by the bot message with timestamp ts in the channel, deleted tasks included.
+// Returns sql.ErrNoRows if the message doesn't show a task.
+func (repo *TaskRepository) GetTaskByMessage(ctx context.Context, channelID string, ts string) (*mysql.Task, error) {
+	repo.mu.RLock()
+	number, ok := repo.messages[messageKey{channelID, ts}]
+	repo.mu.RUnlock()
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return repo.GetTask(ctx, channelID, number)
+}
+
+// update applies change to the stored task with this number in the channel if its deleted flag equals deleted.
+// The change of field is recorded unless its value stays the same, the update time is set to now.
+func (repo *TaskRepository) update(channelID string, number int, deleted bool, actorID string, field string, change func(t *mysql.Task)) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, ok := repo.tasks[taskKey{channelID, number}]
+	if !ok || stored.Deleted != deleted {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	oldValue := fieldValue(stored, field)
+	change(stored)
+	now := mysql.Now()
+	stored.UpdatedAt = &now
+	newValue := fieldValue(stored, field)
+	if oldValue != newValue {
+		repo.record(stored.ID, actorID, field, oldValue, newValue)
+	}
+	return nil
+}
+
+// record appends an event to the history of the task with taskID. The caller holds the lock.
+func (repo *TaskRepository) record(taskID int, actorID string, field string, oldValue string, newValue string) {
+	repo.lastEventID++
+	event := mysql.TaskEvent{
+		ID:        repo.lastEventID,
+		TaskID:    taskID,
+		ActorID:   actorID,
+		CreatedAt: mysql.Now(),
+		Field:     field,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+	repo.events[taskID] = append(repo.events[taskID], &event)
+}
+
+// fieldValue returns the value of field of t as recorded in mysql.TaskEvent
+func fieldValue(t *mysql.Task, field string) string {
+	switch field {
+	case mysql.FieldStatus:
+		return t.Status
+	case mysql.FieldAssignee:
+		return t.AsigneeID
+	case mysql.FieldTitle:
+		return t.Title
+	case mysql.FieldPriority:
+		return strconv.Itoa(t.Priority)
+	case mysql.FieldLabels:
+		return strings.Join(t.Labels, " ")
+	case mysql.FieldDescription:
+		return t.Description
+	case mysql.FieldDue:
+		return mysql.FormatDue(t.DueDate)
+	case mysql.FieldDeleted:
+		if t.Deleted {
+			return "1"
+		}
+		return "0"
+	}
+	return ""
+}
+
+// copyTask returns a deep copy of t, so callers can't change stored tasks.
+func copyTask(t *mysql.Task) *mysql.Task {
+	task := *t
+	task.DueDate = copyTime(t.DueDate)
+	task.CreatedAt = copyTime(t.CreatedAt)
+	task.UpdatedAt = copyTime(t.UpdatedAt)
+	task.CompletedAt = copyTime(t.CompletedAt)
+	if t.Labels != nil {
+		task.Labels = append([]string{}, t.Labels...)
+	}
+	return &task
+}
+
+// copyTime returns a copy of t or nil if t is nil
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/repotest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var ctx = context.Background()
+
+func TestConformance(t *testing.T) {
+	repotest.RunConformance(t, func(t *testing.T) mysql.TaskRepositoryInterface {
+		return NewTaskRepository()
+	})
+}
+
+func TestUserSettingConformance(t *testing.T) {
+	repotest.RunUserSettingConformance(t, func(t *testing.T) mysql.UserSettingRepositoryInterface {
+		return NewUserSettingRepository()
+	})
+}
+
+func TestChannelSettingConformance(t *testing.T) {
+	repotest.RunChannelSettingConformance(t, func(t *testing.T) mysql.ChannelSettingRepositoryInterface {
+		return NewChannelSettingRepository()
+	})
+}
+
+func TestGetTaskReturnsCopy(t *testing.T) {
+	repo := NewTaskRepository()
+	task := mysql.NewTask("copy", "C1", "U1")
+	assert.NoError(t, repo.PersistTask(ctx, task))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	assert.NoError(t, err)
+	res.Title = "changed"
+	stored, _ := repo.GetTask(ctx, task.ChannelID, task.Number)
+	assert.Equal(t, "copy", stored.Title)
+}
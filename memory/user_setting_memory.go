@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// UserSettingRepository implements mysql.UserSettingRepositoryInterface by keeping settings in a map guarded by a mutex
+type UserSettingRepository struct {
+	mu        sync.RWMutex
+	timezones map[string]string
+}
+
+// NewUserSettingRepository constructs an empty repository.
+func NewUserSettingRepository() *UserSettingRepository {
+	repo := UserSettingRepository{}
+	repo.timezones = make(map[string]string)
+	return &repo
+}
+
+// GetTimezone returns the timezone set by the user or empty string if not set.
+func (repo *UserSettingRepository) GetTimezone(ctx context.Context, userID string) (string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.timezones[userID], nil
+}
+
+// SetTimezone saves the timezone of the user, replacing the previous one.
+func (repo *UserSettingRepository) SetTimezone(ctx context.Context, userID string, timezone string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.timezones[userID] = timezone
+	return nil
+}
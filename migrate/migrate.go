@@ -0,0 +1,254 @@
+// Package migrate applies the versioned database schema of the bot.
+// Migrations are embedded in the binary as pairs of files per dialect - mysql/0002_add_due_date.up.sql and mysql/0002_add_due_date.down.sql.
+// Statements in a file are separated by semicolons. Applied versions are recorded in table schema_version.
+package migrate
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dialects with embedded migrations.
+const (
+	DialectMySQL  = "mysql"
+	DialectSQLite = "sqlite"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// Errors returned by Check when the bot can't serve against the database schema.
+var (
+	ErrSchemaUnknown  = errors.New("migrate: database has a schema version unknown to this binary")
+	ErrSchemaNewer    = errors.New("migrate: database schema is newer than this binary")
+	ErrSchemaOutdated = errors.New("migrate: database schema is outdated, run migrate up")
+)
+
+// Migration is one version of the schema with statements to apply and revert it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes a migration and whether it is applied to the database.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// Migrator applies migrations to DB and keeps track of them in schema_version.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+}
+
+// New constructs a migrator with the embedded migrations of dialect.
+func New(db *sql.DB, dialect string) (*Migrator, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// Load reads the embedded migrations of dialect ordered by version.
+// Returns error if a version is missing its up or down file or versions are not consecutive from 1.
+func Load(dialect string) ([]Migration, error) {
+	entries, err := files.ReadDir(dialect)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: unknown dialect %s", dialect)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migrate: unexpected file %s", name)
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("migrate: bad file name %s", name)
+		}
+		byt, err := files.ReadFile(path.Join(dialect, name))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(byt)
+		} else {
+			m.Down = string(byt)
+		}
+	}
+	migrations := make([]Migration, 0)
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrate: version %d must have up and down files", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migrate: missing version %d", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// Latest returns the version of the newest migration known to the migrator.
+func (m *Migrator) Latest() int {
+	return len(m.Migrations)
+}
+
+// Current returns the highest version applied to the database, 0 if none.
+func (m *Migrator) Current() (int, error) {
+	err := m.ensureVersionTable()
+	if err != nil {
+		return 0, err
+	}
+	var version sql.NullInt64
+	err = m.DB.QueryRow("SELECT MAX(VERSION) FROM schema_version").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// Status returns every known migration and whether it is applied.
+func (m *Migrator) Status() ([]Status, error) {
+	current, err := m.Current()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0)
+	for _, migration := range m.Migrations {
+		statuses = append(statuses, Status{Migration: migration, Applied: migration.Version <= current})
+	}
+	return statuses, nil
+}
+
+// Check returns nil if the database schema is exactly the latest version known to the migrator.
+func (m *Migrator) Check() error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	var applied int
+	err = m.DB.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&applied)
+	if err != nil {
+		return err
+	}
+	switch {
+	case current > m.Latest():
+		return ErrSchemaNewer
+	case applied != current:
+		return ErrSchemaUnknown
+	case current < m.Latest():
+		return ErrSchemaOutdated
+	}
+	return nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down reverts the last applied migration.
+func (m *Migrator) Down() error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+	return m.To(current - 1)
+}
+
+// To applies or reverts migrations one by one until the database is at version.
+func (m *Migrator) To(version int) error {
+	if version < 0 || version > m.Latest() {
+		return fmt.Errorf("migrate: unknown version %d", version)
+	}
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	if current > m.Latest() {
+		return ErrSchemaNewer
+	}
+	for current < version {
+		migration := m.Migrations[current]
+		err = m.apply(migration.Up, "INSERT INTO schema_version (VERSION) VALUES (?)", migration.Version)
+		if err != nil {
+			return fmt.Errorf("migrate: up %d_%s: %s", migration.Version, migration.Name, err)
+		}
+		current++
+	}
+	for current > version {
+		migration := m.Migrations[current-1]
+		err = m.apply(migration.Down, "DELETE FROM schema_version WHERE VERSION = ?", migration.Version)
+		if err != nil {
+			return fmt.Errorf("migrate: down %d_%s: %s", migration.Version, migration.Name, err)
+		}
+		current--
+	}
+	return nil
+}
+
+func (m *Migrator) apply(script string, record string, version int) error {
+	txn, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(script) {
+		_, err = txn.Exec(stmt)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+	_, err = txn.Exec(record, version)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
+
+func (m *Migrator) ensureVersionTable() error {
+	_, err := m.DB.Exec("CREATE TABLE IF NOT EXISTS schema_version (VERSION INT NOT NULL PRIMARY KEY, APPLIED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)")
+	return err
+}
+
+func splitStatements(script string) []string {
+	statements := make([]string, 0)
+	for _, stmt := range strings.Split(script, ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
@@ -0,0 +1,216 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+	//SQL Driver
+	_ "modernc.org/sqlite"
+)
+
+func newTestMigrator(t *testing.T) *Migrator {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	m, err := New(db, DialectSQLite)
+	require.NoError(t, err)
+	return m
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	require.NoError(t, err)
+	return count == 1
+}
+
+func TestLoad(t *testing.T) {
+	for _, dialect := range []string{DialectMySQL, DialectSQLite} {
+		migrations, err := Load(dialect)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+		assert.Equal(t, 1, migrations[0].Version)
+		assert.Equal(t, "create_task", migrations[0].Name)
+		assert.Contains(t, migrations[0].Up, "CREATE TABLE")
+		assert.Contains(t, migrations[0].Down, "DROP TABLE")
+	}
+}
+
+func TestLoadDialectsInSync(t *testing.T) {
+	mysql, err := Load(DialectMySQL)
+	require.NoError(t, err)
+	sqlite, err := Load(DialectSQLite)
+	require.NoError(t, err)
+	require.Equal(t, len(mysql), len(sqlite))
+	for i := range mysql {
+		assert.Equal(t, mysql[i].Name, sqlite[i].Name)
+	}
+}
+
+func TestLoadUnknownDialect(t *testing.T) {
+	_, err := Load("oracle")
+	assert.Error(t, err)
+}
+
+func TestUpDown(t *testing.T) {
+	m := newTestMigrator(t)
+	require.NoError(t, m.Up())
+	current, err := m.Current()
+	require.NoError(t, err)
+	assert.Equal(t, m.Latest(), current)
+	assert.True(t, tableExists(t, m.DB, "task"))
+	assert.NoError(t, m.Check())
+
+	require.NoError(t, m.To(0))
+	current, err = m.Current()
+	require.NoError(t, err)
+	assert.Equal(t, 0, current)
+	assert.False(t, tableExists(t, m.DB, "task"))
+}
+
+func TestDown(t *testing.T) {
+	m := newTestMigrator(t)
+	require.NoError(t, m.Up())
+	require.NoError(t, m.Down())
+	current, err := m.Current()
+	require.NoError(t, err)
+	assert.Equal(t, m.Latest()-1, current)
+}
+
+func TestStatus(t *testing.T) {
+	m := newTestMigrator(t)
+	require.NoError(t, m.To(1))
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, m.Latest())
+	for _, s := range statuses {
+		assert.Equal(t, s.Version <= 1, s.Applied)
+	}
+}
+
+func TestPerChannelNumberingBackfill(t *testing.T) {
+	m := newTestMigrator(t)
+	require.NoError(t, m.To(4))
+	for _, channelID := range []string{"C1", "C2", "C1"} {
+		_, err := m.DB.Exec("INSERT INTO TASK (STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID) VALUES ('OPEN', 'task', '', ?)", channelID)
+		require.NoError(t, err)
+	}
+	require.NoError(t, m.Up())
+	rows, err := m.DB.Query("SELECT CHANNEL_ID, NUMBER FROM TASK ORDER BY ID")
+	require.NoError(t, err)
+	defer rows.Close()
+	numbers := make([]string, 0)
+	for rows.Next() {
+		var channelID string
+		var number int
+		require.NoError(t, rows.Scan(&channelID, &number))
+		numbers = append(numbers, fmt.Sprintf("%s:%d", channelID, number))
+	}
+	assert.Equal(t, []string{"C1:1", "C2:1", "C1:2"}, numbers)
+	var last int
+	require.NoError(t, m.DB.QueryRow("SELECT LAST_NUMBER FROM CHANNEL_SEQUENCE WHERE CHANNEL_ID = 'C1'").Scan(&last))
+	assert.Equal(t, 2, last)
+}
+
+func TestCanonicalAssignee(t *testing.T) {
+	m := newTestMigrator(t)
+	require.NoError(t, m.To(6))
+	for i, assignee := range []string{"<@U1ABC|bob>", "<@W2ABC>", "Not assigned", "@eve"} {
+		_, err := m.DB.Exec("INSERT INTO TASK (NUMBER, STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID) VALUES (?, 'OPEN', 'task', ?, 'C1')", i+1, assignee)
+		require.NoError(t, err)
+	}
+	assignees := func() []string {
+		rows, err := m.DB.Query("SELECT ASIGNEE_ID FROM TASK ORDER BY ID")
+		require.NoError(t, err)
+		defer rows.Close()
+		res := make([]string, 0)
+		for rows.Next() {
+			var assignee string
+			require.NoError(t, rows.Scan(&assignee))
+			res = append(res, assignee)
+		}
+		return res
+	}
+	require.NoError(t, m.To(7))
+	assert.Equal(t, []string{"U1ABC", "W2ABC", "", "@eve"}, assignees())
+	require.NoError(t, m.To(6))
+	assert.Equal(t, []string{"<@U1ABC>", "<@W2ABC>", "Not assigned", "@eve"}, assignees())
+}
+
+func TestTaskMetadataBackfill(t *testing.T) {
+	m := newTestMigrator(t)
+	require.NoError(t, m.To(9))
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= 2; i++ {
+		_, err := m.DB.Exec("INSERT INTO TASK (ID, NUMBER, STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID) VALUES (?, ?, 'OPEN', 'task', '', 'C1')", i, i)
+		require.NoError(t, err)
+	}
+	insertEvent := "INSERT INTO TASK_EVENT (TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE) VALUES (1, ?, ?, ?, '', '')"
+	_, err := m.DB.Exec(insertEvent, "U1ABC", created, "created")
+	require.NoError(t, err)
+	_, err = m.DB.Exec(insertEvent, "U2ABC", created.Add(time.Hour), "status")
+	require.NoError(t, err)
+	require.NoError(t, m.To(10))
+	var creatorID string
+	var createdAt, updatedAt *time.Time
+	require.NoError(t, m.DB.QueryRow("SELECT CREATOR_ID, CREATED_AT, UPDATED_AT FROM TASK WHERE ID = 1").Scan(&creatorID, &createdAt, &updatedAt))
+	assert.Equal(t, "U1ABC", creatorID)
+	require.NotNil(t, createdAt)
+	require.NotNil(t, updatedAt)
+	assert.True(t, created.Equal(*createdAt))
+	assert.True(t, created.Add(time.Hour).Equal(*updatedAt))
+	require.NoError(t, m.DB.QueryRow("SELECT CREATOR_ID, CREATED_AT, UPDATED_AT FROM TASK WHERE ID = 2").Scan(&creatorID, &createdAt, &updatedAt))
+	assert.Equal(t, "", creatorID)
+	assert.Nil(t, createdAt)
+	assert.Nil(t, updatedAt)
+	require.NoError(t, m.To(9))
+}
+
+func TestToUnknownVersion(t *testing.T) {
+	m := newTestMigrator(t)
+	assert.Error(t, m.To(m.Latest()+1))
+	assert.Error(t, m.To(-1))
+}
+
+func TestCheckOutdated(t *testing.T) {
+	m := newTestMigrator(t)
+	assert.Equal(t, ErrSchemaOutdated, m.Check())
+}
+
+func TestCheckNewer(t *testing.T) {
+	m := newTestMigrator(t)
+	require.NoError(t, m.Up())
+	_, err := m.DB.Exec("INSERT INTO schema_version (VERSION) VALUES (?)", m.Latest()+1)
+	require.NoError(t, err)
+	assert.Equal(t, ErrSchemaNewer, m.Check())
+	assert.Equal(t, ErrSchemaNewer, m.Up())
+}
+
+func TestCheckUnknown(t *testing.T) {
+	m := newTestMigrator(t)
+	m.Migrations = append(m.Migrations, Migration{Version: m.Latest() + 1, Name: "extra", Up: "SELECT 1", Down: "SELECT 1"}, Migration{Version: m.Latest() + 2, Name: "extra", Up: "SELECT 1", Down: "SELECT 1"})
+	_, err := m.Current()
+	require.NoError(t, err)
+	_, err = m.DB.Exec("INSERT INTO schema_version (VERSION) VALUES (?)", m.Latest())
+	require.NoError(t, err)
+	assert.Equal(t, ErrSchemaUnknown, m.Check())
+}
+
+func TestFailedMigrationIsNotRecorded(t *testing.T) {
+	m := newTestMigrator(t)
+	m.Migrations = []Migration{{Version: 1, Name: "broken", Up: "CREATE TABLE a (ID INT); NOT SQL", Down: "DROP TABLE a"}}
+	assert.Error(t, m.Up())
+	current, err := m.Current()
+	require.NoError(t, err)
+	assert.Equal(t, 0, current)
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements("CREATE TABLE a (ID INT);\n\nCREATE INDEX i ON a (ID);\n")
+	assert.Equal(t, []string{"CREATE TABLE a (ID INT)", "CREATE INDEX i ON a (ID)"}, statements)
+}
@@ -1,7 +1,7 @@
-CREATE TABLE task (
+CREATE TABLE IF NOT EXISTS task (
 	ID INT UNSIGNED AUTO_INCREMENT NOT NULL PRIMARY KEY,
 	STATUS VARCHAR(60) NOT NULL,
 	TITLE VARCHAR(60) NOT NULL,
 	ASIGNEE_ID VARCHAR(60) NOT NULL,
 	CHANNEL_ID VARCHAR(60) NOT NULL
-);
\ No newline at end of file
+);
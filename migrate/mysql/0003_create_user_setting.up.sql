@@ -0,0 +1,4 @@
+CREATE TABLE user_setting (
+	USER_ID VARCHAR(60) NOT NULL PRIMARY KEY,
+	TIMEZONE VARCHAR(60) NOT NULL
+);
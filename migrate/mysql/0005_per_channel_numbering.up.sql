@@ -0,0 +1,10 @@
+CREATE TABLE channel_sequence (
+	CHANNEL_ID VARCHAR(60) NOT NULL PRIMARY KEY,
+	LAST_NUMBER INT UNSIGNED NOT NULL
+);
+ALTER TABLE task ADD COLUMN NUMBER INT UNSIGNED NOT NULL DEFAULT 0;
+UPDATE task t JOIN (
+	SELECT a.ID, COUNT(*) AS N FROM task a JOIN task b ON b.CHANNEL_ID = a.CHANNEL_ID AND b.ID <= a.ID GROUP BY a.ID
+) n ON n.ID = t.ID SET t.NUMBER = n.N;
+INSERT INTO channel_sequence (CHANNEL_ID, LAST_NUMBER) SELECT CHANNEL_ID, MAX(NUMBER) FROM task GROUP BY CHANNEL_ID;
+CREATE UNIQUE INDEX task_channel_number ON task (CHANNEL_ID, NUMBER);
@@ -0,0 +1,2 @@
+UPDATE task SET ASIGNEE_ID = 'Not assigned' WHERE ASIGNEE_ID = '';
+UPDATE task SET ASIGNEE_ID = CONCAT('<@', ASIGNEE_ID, '>') WHERE REGEXP_LIKE(ASIGNEE_ID, '^[UW][A-Z0-9]{2,}$', 'c');
@@ -0,0 +1,2 @@
+UPDATE task SET ASIGNEE_ID = SUBSTRING_INDEX(SUBSTRING(ASIGNEE_ID, 3, CHAR_LENGTH(ASIGNEE_ID) - 3), '|', 1) WHERE ASIGNEE_ID LIKE '<@%>';
+UPDATE task SET ASIGNEE_ID = '' WHERE ASIGNEE_ID = 'Not assigned';
@@ -0,0 +1,10 @@
+CREATE TABLE task_event (
+	ID INT UNSIGNED AUTO_INCREMENT NOT NULL PRIMARY KEY,
+	TASK_ID INT UNSIGNED NOT NULL,
+	ACTOR_ID VARCHAR(60) NOT NULL,
+	CREATED_AT DATETIME NOT NULL,
+	FIELD VARCHAR(20) NOT NULL,
+	OLD_VALUE VARCHAR(255) NOT NULL,
+	NEW_VALUE VARCHAR(255) NOT NULL
+);
+CREATE INDEX task_event_task ON task_event (TASK_ID);
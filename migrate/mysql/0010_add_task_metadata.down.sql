@@ -0,0 +1,3 @@
+ALTER TABLE task DROP COLUMN UPDATED_AT;
+ALTER TABLE task DROP COLUMN CREATED_AT;
+ALTER TABLE task DROP COLUMN CREATOR_ID;
@@ -0,0 +1,7 @@
+ALTER TABLE task ADD COLUMN CREATOR_ID VARCHAR(60) NOT NULL DEFAULT '';
+ALTER TABLE task ADD COLUMN CREATED_AT DATETIME NULL;
+ALTER TABLE task ADD COLUMN UPDATED_AT DATETIME NULL;
+UPDATE task t JOIN task_event e ON e.TASK_ID = t.ID AND e.FIELD = 'created' SET t.CREATOR_ID = e.ACTOR_ID, t.CREATED_AT = e.CREATED_AT;
+UPDATE task t JOIN (
+	SELECT TASK_ID, MAX(CREATED_AT) AS LAST_CHANGE FROM task_event GROUP BY TASK_ID
+) e ON e.TASK_ID = t.ID SET t.UPDATED_AT = e.LAST_CHANGE;
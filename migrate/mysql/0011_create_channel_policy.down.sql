@@ -0,0 +1,2 @@
+DROP TABLE channel_admin;
+DROP TABLE channel_policy;
@@ -0,0 +1 @@
+ALTER TABLE task ADD COLUMN PRIORITY INT NOT NULL DEFAULT 0;
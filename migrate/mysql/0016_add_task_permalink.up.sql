@@ -0,0 +1 @@
+ALTER TABLE task ADD COLUMN PERMALINK VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE task ADD COLUMN DESCRIPTION VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+CREATE INDEX task_assignee ON task (ASIGNEE_ID);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS task (
+	ID INTEGER PRIMARY KEY AUTOINCREMENT,
+	STATUS VARCHAR(60) NOT NULL,
+	TITLE VARCHAR(60) NOT NULL,
+	ASIGNEE_ID VARCHAR(60) NOT NULL,
+	CHANNEL_ID VARCHAR(60) NOT NULL
+);
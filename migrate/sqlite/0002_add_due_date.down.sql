@@ -0,0 +1 @@
+ALTER TABLE task DROP COLUMN DUE_DATE;
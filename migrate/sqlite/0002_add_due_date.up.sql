@@ -0,0 +1 @@
+ALTER TABLE task ADD COLUMN DUE_DATE DATETIME NULL;
@@ -0,0 +1 @@
+ALTER TABLE task ADD COLUMN DELETED BOOLEAN NOT NULL DEFAULT FALSE;
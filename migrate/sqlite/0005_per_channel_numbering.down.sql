@@ -0,0 +1,3 @@
+DROP INDEX task_channel_number;
+ALTER TABLE task DROP COLUMN NUMBER;
+DROP TABLE channel_sequence;
@@ -0,0 +1,8 @@
+CREATE TABLE channel_sequence (
+	CHANNEL_ID VARCHAR(60) NOT NULL PRIMARY KEY,
+	LAST_NUMBER INTEGER NOT NULL
+);
+ALTER TABLE task ADD COLUMN NUMBER INTEGER NOT NULL DEFAULT 0;
+UPDATE task SET NUMBER = (SELECT COUNT(*) FROM task b WHERE b.CHANNEL_ID = task.CHANNEL_ID AND b.ID <= task.ID);
+INSERT INTO channel_sequence (CHANNEL_ID, LAST_NUMBER) SELECT CHANNEL_ID, MAX(NUMBER) FROM task GROUP BY CHANNEL_ID;
+CREATE UNIQUE INDEX task_channel_number ON task (CHANNEL_ID, NUMBER);
@@ -0,0 +1 @@
+ALTER TABLE task ADD COLUMN COMPLETED_AT DATETIME NULL;
@@ -0,0 +1,2 @@
+UPDATE task SET ASIGNEE_ID = 'Not assigned' WHERE ASIGNEE_ID = '';
+UPDATE task SET ASIGNEE_ID = '<@' || ASIGNEE_ID || '>' WHERE ASIGNEE_ID GLOB '[UW][A-Z0-9][A-Z0-9]*' AND ASIGNEE_ID NOT GLOB '*[^A-Z0-9]*';
@@ -0,0 +1,5 @@
+UPDATE task SET ASIGNEE_ID = CASE
+	WHEN instr(ASIGNEE_ID, '|') > 0 THEN substr(ASIGNEE_ID, 3, instr(ASIGNEE_ID, '|') - 3)
+	ELSE substr(ASIGNEE_ID, 3, length(ASIGNEE_ID) - 3)
+END WHERE ASIGNEE_ID LIKE '<@%>';
+UPDATE task SET ASIGNEE_ID = '' WHERE ASIGNEE_ID = 'Not assigned';
@@ -0,0 +1,4 @@
+CREATE TABLE channel_setting (
+	CHANNEL_ID VARCHAR(60) NOT NULL PRIMARY KEY,
+	VISIBILITY VARCHAR(20) NOT NULL
+);
@@ -0,0 +1,7 @@
+ALTER TABLE task ADD COLUMN CREATOR_ID VARCHAR(60) NOT NULL DEFAULT '';
+ALTER TABLE task ADD COLUMN CREATED_AT DATETIME NULL;
+ALTER TABLE task ADD COLUMN UPDATED_AT DATETIME NULL;
+UPDATE task SET
+	CREATOR_ID = COALESCE((SELECT e.ACTOR_ID FROM task_event e WHERE e.TASK_ID = task.ID AND e.FIELD = 'created'), ''),
+	CREATED_AT = (SELECT e.CREATED_AT FROM task_event e WHERE e.TASK_ID = task.ID AND e.FIELD = 'created'),
+	UPDATED_AT = (SELECT MAX(e.CREATED_AT) FROM task_event e WHERE e.TASK_ID = task.ID);
@@ -0,0 +1,9 @@
+CREATE TABLE channel_policy (
+	CHANNEL_ID VARCHAR(60) NOT NULL PRIMARY KEY,
+	POLICY VARCHAR(20) NOT NULL
+);
+CREATE TABLE channel_admin (
+	CHANNEL_ID VARCHAR(60) NOT NULL,
+	USER_ID VARCHAR(60) NOT NULL,
+	PRIMARY KEY (CHANNEL_ID, USER_ID)
+);
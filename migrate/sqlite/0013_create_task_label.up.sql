@@ -0,0 +1,6 @@
+CREATE TABLE task_label (
+	TASK_ID INTEGER NOT NULL,
+	LABEL VARCHAR(60) NOT NULL,
+	PRIMARY KEY (TASK_ID, LABEL)
+);
+CREATE INDEX task_label_label ON task_label (LABEL);
@@ -0,0 +1,2 @@
+DROP TABLE channel_workflow_transition;
+DROP TABLE channel_workflow_state;
@@ -0,0 +1,14 @@
+CREATE TABLE channel_workflow_state (
+	CHANNEL_ID VARCHAR(60) NOT NULL,
+	POSITION INT NOT NULL,
+	NAME VARCHAR(60) NOT NULL,
+	EMOJI VARCHAR(60) NOT NULL,
+	TERMINAL BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (CHANNEL_ID, POSITION)
+);
+CREATE TABLE channel_workflow_transition (
+	CHANNEL_ID VARCHAR(60) NOT NULL,
+	FROM_STATE VARCHAR(60) NOT NULL,
+	TO_STATE VARCHAR(60) NOT NULL,
+	PRIMARY KEY (CHANNEL_ID, FROM_STATE, TO_STATE)
+);
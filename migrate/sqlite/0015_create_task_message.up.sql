@@ -0,0 +1,6 @@
+CREATE TABLE task_message (
+	CHANNEL_ID VARCHAR(60) NOT NULL,
+	TS VARCHAR(32) NOT NULL,
+	TASK_ID INTEGER NOT NULL,
+	PRIMARY KEY (CHANNEL_ID, TS)
+);
@@ -0,0 +1 @@
+DROP INDEX task_assignee;
@@ -0,0 +1,254 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Visibility of the responses in a channel, set with /tododo-visibility.
+// VisibilityDefault keeps the visibility decided by each command, VisibilityPrivate shows the responses only to the user who sent the command
+// and VisibilityPublic posts them to the channel.
+const (
+	VisibilityDefault = "default"
+	VisibilityPrivate = "private"
+	VisibilityPublic  = "public"
+)
+
+// Permission policy of a channel, set with /tododo-policy. Task admins of the channel may change every task.
+// PolicyOpen lets everybody change every task, PolicyAssignee lets the assignee and the creator change a task
+// and PolicyAdmins lets only task admins change tasks.
+const (
+	PolicyOpen     = "open"
+	PolicyAssignee = "assignee"
+	PolicyAdmins   = "admins"
+)
+
+// WorkflowState is a status tasks of a channel can have. Emoji is shown next to the tasks in the state, e.g. ":eyes:".
+// Tasks moved to a Terminal state are finished, they get a completion time and are hidden from the task list by default.
+type WorkflowState struct {
+	Name     string
+	Emoji    string
+	Terminal bool
+}
+
+// WorkflowTransition allows moving a task from the state named From to the state named To.
+type WorkflowTransition struct {
+	From string
+	To   string
+}
+
+// Workflow is the set of states of the tasks in a channel, kept in tables CHANNEL_WORKFLOW_STATE and CHANNEL_WORKFLOW_TRANSITION.
+// States are ordered, new tasks start in the first one. Tasks can only be moved along Transitions.
+type Workflow struct {
+	States      []*WorkflowState
+	Transitions []*WorkflowTransition
+}
+
+// ChannelSettingRepositoryInterface provides functions for database operation execution on tables CHANNEL_SETTING, CHANNEL_POLICY, CHANNEL_ADMIN,
+// CHANNEL_WORKFLOW_STATE and CHANNEL_WORKFLOW_TRANSITION
+type ChannelSettingRepositoryInterface interface {
+	GetVisibility(ctx context.Context, channelID string) (string, error)
+	SetVisibility(ctx context.Context, channelID string, visibility string) error
+	GetPolicy(ctx context.Context, channelID string) (string, error)
+	SetPolicy(ctx context.Context, channelID string, policy string) error
+	GetAdmins(ctx context.Context, channelID string) ([]string, error)
+	AddAdmin(ctx context.Context, channelID string, userID string) error
+	RemoveAdmin(ctx context.Context, channelID string, userID string) error
+	GetWorkflow(ctx context.Context, channelID string) (*Workflow, error)
+	SetWorkflow(ctx context.Context, channelID string, workflow *Workflow) error
+}
+
+// ChannelSettingRepository implements ChannelSettingRepositoryInterface
+type ChannelSettingRepository struct {
+	DB *sql.DB
+}
+
+// GetVisibility returns the visibility of the responses set for the channel.
+// Returns VisibilityDefault if the channel has no setting.
+func (repo *ChannelSettingRepository) GetVisibility(ctx context.Context, channelID string) (string, error) {
+	query := "SELECT VISIBILITY FROM CHANNEL_SETTING WHERE CHANNEL_ID = ?"
+	var visibility string
+	err := repo.DB.QueryRowContext(ctx, query, channelID).Scan(&visibility)
+	if err == sql.ErrNoRows {
+		return VisibilityDefault, nil
+	}
+	return visibility, err
+}
+
+// SetVisibility saves the visibility of the responses in the channel, replacing the previous one.
+func (repo *ChannelSettingRepository) SetVisibility(ctx context.Context, channelID string, visibility string) error {
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "DELETE FROM CHANNEL_SETTING WHERE CHANNEL_ID = ?", channelID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "INSERT INTO CHANNEL_SETTING (CHANNEL_ID, VISIBILITY) VALUES (?,?)", channelID, visibility)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
+
+// GetPolicy returns the permission policy set for the channel.
+// Returns PolicyOpen if the channel has no policy.
+func (repo *ChannelSettingRepository) GetPolicy(ctx context.Context, channelID string) (string, error) {
+	query := "SELECT POLICY FROM CHANNEL_POLICY WHERE CHANNEL_ID = ?"
+	var policy string
+	err := repo.DB.QueryRowContext(ctx, query, channelID).Scan(&policy)
+	if err == sql.ErrNoRows {
+		return PolicyOpen, nil
+	}
+	return policy, err
+}
+
+// SetPolicy saves the permission policy of the channel, replacing the previous one.
+func (repo *ChannelSettingRepository) SetPolicy(ctx context.Context, channelID string, policy string) error {
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "DELETE FROM CHANNEL_POLICY WHERE CHANNEL_ID = ?", channelID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "INSERT INTO CHANNEL_POLICY (CHANNEL_ID, POLICY) VALUES (?,?)", channelID, policy)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
+
+// GetAdmins returns the Slack user IDs of the task admins of the channel ordered by ID.
+// Returns empty list if the channel has no task admins.
+func (repo *ChannelSettingRepository) GetAdmins(ctx context.Context, channelID string) ([]string, error) {
+	rows, err := repo.DB.QueryContext(ctx, "SELECT USER_ID FROM CHANNEL_ADMIN WHERE CHANNEL_ID = ? ORDER BY USER_ID", channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	admins := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		err = rows.Scan(&userID)
+		if err != nil {
+			return nil, err
+		}
+		admins = append(admins, userID)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
+
+// AddAdmin makes the user a task admin of the channel. Adding an admin twice has no effect.
+func (repo *ChannelSettingRepository) AddAdmin(ctx context.Context, channelID string, userID string) error {
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "DELETE FROM CHANNEL_ADMIN WHERE CHANNEL_ID = ? AND USER_ID = ?", channelID, userID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "INSERT INTO CHANNEL_ADMIN (CHANNEL_ID, USER_ID) VALUES (?,?)", channelID, userID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
+
+// RemoveAdmin removes the user from the task admins of the channel. Removing a user who is not an admin has no effect.
+func (repo *ChannelSettingRepository) RemoveAdmin(ctx context.Context, channelID string, userID string) error {
+	_, err := repo.DB.ExecContext(ctx, "DELETE FROM CHANNEL_ADMIN WHERE CHANNEL_ID = ? AND USER_ID = ?", channelID, userID)
+	return err
+}
+
+// GetWorkflow returns the workflow of the channel with the states in order and the transitions ordered by state and target.
+// Returns nil if the channel has no workflow.
+func (repo *ChannelSettingRepository) GetWorkflow(ctx context.Context, channelID string) (*Workflow, error) {
+	rows, err := repo.DB.QueryContext(ctx, "SELECT NAME, EMOJI, TERMINAL FROM CHANNEL_WORKFLOW_STATE WHERE CHANNEL_ID = ? ORDER BY POSITION", channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	workflow := Workflow{States: make([]*WorkflowState, 0), Transitions: make([]*WorkflowTransition, 0)}
+	for rows.Next() {
+		var state WorkflowState
+		err = rows.Scan(&state.Name, &state.Emoji, &state.Terminal)
+		if err != nil {
+			return nil, err
+		}
+		workflow.States = append(workflow.States, &state)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if len(workflow.States) == 0 {
+		return nil, nil
+	}
+	transitions, err := repo.DB.QueryContext(ctx, "SELECT FROM_STATE, TO_STATE FROM CHANNEL_WORKFLOW_TRANSITION WHERE CHANNEL_ID = ? ORDER BY FROM_STATE, TO_STATE", channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer transitions.Close()
+	for transitions.Next() {
+		var transition WorkflowTransition
+		err = transitions.Scan(&transition.From, &transition.To)
+		if err != nil {
+			return nil, err
+		}
+		workflow.Transitions = append(workflow.Transitions, &transition)
+	}
+	err = transitions.Err()
+	if err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// SetWorkflow saves the workflow of the channel, replacing the previous one. A nil workflow removes it.
+func (repo *ChannelSettingRepository) SetWorkflow(ctx context.Context, channelID string, workflow *Workflow) error {
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "DELETE FROM CHANNEL_WORKFLOW_STATE WHERE CHANNEL_ID = ?", channelID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "DELETE FROM CHANNEL_WORKFLOW_TRANSITION WHERE CHANNEL_ID = ?", channelID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	if workflow != nil {
+		for i, state := range workflow.States {
+			_, err = txn.ExecContext(ctx, "INSERT INTO CHANNEL_WORKFLOW_STATE (CHANNEL_ID, POSITION, NAME, EMOJI, TERMINAL) VALUES (?,?,?,?,?)", channelID, i, state.Name, state.Emoji, state.Terminal)
+			if err != nil {
+				txn.Rollback()
+				return err
+			}
+		}
+		for _, transition := range workflow.Transitions {
+			_, err = txn.ExecContext(ctx, "INSERT INTO CHANNEL_WORKFLOW_TRANSITION (CHANNEL_ID, FROM_STATE, TO_STATE) VALUES (?,?,?)", channelID, transition.From, transition.To)
+			if err != nil {
+				txn.Rollback()
+				return err
+			}
+		}
+	}
+	return txn.Commit()
+}
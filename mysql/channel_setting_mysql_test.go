@@ -0,0 +1,231 @@
+package mysql
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetVisibility(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"VISIBILITY"}).AddRow(VisibilityPublic)
+	mock.ExpectQuery("SELECT VISIBILITY FROM CHANNEL_SETTING WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnRows(rows)
+	mockService := &ChannelSettingRepository{db}
+	res, err := mockService.GetVisibility(ctx, "C1")
+	assert.NoError(t, err)
+	assert.Equal(t, VisibilityPublic, res)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetVisibilityNotSet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT VISIBILITY FROM CHANNEL_SETTING WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnRows(sqlmock.NewRows([]string{"VISIBILITY"}))
+	mockService := &ChannelSettingRepository{db}
+	res, err := mockService.GetVisibility(ctx, "C1")
+	assert.NoError(t, err)
+	assert.Equal(t, VisibilityDefault, res)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetVisibility(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM CHANNEL_SETTING WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO CHANNEL_SETTING \\(CHANNEL_ID, VISIBILITY\\) VALUES \\(\\?,\\?\\)").WithArgs("C1", VisibilityPrivate).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mockService := &ChannelSettingRepository{db}
+	err = mockService.SetVisibility(ctx, "C1", VisibilityPrivate)
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetPolicyNotSet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT POLICY FROM CHANNEL_POLICY WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnRows(sqlmock.NewRows([]string{"POLICY"}))
+	mockService := &ChannelSettingRepository{db}
+	res, err := mockService.GetPolicy(ctx, "C1")
+	assert.NoError(t, err)
+	assert.Equal(t, PolicyOpen, res)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetPolicy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM CHANNEL_POLICY WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO CHANNEL_POLICY \\(CHANNEL_ID, POLICY\\) VALUES \\(\\?,\\?\\)").WithArgs("C1", PolicyAssignee).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mockService := &ChannelSettingRepository{db}
+	err = mockService.SetPolicy(ctx, "C1", PolicyAssignee)
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetAdmins(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"USER_ID"}).AddRow("U1").AddRow("U2")
+	mock.ExpectQuery("SELECT USER_ID FROM CHANNEL_ADMIN WHERE CHANNEL_ID = \\? ORDER BY USER_ID").WithArgs("C1").WillReturnRows(rows)
+	mock.ExpectQuery("SELECT USER_ID FROM CHANNEL_ADMIN WHERE CHANNEL_ID = \\? ORDER BY USER_ID").WithArgs("C2").WillReturnRows(sqlmock.NewRows([]string{"USER_ID"}))
+	mockService := &ChannelSettingRepository{db}
+	res, err := mockService.GetAdmins(ctx, "C1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"U1", "U2"}, res)
+	res, err = mockService.GetAdmins(ctx, "C2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, res)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestAddAdmin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM CHANNEL_ADMIN WHERE CHANNEL_ID = \\? AND USER_ID = \\?").WithArgs("C1", "U1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO CHANNEL_ADMIN \\(CHANNEL_ID, USER_ID\\) VALUES \\(\\?,\\?\\)").WithArgs("C1", "U1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mockService := &ChannelSettingRepository{db}
+	err = mockService.AddAdmin(ctx, "C1", "U1")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestRemoveAdmin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.ExpectExec("DELETE FROM CHANNEL_ADMIN WHERE CHANNEL_ID = \\? AND USER_ID = \\?").WithArgs("C1", "U1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockService := &ChannelSettingRepository{db}
+	err = mockService.RemoveAdmin(ctx, "C1", "U1")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetWorkflow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	states := sqlmock.NewRows([]string{"NAME", "EMOJI", "TERMINAL"}).AddRow("Open", ":question:", false).AddRow("Done", ":white_check_mark:", true)
+	transitions := sqlmock.NewRows([]string{"FROM_STATE", "TO_STATE"}).AddRow("Done", "Open").AddRow("Open", "Done")
+	mock.ExpectQuery("SELECT NAME, EMOJI, TERMINAL FROM CHANNEL_WORKFLOW_STATE WHERE CHANNEL_ID = \\? ORDER BY POSITION").WithArgs("C1").WillReturnRows(states)
+	mock.ExpectQuery("SELECT FROM_STATE, TO_STATE FROM CHANNEL_WORKFLOW_TRANSITION WHERE CHANNEL_ID = \\? ORDER BY FROM_STATE, TO_STATE").WithArgs("C1").WillReturnRows(transitions)
+	mockService := &ChannelSettingRepository{db}
+	res, err := mockService.GetWorkflow(ctx, "C1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Workflow{
+		States:      []*WorkflowState{{Name: "Open", Emoji: ":question:"}, {Name: "Done", Emoji: ":white_check_mark:", Terminal: true}},
+		Transitions: []*WorkflowTransition{{From: "Done", To: "Open"}, {From: "Open", To: "Done"}},
+	}, res)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetWorkflowNotSet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT NAME, EMOJI, TERMINAL FROM CHANNEL_WORKFLOW_STATE WHERE CHANNEL_ID = \\? ORDER BY POSITION").WithArgs("C1").WillReturnRows(sqlmock.NewRows([]string{"NAME", "EMOJI", "TERMINAL"}))
+	mockService := &ChannelSettingRepository{db}
+	res, err := mockService.GetWorkflow(ctx, "C1")
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetWorkflow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM CHANNEL_WORKFLOW_STATE WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM CHANNEL_WORKFLOW_TRANSITION WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnResult(sqlmock.NewResult(0, 6))
+	mock.ExpectExec("INSERT INTO CHANNEL_WORKFLOW_STATE \\(CHANNEL_ID, POSITION, NAME, EMOJI, TERMINAL\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)").WithArgs("C1", 0, "Open", ":question:", false).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO CHANNEL_WORKFLOW_STATE \\(CHANNEL_ID, POSITION, NAME, EMOJI, TERMINAL\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)").WithArgs("C1", 1, "Done", ":white_check_mark:", true).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO CHANNEL_WORKFLOW_TRANSITION \\(CHANNEL_ID, FROM_STATE, TO_STATE\\) VALUES \\(\\?,\\?,\\?\\)").WithArgs("C1", "Open", "Done").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mockService := &ChannelSettingRepository{db}
+	err = mockService.SetWorkflow(ctx, "C1", &Workflow{
+		States:      []*WorkflowState{{Name: "Open", Emoji: ":question:"}, {Name: "Done", Emoji: ":white_check_mark:", Terminal: true}},
+		Transitions: []*WorkflowTransition{{From: "Open", To: "Done"}},
+	})
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetWorkflowReset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM CHANNEL_WORKFLOW_STATE WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM CHANNEL_WORKFLOW_TRANSITION WHERE CHANNEL_ID = \\?").WithArgs("C1").WillReturnResult(sqlmock.NewResult(0, 6))
+	mock.ExpectCommit()
+	mockService := &ChannelSettingRepository{db}
+	err = mockService.SetWorkflow(ctx, "C1", nil)
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
@@ -0,0 +1,47 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/repotest"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// TestConformance runs the repository conformance suite against a live MySQL database.
+// Set TODODO_TEST_MYSQL_DSN to a database migrated with "migrate up", e.g. the one from docker-compose. Every test truncates the table.
+func TestConformance(t *testing.T) {
+	dsn, exists := os.LookupEnv("TODODO_TEST_MYSQL_DSN")
+	if !exists {
+		t.Skip("TODODO_TEST_MYSQL_DSN not set in environment")
+	}
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	repotest.RunConformance(t, func(t *testing.T) mysql.TaskRepositoryInterface {
+		_, err := db.Exec("TRUNCATE TABLE TASK")
+		require.NoError(t, err)
+		_, err = db.Exec("TRUNCATE TABLE CHANNEL_SEQUENCE")
+		require.NoError(t, err)
+		_, err = db.Exec("TRUNCATE TABLE TASK_EVENT")
+		require.NoError(t, err)
+		_, err = db.Exec("TRUNCATE TABLE TASK_LABEL")
+		require.NoError(t, err)
+		_, err = db.Exec("TRUNCATE TABLE TASK_MESSAGE")
+		require.NoError(t, err)
+		return &mysql.TaskRepository{DB: db}
+	})
+	repotest.RunUserSettingConformance(t, func(t *testing.T) mysql.UserSettingRepositoryInterface {
+		_, err := db.Exec("TRUNCATE TABLE USER_SETTING")
+		require.NoError(t, err)
+		return &mysql.UserSettingRepository{DB: db}
+	})
+	repotest.RunChannelSettingConformance(t, func(t *testing.T) mysql.ChannelSettingRepositoryInterface {
+		_, err := db.Exec("TRUNCATE TABLE CHANNEL_WORKFLOW_STATE")
+		require.NoError(t, err)
+		_, err = db.Exec("TRUNCATE TABLE CHANNEL_WORKFLOW_TRANSITION")
+		require.NoError(t, err)
+		return &mysql.ChannelSettingRepository{DB: db}
+	})
+}
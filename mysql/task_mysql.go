@@ -2,172 +2,916 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 	//SQL Driver
 	_ "github.com/go-sql-driver/mysql"
 )
 
-// String constants to set to Status in task database table.
+// String constants to set to Status in task database table, the states of channels without a workflow of their own.
 const (
 	StatusOpen       = "Open"
 	StatusInProgress = "In Progress"
 	StatusDone       = "Done"
 )
 
-// Task entity to represent database records
+// Priorities of Task, PriorityP1 is the highest. PriorityNone is the priority of tasks without one.
+const (
+	PriorityNone = 0
+	PriorityP1   = 1
+	PriorityP2   = 2
+	PriorityP3   = 3
+	PriorityP4   = 4
+)
+
+// Sort orders of TaskFilter.
+const (
+	SortPriority = "priority"
+	SortNumber   = "number"
+	SortDue      = "due"
+)
+
+// Fields of TaskEvent.
+const (
+	FieldCreated     = "created"
+	FieldStatus      = "status"
+	FieldAssignee    = "assignee"
+	FieldTitle       = "title"
+	FieldDeleted     = "deleted"
+	FieldPriority    = "priority"
+	FieldLabels      = "labels"
+	FieldDescription = "description"
+	FieldDue         = "due"
+)
+
+// Task entity to represent database records.
+// ID is unique in the database, Number is unique in the channel and is the one shown to users.
+// AsigneeID is the Slack user ID of the assignee, empty if the task is not assigned. CreatorID is the Slack user ID of the user who added the task.
+// CreatedAt is set when the task is persisted and UpdatedAt on every change, both are nil for tasks added before they were recorded.
+// CompletedAt is set when the task is moved to a terminal status of the workflow of the channel, e.g. StatusDone, and cleared when it is reopened. Priority is PriorityNone or one of PriorityP1 to PriorityP4.
+// Labels are kept in table TASK_LABEL ordered by name, nil if the task has none.
+// Permalink is the link to the Slack message the task was added from, empty if it was added with a command.
+// Description is the longer text of the task entered in the task modal, at most MaxDescriptionLength characters, empty if there is none.
 type Task struct {
+	ID          int
+	Number      int
+	Status      string
+	Priority    int
+	Title       string
+	AsigneeID   string
+	ChannelID   string
+	CreatorID   string
+	DueDate     *time.Time
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+	CompletedAt *time.Time
+	Deleted     bool
+	Labels      []string
+	Permalink   string
+	Description string
+}
+
+// TaskEvent is a change of a task recorded in table TASK_EVENT. ActorID is the Slack user ID of the user who made the change.
+// OldValue and NewValue are the values of Field before and after the change, e.g. the statuses or the assignee IDs.
+// FieldCreated has the title as new value, FieldDeleted has "0" and "1" for deleting and the reverse for restoring, FieldPriority has the priorities as numbers.
+// FieldLabels has the ordered labels separated by spaces, FieldDue has the due dates formatted by FormatDue.
+type TaskEvent struct {
 	ID        int
-	Status    string
-	Title     string
-	AsigneeID string
-	ChannelID string
+	TaskID    int
+	ActorID   string
+	CreatedAt time.Time
+	Field     string
+	OldValue  string
+	NewValue  string
+}
+
+// TaskFilter selects tasks of a channel for FindTasks. Empty fields don't filter.
+// Statuses matches any of the statuses, Labels matches tasks with all of the labels, CompletedSince matches tasks completed at or after the time.
+// Sort is SortPriority, SortNumber or SortDue. SortPriority puts the highest priority first and tasks without priority last,
+// SortDue puts tasks without due date last. Ties are ordered by number, so the order is stable between pages.
+// Limit is the maximum number of tasks returned after skipping the first Offset tasks. All tasks are returned and Offset is ignored if Limit is 0.
+type TaskFilter struct {
+	ChannelID      string
+	Statuses       []string
+	AsigneeID      string
+	Labels         []string
+	CompletedSince *time.Time
+	Sort           string
+	Limit          int
+	Offset         int
+}
+
+// TaskUpdate changes several fields of a task at once with UpdateTask. Fields which are nil stay the same.
+// The due date is set to DueDate, nil removing it, only if SetDue is true.
+type TaskUpdate struct {
+	Title       *string
+	AsigneeID   *string
+	SetDue      bool
+	DueDate     *time.Time
+	Priority    *int
+	Description *string
+}
+
+// Changes returns the fields of t which update changes to a different value as events with Field, OldValue and NewValue,
+// in the order title, assignee, due date, priority and description.
+func (update *TaskUpdate) Changes(t *Task) []*TaskEvent {
+	changes := make([]*TaskEvent, 0)
+	add := func(field string, oldValue string, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, &TaskEvent{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	if update.Title != nil {
+		add(FieldTitle, t.Title, *update.Title)
+	}
+	if update.AsigneeID != nil {
+		add(FieldAssignee, t.AsigneeID, *update.AsigneeID)
+	}
+	if update.SetDue {
+		add(FieldDue, FormatDue(t.DueDate), FormatDue(update.DueDate))
+	}
+	if update.Priority != nil {
+		add(FieldPriority, strconv.Itoa(t.Priority), strconv.Itoa(*update.Priority))
+	}
+	if update.Description != nil {
+		add(FieldDescription, t.Description, *update.Description)
+	}
+	return changes
+}
+
+// LabelCount is the number of tasks with Label in a channel.
+type LabelCount struct {
+	Label string
+	Count int
+}
+
+// MaxTitleLength is the length of column TITLE, longer titles are rejected or shortened before a task is saved.
+const MaxTitleLength = 60
+
+// MaxDescriptionLength is the length of column DESCRIPTION, the same as of the values of TASK_EVENT, so changes of descriptions are recorded whole.
+const MaxDescriptionLength = 255
+
+// eventColumns are the columns of table TASK_EVENT in the order scanned by GetTaskEvents
+const eventColumns = "E.ID, E.TASK_ID, E.ACTOR_ID, E.CREATED_AT, E.FIELD, E.OLD_VALUE, E.NEW_VALUE"
+
+// taskColumns are the columns of table TASK in the order scanned by scanTask
+const taskColumns = "ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION"
+
+// MySQLSequenceQuery increments the task number sequence of a channel, starting from 1.
+const MySQLSequenceQuery = "INSERT INTO CHANNEL_SEQUENCE (CHANNEL_ID, LAST_NUMBER) VALUES (?, 1) ON DUPLICATE KEY UPDATE LAST_NUMBER = LAST_NUMBER + 1"
+
+// FormatDue returns due as recorded in TaskEvent, in RFC 3339 format in UTC or empty if due is nil.
+func FormatDue(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	return due.UTC().Format(time.RFC3339)
+}
+
+// Now returns the current time in UTC truncated to seconds, as stored by DATETIME columns. Repositories use it for CreatedAt, UpdatedAt and CompletedAt.
+func Now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
 }
 
 // ErrNoRowOrMoreThanOne database error when exactly 1 result is expected.
 var ErrNoRowOrMoreThanOne = errors.New("sql: Expected exactly one row to be affected")
 
-// NewTask constructs a task object. Pass title and channel id.
-// Default status: "Open", default asignee value: "Not assigned".
-func NewTask(title string, channelID string) *Task {
+// NewTask constructs a task object. Pass title, channel id and the Slack user ID of the user adding the task.
+// Default status: "Open", no priority, not assigned - empty asignee id, no due date, no labels.
+func NewTask(title string, channelID string, creatorID string) *Task {
 	task := Task{}
 	task.Status = StatusOpen
 	task.Title = title
-	task.AsigneeID = "Not assigned"
 	task.ChannelID = channelID
+	task.CreatorID = creatorID
 	return &task
 }
 
-// TaskRepositoryInterface provides functions for database operation execution on table TASK
+// TaskRepositoryInterface provides functions for database operation execution on table TASK.
+// Tasks are looked up by channel and number, so a channel can't reach tasks of another channel.
+// Every change of a task is recorded in table TASK_EVENT with actorID, the Slack user ID of the user who made it, the creation with the creator of the task.
+// The queries are cancelled when ctx is done.
 type TaskRepositoryInterface interface {
-	PersistTask(t *Task) error
-	GetTaskByID(ID int) (*Task, error)
-	GetAllInChannel(channelID string) ([]*Task, error)
-	AssignTaskTo(taskID int, assigneeID string) error
-	SetStatus(taskID int, status string) error
+	PersistTask(ctx context.Context, t *Task) error
+	GetTask(ctx context.Context, channelID string, number int) (*Task, error)
+	GetAllInChannel(ctx context.Context, channelID string) ([]*Task, error)
+	GetAllAssignedTo(ctx context.Context, assigneeID string) ([]*Task, error)
+	FindTasks(ctx context.Context, filter *TaskFilter) ([]*Task, error)
+	GetLabelCounts(ctx context.Context, channelID string) ([]*LabelCount, error)
+	AssignTaskTo(ctx context.Context, channelID string, number int, assigneeID string, actorID string) error
+	SetStatus(ctx context.Context, channelID string, number int, status string, terminal bool, actorID string) error
+	UpdateTitle(ctx context.Context, channelID string, number int, title string, actorID string) error
+	SetPriority(ctx context.Context, channelID string, number int, priority int, actorID string) error
+	UpdateLabels(ctx context.Context, channelID string, number int, added []string, removed []string, actorID string) error
+	UpdateDescription(ctx context.Context, channelID string, number int, description string, actorID string) error
+	SetDueDate(ctx context.Context, channelID string, number int, due *time.Time, actorID string) error
+	UpdateTask(ctx context.Context, channelID string, number int, update *TaskUpdate, actorID string) error
+	DeleteTask(ctx context.Context, channelID string, number int, actorID string) error
+	RestoreTask(ctx context.Context, channelID string, number int, actorID string) error
+	GetTaskEvents(ctx context.Context, channelID string, number int) ([]*TaskEvent, error)
+	AddTaskMessage(ctx context.Context, channelID string, number int, ts string) error
+	GetTaskByMessage(ctx context.Context, channelID string, ts string) (*Task, error)
 }
 
-// TaskRepository implements TaskRepositoryInterface
+// TaskRepository implements TaskRepositoryInterface.
+// SequenceQuery increments the number sequence of a channel, MySQLSequenceQuery if empty.
 type TaskRepository struct {
-	DB *sql.DB
+	DB            *sql.DB
+	SequenceQuery string
 }
 
-// PersistTask saves task in database.
-// Task id is automatically incremented.
-func (repo *TaskRepository) PersistTask(t *Task) error {
-	query := "INSERT INTO TASK (STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID) VALUES (?,?,?,?)"
+// PersistTask saves task in database and records its creation by t.CreatorID.
+// Task id is automatically incremented and set to t.ID, the next number in the channel is set to t.Number. Creation and update time are set to now.
+// The labels are saved in table TASK_LABEL and their ordered set is set to t.Labels.
+func (repo *TaskRepository) PersistTask(ctx context.Context, t *Task) error {
+	sequenceQuery := repo.SequenceQuery
+	if sequenceQuery == "" {
+		sequenceQuery = MySQLSequenceQuery
+	}
+	query := "INSERT INTO TASK (NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, PERMALINK, DESCRIPTION) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)"
+	now := Now()
 
-	txn, err := repo.DB.Begin()
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = txn.ExecContext(ctx, sequenceQuery, t.ChannelID)
 	if err != nil {
 		txn.Rollback()
 		return err
 	}
-	stmt, err := repo.DB.Prepare(query)
+	var number int
+	err = txn.QueryRowContext(ctx, "SELECT LAST_NUMBER FROM CHANNEL_SEQUENCE WHERE CHANNEL_ID = ?", t.ChannelID).Scan(&number)
 	if err != nil {
+		txn.Rollback()
 		return err
 	}
-	defer stmt.Close()
+	result, err := txn.ExecContext(ctx, query, number, t.Status, t.Priority, t.Title, t.AsigneeID, t.ChannelID, t.CreatorID, t.DueDate, now, now, t.Permalink, t.Description)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	_, err = txn.ExecContext(ctx, insertEventQuery, id, t.CreatorID, now, FieldCreated, "", t.Title)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	labels := ApplyLabels(nil, t.Labels, nil)
+	for _, label := range labels {
+		_, err = txn.ExecContext(ctx, insertLabelQuery, id, label)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+	if len(labels) > 0 {
+		_, err = txn.ExecContext(ctx, insertEventQuery, id, t.CreatorID, now, FieldLabels, "", strings.Join(labels, " "))
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+	err = txn.Commit()
+	if err != nil {
+		return err
+	}
+	t.ID = int(id)
+	t.Number = number
+	t.Labels = labels
+	t.CreatedAt = &now
+	t.UpdatedAt = &now
+	return nil
+}
+
+// GetTask returns reference to the task with this number in the channel, deleted tasks included.
+// Return error if there is no such task.
+func (repo *TaskRepository) GetTask(ctx context.Context, channelID string, number int) (*Task, error) {
+	query := "SELECT " + taskColumns + " FROM TASK WHERE CHANNEL_ID = ? AND NUMBER = ?"
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
 	defer txn.Commit()
+	stmt, err := txn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	task, err := scanTask(stmt.QueryRowContext(ctx, channelID, number))
+	if err != nil {
+		return nil, err
+	}
+	err = loadLabels(ctx, txn, []*Task{task})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
 
-	_, err = stmt.Exec(t.Status, t.Title, t.AsigneeID, t.ChannelID)
-	return err
+// GetAllInChannel accepts channel ID and returns all tasks in the specified channel ordered by number. Deleted tasks are not returned.
+func (repo *TaskRepository) GetAllInChannel(ctx context.Context, channelID string) ([]*Task, error) {
+	query := "SELECT " + taskColumns + " FROM TASK WHERE CHANNEL_ID = ? AND DELETED = 0 ORDER BY NUMBER"
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer txn.Commit()
+	stmt, err := txn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, loadLabels(ctx, txn, tasks)
 }
 
-// GetTaskByID returns reference to a task with this id.
-// Return error if there is no such task.
-func (repo *TaskRepository) GetTaskByID(ID int) (*Task, error) {
-	query := "SELECT ID, STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID FROM TASK WHERE ID = ?"
-	txn, err := repo.DB.Begin()
+// GetAllAssignedTo accepts a Slack user ID and returns the tasks assigned to the user in all channels ordered by channel and number.
+// Deleted tasks are not returned.
+func (repo *TaskRepository) GetAllAssignedTo(ctx context.Context, assigneeID string) ([]*Task, error) {
+	query := "SELECT " + taskColumns + " FROM TASK WHERE ASIGNEE_ID = ? AND DELETED = 0 ORDER BY CHANNEL_ID, NUMBER"
+	txn, err := repo.DB.BeginTx(ctx, nil)
 	if err != nil {
-		txn.Rollback()
 		return nil, err
 	}
 	defer txn.Commit()
-	stmt, err := repo.DB.Prepare(query)
+	stmt, err := txn.PrepareContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	var task Task
-	err = stmt.QueryRow(ID).Scan(&task.ID, &task.Status, &task.Title, &task.AsigneeID, &task.ChannelID)
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, assigneeID)
 	if err != nil {
 		return nil, err
 	}
-	return &task, nil
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, loadLabels(ctx, txn, tasks)
 }
 
-// GetAllInChannel accepts channel ID and returns all tasks in the specified channel
-func (repo *TaskRepository) GetAllInChannel(channelID string) ([]*Task, error) {
-	query := "SELECT ID, STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID FROM TASK WHERE CHANNEL_ID = ?"
-	txn, err := repo.DB.Begin()
+// FindTasks returns the tasks matching filter. Deleted tasks are not returned.
+// The query is built from filter with placeholders only, values are never part of the SQL text.
+func (repo *TaskRepository) FindTasks(ctx context.Context, filter *TaskFilter) ([]*Task, error) {
+	query, args := filterQuery(filter)
+	txn, err := repo.DB.BeginTx(ctx, nil)
 	if err != nil {
-		txn.Rollback()
 		return nil, err
 	}
 	defer txn.Commit()
-	stmt, err := repo.DB.Prepare(query)
+	stmt, err := txn.PrepareContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	rows, err := stmt.Query(channelID)
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
-	tasks := make([]*Task, 0)
-	for rows.Next() {
-		var task Task
-		err = rows.Scan(&task.ID, &task.Status, &task.Title, &task.AsigneeID, &task.ChannelID)
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, loadLabels(ctx, txn, tasks)
+}
+
+// filterQuery builds the parameterized select of FindTasks and its arguments
+func filterQuery(filter *TaskFilter) (string, []interface{}) {
+	conditions := []string{"CHANNEL_ID = ?", "DELETED = 0"}
+	args := []interface{}{filter.ChannelID}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "STATUS IN (?"+strings.Repeat(",?", len(filter.Statuses)-1)+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.AsigneeID != "" {
+		conditions = append(conditions, "ASIGNEE_ID = ?")
+		args = append(args, filter.AsigneeID)
+	}
+	for _, label := range filter.Labels {
+		conditions = append(conditions, "ID IN (SELECT TASK_ID FROM TASK_LABEL WHERE LABEL = ?)")
+		args = append(args, label)
+	}
+	if filter.CompletedSince != nil {
+		conditions = append(conditions, "COMPLETED_AT >= ?")
+		args = append(args, filter.CompletedSince.UTC())
+	}
+	order := "NUMBER"
+	switch filter.Sort {
+	case SortPriority:
+		order = "PRIORITY = 0, PRIORITY, NUMBER"
+	case SortDue:
+		order = "DUE_DATE IS NULL, DUE_DATE, NUMBER"
+	}
+	query := "SELECT " + taskColumns + " FROM TASK WHERE " + strings.Join(conditions, " AND ") + " ORDER BY " + order
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	return query, args
+}
+
+// AssignTaskTo sets the assigneeID to assigneeID of the task with this number in the channel. Returns error if there is no such task or it is deleted.
+func (repo *TaskRepository) AssignTaskTo(ctx context.Context, channelID string, number int, assigneeID string, actorID string) error {
+	return repo.applyChange(ctx, &change{
+		channelID: channelID,
+		number:    number,
+		actorID:   actorID,
+		field:     FieldAssignee,
+		column:    "ASIGNEE_ID",
+		value:     assigneeID,
+		update:    "UPDATE TASK SET UPDATED_AT = ?, ASIGNEE_ID = ? WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0",
+		args:      []interface{}{assigneeID},
+		selected:  "DELETED = 0",
+	})
+}
+
+// SetStatus sets the status to status of the task with this number in the channel. Returns error if there is no such task or it is deleted.
+// Terminal tells whether status finishes the task in the workflow of the channel.
+// Completion time is set to now when the task reaches a terminal status for the first time and cleared when it is reopened.
+func (repo *TaskRepository) SetStatus(ctx context.Context, channelID string, number int, status string, terminal bool, actorID string) error {
+	c := change{
+		channelID: channelID,
+		number:    number,
+		actorID:   actorID,
+		field:     FieldStatus,
+		column:    "STATUS",
+		value:     status,
+		update:    "UPDATE TASK SET UPDATED_AT = ?, STATUS = ?, COMPLETED_AT = NULL WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0",
+		args:      []interface{}{status},
+		selected:  "DELETED = 0",
+	}
+	if terminal {
+		c.update = "UPDATE TASK SET UPDATED_AT = ?, STATUS = ?, COMPLETED_AT = COALESCE(COMPLETED_AT, ?) WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0"
+		c.args = []interface{}{status, Now()}
+	}
+	return repo.applyChange(ctx, &c)
+}
+
+// UpdateTitle sets the title to title of the task with this number in the channel. Returns error if there is no such task or it is deleted.
+func (repo *TaskRepository) UpdateTitle(ctx context.Context, channelID string, number int, title string, actorID string) error {
+	return repo.applyChange(ctx, &change{
+		channelID: channelID,
+		number:    number,
+		actorID:   actorID,
+		field:     FieldTitle,
+		column:    "TITLE",
+		value:     title,
+		update:    "UPDATE TASK SET UPDATED_AT = ?, TITLE = ? WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0",
+		args:      []interface{}{title},
+		selected:  "DELETED = 0",
+	})
+}
+
+// SetPriority sets the priority to priority of the task with this number in the channel. Returns error if there is no such task or it is deleted.
+func (repo *TaskRepository) SetPriority(ctx context.Context, channelID string, number int, priority int, actorID string) error {
+	return repo.applyChange(ctx, &change{
+		channelID: channelID,
+		number:    number,
+		actorID:   actorID,
+		field:     FieldPriority,
+		column:    "PRIORITY",
+		value:     strconv.Itoa(priority),
+		update:    "UPDATE TASK SET UPDATED_AT = ?, PRIORITY = ? WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0",
+		args:      []interface{}{priority},
+		selected:  "DELETED = 0",
+	})
+}
+
+// UpdateDescription sets the description to description of the task with this number in the channel. Returns error if there is no such task or it is deleted.
+func (repo *TaskRepository) UpdateDescription(ctx context.Context, channelID string, number int, description string, actorID string) error {
+	return repo.applyChange(ctx, &change{
+		channelID: channelID,
+		number:    number,
+		actorID:   actorID,
+		field:     FieldDescription,
+		column:    "DESCRIPTION",
+		value:     description,
+		update:    "UPDATE TASK SET UPDATED_AT = ?, DESCRIPTION = ? WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0",
+		args:      []interface{}{description},
+		selected:  "DELETED = 0",
+	})
+}
+
+// SetDueDate sets the due date to due of the task with this number in the channel, nil removes it.
+// The change is recorded unless the due date stays the same. Returns error if there is no such task or it is deleted.
+func (repo *TaskRepository) SetDueDate(ctx context.Context, channelID string, number int, due *time.Time, actorID string) error {
+	now := Now()
+	var value interface{}
+	if due != nil {
+		value = due.UTC()
+	}
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	task := Task{}
+	err = txn.QueryRowContext(ctx, "SELECT ID, DUE_DATE FROM TASK WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0", channelID, number).Scan(&task.ID, &task.DueDate)
+	if err == sql.ErrNoRows {
+		txn.Rollback()
+		return ErrNoRowOrMoreThanOne
+	} else if err != nil {
+		txn.Rollback()
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "UPDATE TASK SET UPDATED_AT = ?, DUE_DATE = ? WHERE ID = ?", now, value, task.ID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	oldValue := FormatDue(task.DueDate)
+	newValue := FormatDue(due)
+	if oldValue != newValue {
+		_, err = txn.ExecContext(ctx, insertEventQuery, task.ID, actorID, now, FieldDue, oldValue, newValue)
 		if err != nil {
-			return nil, err
+			txn.Rollback()
+			return err
 		}
-		tasks = append(tasks, &task)
 	}
-	return tasks, nil
+	return txn.Commit()
 }
 
-// AssignTaskTo sets the assigneeID to assigneeID of the task with ID taskID. Returns error if there is no task with ID taskID.
-func (repo *TaskRepository) AssignTaskTo(taskID int, assigneeID string) error {
-	query := "UPDATE TASK SET ASIGNEE_ID = ? WHERE ID = ?"
+// UpdateTask changes the fields of update of the task with this number in the channel in one transaction, so either all of them change or none.
+// Every field which gets a different value is recorded. Returns error if there is no such task or it is deleted.
+func (repo *TaskRepository) UpdateTask(ctx context.Context, channelID string, number int, update *TaskUpdate, actorID string) error {
+	now := Now()
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	task, err := scanTask(txn.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM TASK WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0", channelID, number))
+	if err == sql.ErrNoRows {
+		txn.Rollback()
+		return ErrNoRowOrMoreThanOne
+	} else if err != nil {
+		txn.Rollback()
+		return err
+	}
+	columns := []string{"UPDATED_AT = ?"}
+	args := []interface{}{now}
+	if update.Title != nil {
+		columns = append(columns, "TITLE = ?")
+		args = append(args, *update.Title)
+	}
+	if update.AsigneeID != nil {
+		columns = append(columns, "ASIGNEE_ID = ?")
+		args = append(args, *update.AsigneeID)
+	}
+	if update.SetDue {
+		var due interface{}
+		if update.DueDate != nil {
+			due = update.DueDate.UTC()
+		}
+		columns = append(columns, "DUE_DATE = ?")
+		args = append(args, due)
+	}
+	if update.Priority != nil {
+		columns = append(columns, "PRIORITY = ?")
+		args = append(args, *update.Priority)
+	}
+	if update.Description != nil {
+		columns = append(columns, "DESCRIPTION = ?")
+		args = append(args, *update.Description)
+	}
+	_, err = txn.ExecContext(ctx, "UPDATE TASK SET "+strings.Join(columns, ", ")+" WHERE ID = ?", append(args, task.ID)...)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	for _, c := range update.Changes(task) {
+		_, err = txn.ExecContext(ctx, insertEventQuery, task.ID, actorID, now, c.Field, c.OldValue, c.NewValue)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+	return txn.Commit()
+}
 
-	txn, err := repo.DB.Begin()
+// UpdateLabels removes the labels in removed from the task with this number in the channel and adds the labels in added, refer to ApplyLabels.
+// The change is recorded unless the labels stay the same. Returns error if there is no such task or it is deleted.
+func (repo *TaskRepository) UpdateLabels(ctx context.Context, channelID string, number int, added []string, removed []string, actorID string) error {
+	now := Now()
+	txn, err := repo.DB.BeginTx(ctx, nil)
 	if err != nil {
+		return err
+	}
+	task := Task{}
+	err = txn.QueryRowContext(ctx, "SELECT ID FROM TASK WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0", channelID, number).Scan(&task.ID)
+	if err == sql.ErrNoRows {
+		txn.Rollback()
+		return ErrNoRowOrMoreThanOne
+	} else if err != nil {
 		txn.Rollback()
 		return err
 	}
-	stmt, err := repo.DB.Prepare(query)
+	err = loadLabels(ctx, txn, []*Task{&task})
 	if err != nil {
+		txn.Rollback()
 		return err
 	}
-	defer stmt.Close()
-	defer txn.Commit()
+	labels := ApplyLabels(task.Labels, added, removed)
+	for _, label := range task.Labels {
+		if !containsLabel(labels, label) {
+			_, err = txn.ExecContext(ctx, "DELETE FROM TASK_LABEL WHERE TASK_ID = ? AND LABEL = ?", task.ID, label)
+			if err != nil {
+				txn.Rollback()
+				return err
+			}
+		}
+	}
+	for _, label := range labels {
+		if !containsLabel(task.Labels, label) {
+			_, err = txn.ExecContext(ctx, insertLabelQuery, task.ID, label)
+			if err != nil {
+				txn.Rollback()
+				return err
+			}
+		}
+	}
+	_, err = txn.ExecContext(ctx, "UPDATE TASK SET UPDATED_AT = ? WHERE ID = ?", now, task.ID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	oldValue := strings.Join(task.Labels, " ")
+	newValue := strings.Join(labels, " ")
+	if oldValue != newValue {
+		_, err = txn.ExecContext(ctx, insertEventQuery, task.ID, actorID, now, FieldLabels, oldValue, newValue)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+	return txn.Commit()
+}
 
-	result, err := stmt.Exec(assigneeID, taskID)
-	rows, err := result.RowsAffected()
-	if rows != 1 {
+// GetLabelCounts returns the labels of the tasks in the channel with the number of tasks having each label, ordered by label.
+// Deleted tasks are not counted. Returns empty list if no task in the channel has labels.
+func (repo *TaskRepository) GetLabelCounts(ctx context.Context, channelID string) ([]*LabelCount, error) {
+	query := "SELECT L.LABEL, COUNT(*) FROM TASK_LABEL L JOIN TASK T ON T.ID = L.TASK_ID WHERE T.CHANNEL_ID = ? AND T.DELETED = 0 GROUP BY L.LABEL ORDER BY L.LABEL"
+	rows, err := repo.DB.QueryContext(ctx, query, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make([]*LabelCount, 0)
+	for rows.Next() {
+		var count LabelCount
+		err = rows.Scan(&count.Label, &count.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+	return counts, rows.Err()
+}
+
+// ApplyLabels returns the ordered set of labels without the labels in removed and with the labels in added, nil if it is empty.
+// A label both removed and added is kept. The slices passed are not changed.
+func ApplyLabels(labels []string, added []string, removed []string) []string {
+	set := make(map[string]bool)
+	for _, label := range labels {
+		set[label] = true
+	}
+	for _, label := range removed {
+		delete(set, label)
+	}
+	for _, label := range added {
+		set[label] = true
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(set))
+	for label := range set {
+		res = append(res, label)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// containsLabel reports whether label is one of labels
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteTask marks the task with this number in the channel as deleted. The row is kept, so the task can be restored.
+// Returns error if there is no such task or it is already deleted.
+func (repo *TaskRepository) DeleteTask(ctx context.Context, channelID string, number int, actorID string) error {
+	return repo.applyChange(ctx, &change{
+		channelID: channelID,
+		number:    number,
+		actorID:   actorID,
+		field:     FieldDeleted,
+		column:    "DELETED",
+		value:     "1",
+		update:    "UPDATE TASK SET UPDATED_AT = ?, DELETED = 1 WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 0",
+		selected:  "DELETED = 0",
+	})
+}
+
+// RestoreTask undoes DeleteTask. Returns error if there is no deleted task with this number in the channel.
+func (repo *TaskRepository) RestoreTask(ctx context.Context, channelID string, number int, actorID string) error {
+	return repo.applyChange(ctx, &change{
+		channelID: channelID,
+		number:    number,
+		actorID:   actorID,
+		field:     FieldDeleted,
+		column:    "DELETED",
+		value:     "0",
+		update:    "UPDATE TASK SET UPDATED_AT = ?, DELETED = 0 WHERE CHANNEL_ID = ? AND NUMBER = ? AND DELETED = 1",
+		selected:  "DELETED = 1",
+	})
+}
+
+// GetTaskEvents returns the changes of the task with this number in the channel, deleted tasks included, oldest first.
+// Returns empty list if there is no such task.
+func (repo *TaskRepository) GetTaskEvents(ctx context.Context, channelID string, number int) ([]*TaskEvent, error) {
+	query := "SELECT " + eventColumns + " FROM TASK_EVENT E JOIN TASK T ON T.ID = E.TASK_ID WHERE T.CHANNEL_ID = ? AND T.NUMBER = ? ORDER BY E.ID"
+	rows, err := repo.DB.QueryContext(ctx, query, channelID, number)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := make([]*TaskEvent, 0)
+	for rows.Next() {
+		var event TaskEvent
+		err = rows.Scan(&event.ID, &event.TaskID, &event.ActorID, &event.CreatedAt, &event.Field, &event.OldValue, &event.NewValue)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// AddTaskMessage records that the bot message with timestamp ts in the channel shows the task with this number, in table TASK_MESSAGE.
+// Returns ErrNoRowOrMoreThanOne if there is no such task.
+func (repo *TaskRepository) AddTaskMessage(ctx context.Context, channelID string, number int, ts string) error {
+	query := "INSERT INTO TASK_MESSAGE (CHANNEL_ID, TS, TASK_ID) SELECT CHANNEL_ID, ?, ID FROM TASK WHERE CHANNEL_ID = ? AND NUMBER = ?"
+	result, err := repo.DB.ExecContext(ctx, query, ts, channelID, number)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
 		return ErrNoRowOrMoreThanOne
 	}
-	return err
+	return nil
 }
 
-// SetStatus sets the status to status of the task with ID taskID. Returns error if there is no task with ID taskID.
-func (repo *TaskRepository) SetStatus(taskID int, status string) error {
-	query := "UPDATE TASK SET STATUS = ? WHERE ID = ?"
+// GetTaskByMessage returns reference to the task shown by the bot message with timestamp ts in the channel, deleted tasks included.
+// Returns sql.ErrNoRows if the message doesn't show a task.
+func (repo *TaskRepository) GetTaskByMessage(ctx context.Context, channelID string, ts string) (*Task, error) {
+	query := "SELECT T.NUMBER FROM TASK_MESSAGE M JOIN TASK T ON T.ID = M.TASK_ID WHERE M.CHANNEL_ID = ? AND M.TS = ?"
+	var number int
+	err := repo.DB.QueryRowContext(ctx, query, channelID, ts).Scan(&number)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetTask(ctx, channelID, number)
+}
+
+// insertEventQuery records a change of a task.
+const insertEventQuery = "INSERT INTO TASK_EVENT (TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE) VALUES (?,?,?,?,?,?)"
+
+// insertLabelQuery adds a label to a task.
+const insertLabelQuery = "INSERT INTO TASK_LABEL (TASK_ID, LABEL) VALUES (?,?)"
+
+// change is an update of one field of a task, recorded in TASK_EVENT.
+// The old value is read from column, update takes the update time, args, channel ID and number, selected is the condition the task must match.
+type change struct {
+	channelID string
+	number    int
+	actorID   string
+	field     string
+	column    string
+	value     string
+	update    string
+	args      []interface{}
+	selected  string
+}
 
-	txn, err := repo.DB.Begin()
+// applyChange records c and executes its update in one transaction. Nothing is recorded if the value doesn't change.
+// Returns ErrNoRowOrMoreThanOne if not exactly one row is updated.
+func (repo *TaskRepository) applyChange(ctx context.Context, c *change) error {
+	record := "INSERT INTO TASK_EVENT (TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE) SELECT ID, ?, ?, ?, " + c.column + ", ? FROM TASK WHERE CHANNEL_ID = ? AND NUMBER = ? AND " + c.selected + " AND " + c.column + " <> ?"
+	now := Now()
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = txn.ExecContext(ctx, record, c.actorID, now, c.field, c.value, c.channelID, c.number, c.value)
 	if err != nil {
 		txn.Rollback()
 		return err
 	}
-	stmt, err := repo.DB.Prepare(query)
+	args := append(append([]interface{}{now}, c.args...), c.channelID, c.number)
+	result, err := txn.ExecContext(ctx, c.update, args...)
 	if err != nil {
+		txn.Rollback()
 		return err
 	}
-	defer stmt.Close()
-	defer txn.Commit()
-
-	result, err := stmt.Exec(status, taskID)
 	rows, err := result.RowsAffected()
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
 	if rows != 1 {
+		txn.Rollback()
 		return ErrNoRowOrMoreThanOne
 	}
-	return err
+	return txn.Commit()
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask reads a task selected with taskColumns
+func scanTask(row rowScanner) (*Task, error) {
+	var task Task
+	err := row.Scan(&task.ID, &task.Number, &task.Status, &task.Priority, &task.Title, &task.AsigneeID, &task.ChannelID, &task.CreatorID, &task.DueDate, &task.CreatedAt, &task.UpdatedAt, &task.CompletedAt, &task.Deleted, &task.Permalink, &task.Description)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// scanTasks reads all rows selected with taskColumns and closes them
+func scanTasks(rows *sql.Rows) ([]*Task, error) {
+	defer rows.Close()
+	tasks := make([]*Task, 0)
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadLabels reads the labels of tasks from table TASK_LABEL in one query and sets them ordered to Task.Labels.
+func loadLabels(ctx context.Context, q querier, tasks []*Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	byID := make(map[int]*Task)
+	args := make([]interface{}, 0)
+	for _, task := range tasks {
+		byID[task.ID] = task
+		args = append(args, task.ID)
+	}
+	query := "SELECT TASK_ID, LABEL FROM TASK_LABEL WHERE TASK_ID IN (?" + strings.Repeat(",?", len(tasks)-1) + ") ORDER BY LABEL"
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var label string
+		err = rows.Scan(&id, &label)
+		if err != nil {
+			return err
+		}
+		if task, ok := byID[id]; ok {
+			task.Labels = append(task.Labels, label)
+		}
+	}
+	return rows.Err()
 }
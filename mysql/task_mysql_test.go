@@ -2,18 +2,25 @@ package mysql
 
 // refer to https://medium.com/easyread/unit-test-sql-in-golang-5af19075e68e blog
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
+var ctx = context.Background()
+
 var task = &Task{
 	ID:        1,
+	Number:    3,
 	Status:    StatusOpen,
 	Title:     "Manual test of ui",
 	AsigneeID: "U123",
 	ChannelID: "C123",
+	CreatorID: "U9",
 }
 
 func TestPersistTask(t *testing.T) {
@@ -24,30 +31,60 @@ func TestPersistTask(t *testing.T) {
 	defer db.Close()
 	mock.MatchExpectationsInOrder(true)
 	mock.ExpectBegin()
-	mock.ExpectPrepare("INSERT INTO TASK \\(STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID\\) VALUES \\(\\?,\\?,\\?,\\?\\)").ExpectExec().WithArgs(task.Status, task.Title, task.AsigneeID, task.ChannelID).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO CHANNEL_SEQUENCE \\(CHANNEL_ID, LAST_NUMBER\\) VALUES \\(\\?, 1\\) ON DUPLICATE KEY UPDATE LAST_NUMBER = LAST_NUMBER \\+ 1").WithArgs(task.ChannelID).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT LAST_NUMBER FROM CHANNEL_SEQUENCE WHERE CHANNEL_ID = \\?").WithArgs(task.ChannelID).WillReturnRows(sqlmock.NewRows([]string{"LAST_NUMBER"}).AddRow(task.Number))
+	mock.ExpectExec("INSERT INTO TASK \\(NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, PERMALINK, DESCRIPTION\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?,\\?\\)").WithArgs(task.Number, task.Status, task.Priority, task.Title, task.AsigneeID, task.ChannelID, task.CreatorID, task.DueDate, sqlmock.AnyArg(), sqlmock.AnyArg(), task.Permalink, task.Description).WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").WithArgs(7, "U9", sqlmock.AnyArg(), FieldCreated, "", task.Title).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
-	mockService := &TaskRepository{db}
-	err = mockService.PersistTask(task)
+	mockService := &TaskRepository{DB: db}
+	newTask := NewTask(task.Title, task.ChannelID, task.CreatorID)
+	newTask.AsigneeID = task.AsigneeID
+	err = mockService.PersistTask(ctx, newTask)
 	assert.NoError(t, err)
+	assert.Equal(t, 7, newTask.ID)
+	assert.Equal(t, task.Number, newTask.Number)
+	if assert.NotNil(t, newTask.CreatedAt) && assert.NotNil(t, newTask.UpdatedAt) {
+		assert.WithinDuration(t, time.Now(), *newTask.CreatedAt, time.Minute)
+		assert.Equal(t, *newTask.CreatedAt, *newTask.UpdatedAt)
+	}
 	if err = mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Expectations were not met: %s", err)
 	}
 }
 
-func TestGetTaskByID(t *testing.T) {
+func TestPersistTaskSequenceQuery(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Errorf("Failed to open sqlmock database: Error %s", err)
 	}
 	defer db.Close()
-	rows := sqlmock.NewRows([]string{"ID", "STATUS", "TITLE", "ASIGNEE_ID", "CHANNEL_ID"}).
-		AddRow(task.ID, task.Status, task.Title, task.AsigneeID, task.ChannelID)
 	mock.MatchExpectationsInOrder(true)
 	mock.ExpectBegin()
-	mock.ExpectPrepare("SELECT ID, STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID FROM TASK WHERE ID = \\?").ExpectQuery().WithArgs(task.ID).WillReturnRows(rows)
+	mock.ExpectExec("CUSTOM SEQUENCE").WithArgs(task.ChannelID).WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	mockService := &TaskRepository{DB: db, SequenceQuery: "CUSTOM SEQUENCE"}
+	err = mockService.PersistTask(ctx, NewTask(task.Title, task.ChannelID, task.CreatorID))
+	assert.Equal(t, sql.ErrConnDone, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"ID", "NUMBER", "STATUS", "PRIORITY", "TITLE", "ASIGNEE_ID", "CHANNEL_ID", "CREATOR_ID", "DUE_DATE", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT", "DELETED", "PERMALINK", "DESCRIPTION"}).
+		AddRow(task.ID, task.Number, task.Status, task.Priority, task.Title, task.AsigneeID, task.ChannelID, task.CreatorID, task.DueDate, task.CreatedAt, task.UpdatedAt, task.CompletedAt, task.Deleted, task.Permalink, task.Description)
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\?").ExpectQuery().WithArgs(task.ChannelID, task.Number).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT TASK_ID, LABEL FROM TASK_LABEL WHERE TASK_ID IN \\(\\?\\) ORDER BY LABEL").WithArgs(task.ID).WillReturnRows(sqlmock.NewRows([]string{"TASK_ID", "LABEL"}))
 	mock.ExpectCommit()
-	mockService := &TaskRepository{db}
-	res, err := mockService.GetTaskByID(task.ID)
+	mockService := &TaskRepository{DB: db}
+	res, err := mockService.GetTask(ctx, task.ChannelID, task.Number)
 	if assert.NoError(t, err) {
 		assert.NotNil(t, res)
 		assert.EqualValues(t, res, task)
@@ -57,19 +94,19 @@ func TestGetTaskByID(t *testing.T) {
 	}
 }
 
-func TestGetTaskByIDError(t *testing.T) {
+func TestGetTaskError(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Errorf("Failed to open sqlmock database: Error %s", err)
 	}
 	defer db.Close()
-	rows := sqlmock.NewRows([]string{"ID", "STATUS", "TITLE", "ASIGNEE_ID", "CHANNEL_ID"})
+	rows := sqlmock.NewRows([]string{"ID", "NUMBER", "STATUS", "PRIORITY", "TITLE", "ASIGNEE_ID", "CHANNEL_ID", "CREATOR_ID", "DUE_DATE", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT", "DELETED", "PERMALINK", "DESCRIPTION"})
 	mock.MatchExpectationsInOrder(true)
 	mock.ExpectBegin()
-	mock.ExpectPrepare("SELECT ID, STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID FROM TASK WHERE ID = \\?").ExpectQuery().WithArgs(task.ID).WillReturnRows(rows)
+	mock.ExpectPrepare("SELECT ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\?").ExpectQuery().WithArgs(task.ChannelID, task.Number).WillReturnRows(rows)
 	mock.ExpectCommit()
-	mockService := &TaskRepository{db}
-	res, err := mockService.GetTaskByID(task.ID)
+	mockService := &TaskRepository{DB: db}
+	res, err := mockService.GetTask(ctx, task.ChannelID, task.Number)
 	expectedError := sql.ErrNoRows
 	if assert.Error(t, err) {
 		assert.Nil(t, res)
@@ -86,18 +123,52 @@ func TestGetAllInChannel(t *testing.T) {
 		t.Errorf("Failed to open sqlmock database: Error %s", err)
 	}
 	defer db.Close()
-	rows := sqlmock.NewRows([]string{"ID", "STATUS", "TITLE", "ASIGNEE_ID", "CHANNEL_ID"}).
-		AddRow(task.ID, task.Status, task.Title, task.AsigneeID, task.ChannelID).
-		AddRow(2, task.Status, task.Title, task.AsigneeID, task.ChannelID)
+	due := time.Date(2026, 11, 2, 17, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"ID", "NUMBER", "STATUS", "PRIORITY", "TITLE", "ASIGNEE_ID", "CHANNEL_ID", "CREATOR_ID", "DUE_DATE", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT", "DELETED", "PERMALINK", "DESCRIPTION"}).
+		AddRow(task.ID, task.Number, task.Status, task.Priority, task.Title, task.AsigneeID, task.ChannelID, task.CreatorID, task.DueDate, task.CreatedAt, task.UpdatedAt, task.CompletedAt, task.Deleted, task.Permalink, task.Description).
+		AddRow(2, 4, task.Status, PriorityP1, task.Title, task.AsigneeID, task.ChannelID, "", &due, nil, nil, nil, false, "", "")
 	mock.MatchExpectationsInOrder(true)
 	mock.ExpectBegin()
-	mock.ExpectPrepare("SELECT ID, STATUS, TITLE, ASIGNEE_ID, CHANNEL_ID FROM TASK WHERE CHANNEL_ID = \\?").ExpectQuery().WithArgs(task.ChannelID).WillReturnRows(rows)
+	mock.ExpectPrepare("SELECT ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION FROM TASK WHERE CHANNEL_ID = \\? AND DELETED = 0 ORDER BY NUMBER").ExpectQuery().WithArgs(task.ChannelID).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT TASK_ID, LABEL FROM TASK_LABEL WHERE TASK_ID IN \\(\\?,\\?\\) ORDER BY LABEL").WithArgs(task.ID, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"TASK_ID", "LABEL"}).AddRow(2, "infra").AddRow(2, "q4"))
 	mock.ExpectCommit()
-	mockService := &TaskRepository{db}
-	res, err := mockService.GetAllInChannel(task.ChannelID)
+	mockService := &TaskRepository{DB: db}
+	res, err := mockService.GetAllInChannel(ctx, task.ChannelID)
 	if assert.NoError(t, err) {
 		assert.NotNil(t, res)
 		assert.Equal(t, 2, len(res))
+		assert.Nil(t, res[0].DueDate)
+		assert.Equal(t, due, *res[1].DueDate)
+		assert.Nil(t, res[0].Labels)
+		assert.Equal(t, []string{"infra", "q4"}, res[1].Labels)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetAllAssignedTo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"ID", "NUMBER", "STATUS", "PRIORITY", "TITLE", "ASIGNEE_ID", "CHANNEL_ID", "CREATOR_ID", "DUE_DATE", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT", "DELETED", "PERMALINK", "DESCRIPTION"}).
+		AddRow(task.ID, task.Number, task.Status, task.Priority, task.Title, task.AsigneeID, task.ChannelID, task.CreatorID, task.DueDate, task.CreatedAt, task.UpdatedAt, task.CompletedAt, task.Deleted, task.Permalink, task.Description).
+		AddRow(2, 1, task.Status, PriorityNone, task.Title, task.AsigneeID, "C2", "", nil, nil, nil, nil, false, "", "")
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION FROM TASK WHERE ASIGNEE_ID = \\? AND DELETED = 0 ORDER BY CHANNEL_ID, NUMBER").ExpectQuery().WithArgs(task.AsigneeID).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT TASK_ID, LABEL FROM TASK_LABEL WHERE TASK_ID IN \\(\\?,\\?\\) ORDER BY LABEL").WithArgs(task.ID, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"TASK_ID", "LABEL"}))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	res, err := mockService.GetAllAssignedTo(ctx, task.AsigneeID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(res))
+		assert.Equal(t, task.ChannelID, res[0].ChannelID)
+		assert.Equal(t, "C2", res[1].ChannelID)
 	}
 	if err = mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Expectations were not met: %s", err)
@@ -112,10 +183,11 @@ func TestAssignTaskTo(t *testing.T) {
 	defer db.Close()
 	mock.MatchExpectationsInOrder(true)
 	mock.ExpectBegin()
-	mock.ExpectPrepare("UPDATE TASK SET ASIGNEE_ID = \\? WHERE ID = \\?").ExpectExec().WithArgs(task.AsigneeID, task.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, ASIGNEE_ID, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND ASIGNEE_ID <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldAssignee, task.AsigneeID, task.ChannelID, task.Number, task.AsigneeID).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, ASIGNEE_ID = \\? WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), task.AsigneeID, task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
-	mockService := &TaskRepository{db}
-	err = mockService.AssignTaskTo(task.ID, task.AsigneeID)
+	mockService := &TaskRepository{DB: db}
+	err = mockService.AssignTaskTo(ctx, task.ChannelID, task.Number, task.AsigneeID, "U9")
 	assert.NoError(t, err)
 	if err = mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Expectations were not met: %s", err)
@@ -130,10 +202,11 @@ func TestAssignTaskErrNoRow(t *testing.T) {
 	defer db.Close()
 	mock.MatchExpectationsInOrder(true)
 	mock.ExpectBegin()
-	mock.ExpectPrepare("UPDATE TASK SET ASIGNEE_ID = \\? WHERE ID = \\?").ExpectExec().WithArgs(task.AsigneeID, 57).WillReturnResult(sqlmock.NewResult(0, 0))
-	mock.ExpectCommit()
-	mockService := &TaskRepository{db}
-	err = mockService.AssignTaskTo(57, task.AsigneeID)
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, ASIGNEE_ID, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND ASIGNEE_ID <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldAssignee, task.AsigneeID, task.ChannelID, 57, task.AsigneeID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, ASIGNEE_ID = \\? WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), task.AsigneeID, task.ChannelID, 57).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.AssignTaskTo(ctx, task.ChannelID, 57, task.AsigneeID, "U9")
 	assert.Error(t, err)
 	assert.Equal(t, err, ErrNoRowOrMoreThanOne)
 	if err = mock.ExpectationsWereMet(); err != nil {
@@ -141,6 +214,62 @@ func TestAssignTaskErrNoRow(t *testing.T) {
 	}
 }
 
+func TestFindTasks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	since := time.Date(2026, 10, 7, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"ID", "NUMBER", "STATUS", "PRIORITY", "TITLE", "ASIGNEE_ID", "CHANNEL_ID", "CREATOR_ID", "DUE_DATE", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT", "DELETED", "PERMALINK", "DESCRIPTION"}).
+		AddRow(task.ID, task.Number, StatusDone, task.Priority, task.Title, task.AsigneeID, task.ChannelID, task.CreatorID, nil, &since, &since, &since, false, "", "")
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION FROM TASK WHERE CHANNEL_ID = \\? AND DELETED = 0 AND STATUS IN \\(\\?,\\?\\) AND ASIGNEE_ID = \\? AND COMPLETED_AT >= \\? ORDER BY DUE_DATE IS NULL, DUE_DATE, NUMBER").
+		ExpectQuery().WithArgs(task.ChannelID, StatusInProgress, StatusDone, task.AsigneeID, since).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT TASK_ID, LABEL FROM TASK_LABEL WHERE TASK_ID IN \\(\\?\\) ORDER BY LABEL").WithArgs(task.ID).WillReturnRows(sqlmock.NewRows([]string{"TASK_ID", "LABEL"}))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	filter := &TaskFilter{
+		ChannelID:      task.ChannelID,
+		Statuses:       []string{StatusInProgress, StatusDone},
+		AsigneeID:      task.AsigneeID,
+		CompletedSince: &since,
+		Sort:           SortDue,
+	}
+	res, err := mockService.FindTasks(ctx, filter)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, since, *res[0].CompletedAt)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestFindTasksChannelOnly(t *testing.T) {
+	query, args := filterQuery(&TaskFilter{ChannelID: task.ChannelID})
+	assert.Equal(t, "SELECT "+taskColumns+" FROM TASK WHERE CHANNEL_ID = ? AND DELETED = 0 ORDER BY NUMBER", query)
+	assert.Equal(t, []interface{}{task.ChannelID}, args)
+}
+
+func TestFindTasksPage(t *testing.T) {
+	query, args := filterQuery(&TaskFilter{ChannelID: task.ChannelID, Sort: SortNumber, Limit: 21, Offset: 20})
+	assert.Equal(t, "SELECT "+taskColumns+" FROM TASK WHERE CHANNEL_ID = ? AND DELETED = 0 ORDER BY NUMBER LIMIT ? OFFSET ?", query)
+	assert.Equal(t, []interface{}{task.ChannelID, 21, 20}, args)
+}
+
+func TestFindTasksLabels(t *testing.T) {
+	query, args := filterQuery(&TaskFilter{ChannelID: task.ChannelID, Labels: []string{"infra", "q4"}})
+	assert.Equal(t, "SELECT "+taskColumns+" FROM TASK WHERE CHANNEL_ID = ? AND DELETED = 0 AND ID IN (SELECT TASK_ID FROM TASK_LABEL WHERE LABEL = ?) AND ID IN (SELECT TASK_ID FROM TASK_LABEL WHERE LABEL = ?) ORDER BY NUMBER", query)
+	assert.Equal(t, []interface{}{task.ChannelID, "infra", "q4"}, args)
+}
+
+func TestFindTasksSortPriority(t *testing.T) {
+	query, _ := filterQuery(&TaskFilter{ChannelID: task.ChannelID, Sort: SortPriority})
+	assert.Equal(t, "SELECT "+taskColumns+" FROM TASK WHERE CHANNEL_ID = ? AND DELETED = 0 ORDER BY PRIORITY = 0, PRIORITY, NUMBER", query)
+}
+
 func TestSetStatus(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -149,17 +278,18 @@ func TestSetStatus(t *testing.T) {
 	defer db.Close()
 	mock.MatchExpectationsInOrder(true)
 	mock.ExpectBegin()
-	mock.ExpectPrepare("UPDATE TASK SET STATUS = \\? WHERE ID = \\?").ExpectExec().WithArgs(StatusInProgress, task.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, STATUS, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND STATUS <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldStatus, StatusInProgress, task.ChannelID, task.Number, StatusInProgress).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, STATUS = \\?, COMPLETED_AT = NULL WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), StatusInProgress, task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
-	mockService := &TaskRepository{db}
-	err = mockService.SetStatus(task.ID, StatusInProgress)
+	mockService := &TaskRepository{DB: db}
+	err = mockService.SetStatus(ctx, task.ChannelID, task.Number, StatusInProgress, false, "U9")
 	assert.NoError(t, err)
 	if err = mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Expectations were not met: %s", err)
 	}
 }
 
-func TestSetStatusErrNoRow(t *testing.T) {
+func TestSetStatusDone(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Errorf("Failed to open sqlmock database: Error %s", err)
@@ -167,13 +297,394 @@ func TestSetStatusErrNoRow(t *testing.T) {
 	defer db.Close()
 	mock.MatchExpectationsInOrder(true)
 	mock.ExpectBegin()
-	mock.ExpectPrepare("UPDATE TASK SET STATUS = \\? WHERE ID = \\?").ExpectExec().WithArgs(StatusInProgress, task.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, STATUS, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND STATUS <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldStatus, StatusDone, task.ChannelID, task.Number, StatusDone).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, STATUS = \\?, COMPLETED_AT = COALESCE\\(COMPLETED_AT, \\?\\) WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), StatusDone, sqlmock.AnyArg(), task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
-	mockService := &TaskRepository{db}
-	err = mockService.SetStatus(task.ID, StatusInProgress)
+	mockService := &TaskRepository{DB: db}
+	err = mockService.SetStatus(ctx, task.ChannelID, task.Number, StatusDone, true, "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetStatusErrNoRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, STATUS, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND STATUS <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldStatus, StatusInProgress, task.ChannelID, task.Number, StatusInProgress).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, STATUS = \\?, COMPLETED_AT = NULL WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), StatusInProgress, task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.SetStatus(ctx, task.ChannelID, task.Number, StatusInProgress, false, "U9")
 	assert.Error(t, err)
 	assert.Equal(t, err, ErrNoRowOrMoreThanOne)
 	if err = mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Expectations were not met: %s", err)
 	}
 }
+
+func TestUpdateTitle(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, TITLE, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND TITLE <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldTitle, "New title", task.ChannelID, task.Number, "New title").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, TITLE = \\? WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), "New title", task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.UpdateTitle(ctx, task.ChannelID, task.Number, "New title", "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetPriority(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, PRIORITY, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND PRIORITY <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldPriority, "1", task.ChannelID, task.Number, "1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, PRIORITY = \\? WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), PriorityP1, task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.SetPriority(ctx, task.ChannelID, task.Number, PriorityP1, "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestUpdateDescription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, DESCRIPTION, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND DESCRIPTION <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldDescription, "Steps in the wiki", task.ChannelID, task.Number, "Steps in the wiki").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, DESCRIPTION = \\? WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), "Steps in the wiki", task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.UpdateDescription(ctx, task.ChannelID, task.Number, "Steps in the wiki", "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetDueDate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	old := time.Date(2026, 11, 2, 17, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 11, 6, 17, 0, 0, 0, time.UTC)
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID, DUE_DATE FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(task.ChannelID, task.Number).WillReturnRows(sqlmock.NewRows([]string{"ID", "DUE_DATE"}).AddRow(task.ID, old))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, DUE_DATE = \\? WHERE ID = \\?").WithArgs(sqlmock.AnyArg(), due, task.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").WithArgs(task.ID, "U9", sqlmock.AnyArg(), FieldDue, "2026-11-02T17:00:00Z", "2026-11-06T17:00:00Z").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.SetDueDate(ctx, task.ChannelID, task.Number, &due, "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetDueDateErrNoRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID, DUE_DATE FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(task.ChannelID, task.Number).WillReturnRows(sqlmock.NewRows([]string{"ID", "DUE_DATE"}))
+	mock.ExpectRollback()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.SetDueDate(ctx, task.ChannelID, task.Number, nil, "U9")
+	assert.Equal(t, ErrNoRowOrMoreThanOne, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestUpdateTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"ID", "NUMBER", "STATUS", "PRIORITY", "TITLE", "ASIGNEE_ID", "CHANNEL_ID", "CREATOR_ID", "DUE_DATE", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT", "DELETED", "PERMALINK", "DESCRIPTION"}).
+		AddRow(task.ID, task.Number, task.Status, task.Priority, task.Title, task.AsigneeID, task.ChannelID, task.CreatorID, task.DueDate, task.CreatedAt, task.UpdatedAt, task.CompletedAt, task.Deleted, task.Permalink, task.Description)
+	title, priority := "Manual test of ui and api", PriorityP1
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(task.ChannelID, task.Number).WillReturnRows(rows)
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, TITLE = \\?, DUE_DATE = \\?, PRIORITY = \\? WHERE ID = \\?").WithArgs(sqlmock.AnyArg(), title, nil, priority, task.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").WithArgs(task.ID, "U9", sqlmock.AnyArg(), FieldTitle, task.Title, title).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").WithArgs(task.ID, "U9", sqlmock.AnyArg(), FieldPriority, "0", "1").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.UpdateTask(ctx, task.ChannelID, task.Number, &TaskUpdate{Title: &title, SetDue: true, Priority: &priority}, "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestUpdateTaskRollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"ID", "NUMBER", "STATUS", "PRIORITY", "TITLE", "ASIGNEE_ID", "CHANNEL_ID", "CREATOR_ID", "DUE_DATE", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT", "DELETED", "PERMALINK", "DESCRIPTION"}).
+		AddRow(task.ID, task.Number, task.Status, task.Priority, task.Title, task.AsigneeID, task.ChannelID, task.CreatorID, task.DueDate, task.CreatedAt, task.UpdatedAt, task.CompletedAt, task.Deleted, task.Permalink, task.Description)
+	title, assignee := "Manual test of ui and api", "U7"
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(task.ChannelID, task.Number).WillReturnRows(rows)
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, TITLE = \\?, ASIGNEE_ID = \\? WHERE ID = \\?").WithArgs(sqlmock.AnyArg(), title, assignee, task.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT").WithArgs(task.ID, "U9", sqlmock.AnyArg(), FieldTitle, task.Title, title).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT").WithArgs(task.ID, "U9", sqlmock.AnyArg(), FieldAssignee, task.AsigneeID, assignee).WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.UpdateTask(ctx, task.ChannelID, task.Number, &TaskUpdate{Title: &title, AsigneeID: &assignee}, "U9")
+	assert.EqualError(t, err, "connection lost")
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestUpdateLabels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(task.ChannelID, task.Number).WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow(task.ID))
+	mock.ExpectQuery("SELECT TASK_ID, LABEL FROM TASK_LABEL WHERE TASK_ID IN \\(\\?\\) ORDER BY LABEL").WithArgs(task.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"TASK_ID", "LABEL"}).AddRow(task.ID, "infra").AddRow(task.ID, "q4"))
+	mock.ExpectExec("DELETE FROM TASK_LABEL WHERE TASK_ID = \\? AND LABEL = \\?").WithArgs(task.ID, "q4").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO TASK_LABEL \\(TASK_ID, LABEL\\) VALUES \\(\\?,\\?\\)").WithArgs(task.ID, "security").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\? WHERE ID = \\?").WithArgs(sqlmock.AnyArg(), task.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").WithArgs(task.ID, "U9", sqlmock.AnyArg(), FieldLabels, "infra q4", "infra security").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.UpdateLabels(ctx, task.ChannelID, task.Number, []string{"security"}, []string{"q4"}, "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestUpdateLabelsErrNoRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(task.ChannelID, task.Number).WillReturnRows(sqlmock.NewRows([]string{"ID"}))
+	mock.ExpectRollback()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.UpdateLabels(ctx, task.ChannelID, task.Number, []string{"security"}, nil, "U9")
+	assert.Equal(t, ErrNoRowOrMoreThanOne, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetLabelCounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"LABEL", "COUNT"}).AddRow("infra", 3).AddRow("q4", 1)
+	mock.ExpectQuery("SELECT L.LABEL, COUNT\\(\\*\\) FROM TASK_LABEL L JOIN TASK T ON T.ID = L.TASK_ID WHERE T.CHANNEL_ID = \\? AND T.DELETED = 0 GROUP BY L.LABEL ORDER BY L.LABEL").WithArgs(task.ChannelID).WillReturnRows(rows)
+	mockService := &TaskRepository{DB: db}
+	counts, err := mockService.GetLabelCounts(ctx, task.ChannelID)
+	assert.NoError(t, err)
+	assert.Equal(t, []*LabelCount{{Label: "infra", Count: 3}, {Label: "q4", Count: 1}}, counts)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestApplyLabels(t *testing.T) {
+	labels := []string{"q4", "infra"}
+	assert.Equal(t, []string{"infra", "security"}, ApplyLabels(labels, []string{"security", "infra"}, []string{"q4", "missing"}))
+	assert.Equal(t, []string{"q4", "infra"}, labels)
+	assert.Equal(t, []string{"q4"}, ApplyLabels(nil, []string{"q4"}, []string{"q4"}))
+	assert.Nil(t, ApplyLabels(labels, nil, labels))
+}
+
+func TestDeleteTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, DELETED, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND DELETED <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldDeleted, "1", task.ChannelID, task.Number, "1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, DELETED = 1 WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.DeleteTask(ctx, task.ChannelID, task.Number, "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestDeleteTaskErrNoRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, DELETED, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0 AND DELETED <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldDeleted, "1", task.ChannelID, task.Number, "1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, DELETED = 1 WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 0").WithArgs(sqlmock.AnyArg(), task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.DeleteTask(ctx, task.ChannelID, task.Number, "U9")
+	assert.Equal(t, ErrNoRowOrMoreThanOne, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestRestoreTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO TASK_EVENT \\(TASK_ID, ACTOR_ID, CREATED_AT, FIELD, OLD_VALUE, NEW_VALUE\\) SELECT ID, \\?, \\?, \\?, DELETED, \\? FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 1 AND DELETED <> \\?").WithArgs("U9", sqlmock.AnyArg(), FieldDeleted, "0", task.ChannelID, task.Number, "0").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE TASK SET UPDATED_AT = \\?, DELETED = 0 WHERE CHANNEL_ID = \\? AND NUMBER = \\? AND DELETED = 1").WithArgs(sqlmock.AnyArg(), task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	err = mockService.RestoreTask(ctx, task.ChannelID, task.Number, "U9")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetTaskEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	created := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"ID", "TASK_ID", "ACTOR_ID", "CREATED_AT", "FIELD", "OLD_VALUE", "NEW_VALUE"}).
+		AddRow(1, task.ID, "U9", created, FieldCreated, "", task.Title).
+		AddRow(2, task.ID, "U8", created.Add(time.Hour), FieldStatus, StatusOpen, StatusDone)
+	mock.ExpectQuery("SELECT E.ID, E.TASK_ID, E.ACTOR_ID, E.CREATED_AT, E.FIELD, E.OLD_VALUE, E.NEW_VALUE FROM TASK_EVENT E JOIN TASK T ON T.ID = E.TASK_ID WHERE T.CHANNEL_ID = \\? AND T.NUMBER = \\? ORDER BY E.ID").WithArgs(task.ChannelID, task.Number).WillReturnRows(rows)
+	mockService := &TaskRepository{DB: db}
+	events, err := mockService.GetTaskEvents(ctx, task.ChannelID, task.Number)
+	assert.NoError(t, err)
+	assert.Equal(t, []*TaskEvent{
+		{ID: 1, TaskID: task.ID, ActorID: "U9", CreatedAt: created, Field: FieldCreated, OldValue: "", NewValue: task.Title},
+		{ID: 2, TaskID: task.ID, ActorID: "U8", CreatedAt: created.Add(time.Hour), Field: FieldStatus, OldValue: StatusOpen, NewValue: StatusDone},
+	}, events)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestAddTaskMessage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.ExpectExec("INSERT INTO TASK_MESSAGE \\(CHANNEL_ID, TS, TASK_ID\\) SELECT CHANNEL_ID, \\?, ID FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\?").WithArgs("1700000000.000100", task.ChannelID, task.Number).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockService := &TaskRepository{DB: db}
+	err = mockService.AddTaskMessage(ctx, task.ChannelID, task.Number, "1700000000.000100")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestAddTaskMessageErrNoRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.ExpectExec("INSERT INTO TASK_MESSAGE \\(CHANNEL_ID, TS, TASK_ID\\) SELECT CHANNEL_ID, \\?, ID FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\?").WithArgs("1700000000.000100", task.ChannelID, 99).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockService := &TaskRepository{DB: db}
+	err = mockService.AddTaskMessage(ctx, task.ChannelID, 99, "1700000000.000100")
+	assert.Equal(t, ErrNoRowOrMoreThanOne, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetTaskByMessage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"ID", "NUMBER", "STATUS", "PRIORITY", "TITLE", "ASIGNEE_ID", "CHANNEL_ID", "CREATOR_ID", "DUE_DATE", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT", "DELETED", "PERMALINK", "DESCRIPTION"}).
+		AddRow(task.ID, task.Number, task.Status, task.Priority, task.Title, task.AsigneeID, task.ChannelID, task.CreatorID, task.DueDate, task.CreatedAt, task.UpdatedAt, task.CompletedAt, task.Deleted, task.Permalink, task.Description)
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectQuery("SELECT T.NUMBER FROM TASK_MESSAGE M JOIN TASK T ON T.ID = M.TASK_ID WHERE M.CHANNEL_ID = \\? AND M.TS = \\?").WithArgs(task.ChannelID, "1700000000.000100").WillReturnRows(sqlmock.NewRows([]string{"NUMBER"}).AddRow(task.Number))
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT ID, NUMBER, STATUS, PRIORITY, TITLE, ASIGNEE_ID, CHANNEL_ID, CREATOR_ID, DUE_DATE, CREATED_AT, UPDATED_AT, COMPLETED_AT, DELETED, PERMALINK, DESCRIPTION FROM TASK WHERE CHANNEL_ID = \\? AND NUMBER = \\?").ExpectQuery().WithArgs(task.ChannelID, task.Number).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT TASK_ID, LABEL FROM TASK_LABEL WHERE TASK_ID IN \\(\\?\\) ORDER BY LABEL").WithArgs(task.ID).WillReturnRows(sqlmock.NewRows([]string{"TASK_ID", "LABEL"}))
+	mock.ExpectCommit()
+	mockService := &TaskRepository{DB: db}
+	res, err := mockService.GetTaskByMessage(ctx, task.ChannelID, "1700000000.000100")
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, task, res)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetTaskByMessageNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT T.NUMBER FROM TASK_MESSAGE M JOIN TASK T ON T.ID = M.TASK_ID WHERE M.CHANNEL_ID = \\? AND M.TS = \\?").WithArgs(task.ChannelID, "1700000000.000100").WillReturnRows(sqlmock.NewRows([]string{"NUMBER"}))
+	mockService := &TaskRepository{DB: db}
+	_, err = mockService.GetTaskByMessage(ctx, task.ChannelID, "1700000000.000100")
+	assert.Equal(t, sql.ErrNoRows, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// UserSettingRepositoryInterface provides functions for database operation execution on table USER_SETTING
+type UserSettingRepositoryInterface interface {
+	GetTimezone(ctx context.Context, userID string) (string, error)
+	SetTimezone(ctx context.Context, userID string, timezone string) error
+}
+
+// UserSettingRepository implements UserSettingRepositoryInterface
+type UserSettingRepository struct {
+	DB *sql.DB
+}
+
+// GetTimezone returns the IANA timezone name set by the user, e.g. "Europe/Sofia".
+// Returns empty string if the user has not set a timezone.
+func (repo *UserSettingRepository) GetTimezone(ctx context.Context, userID string) (string, error) {
+	query := "SELECT TIMEZONE FROM USER_SETTING WHERE USER_ID = ?"
+	var timezone string
+	err := repo.DB.QueryRowContext(ctx, query, userID).Scan(&timezone)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return timezone, err
+}
+
+// SetTimezone saves the timezone of the user, replacing the previous one.
+func (repo *UserSettingRepository) SetTimezone(ctx context.Context, userID string, timezone string) error {
+	txn, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "DELETE FROM USER_SETTING WHERE USER_ID = ?", userID)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	_, err = txn.ExecContext(ctx, "INSERT INTO USER_SETTING (USER_ID, TIMEZONE) VALUES (?,?)", userID, timezone)
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
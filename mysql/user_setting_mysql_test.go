@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetTimezone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"TIMEZONE"}).AddRow("Europe/Sofia")
+	mock.ExpectQuery("SELECT TIMEZONE FROM USER_SETTING WHERE USER_ID = \\?").WithArgs("U1").WillReturnRows(rows)
+	mockService := &UserSettingRepository{db}
+	res, err := mockService.GetTimezone(ctx, "U1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Sofia", res)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestGetTimezoneNotSet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT TIMEZONE FROM USER_SETTING WHERE USER_ID = \\?").WithArgs("U1").WillReturnRows(sqlmock.NewRows([]string{"TIMEZONE"}))
+	mockService := &UserSettingRepository{db}
+	res, err := mockService.GetTimezone(ctx, "U1")
+	assert.NoError(t, err)
+	assert.Equal(t, "", res)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
+
+func TestSetTimezone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Failed to open sqlmock database: Error %s", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(true)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM USER_SETTING WHERE USER_ID = \\?").WithArgs("U1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO USER_SETTING \\(USER_ID, TIMEZONE\\) VALUES \\(\\?,\\?\\)").WithArgs("U1", "Europe/Sofia").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mockService := &UserSettingRepository{db}
+	err = mockService.SetTimezone(ctx, "U1", "Europe/Sofia")
+	assert.NoError(t, err)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations were not met: %s", err)
+	}
+}
@@ -0,0 +1,801 @@
+// Package repotest provides a conformance test suite for implementations of mysql.TaskRepositoryInterface.
+// Every storage backend runs the same suite from its own tests, so all of them behave identically.
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Factory returns a new empty repository for a single test.
+type Factory func(t *testing.T) mysql.TaskRepositoryInterface
+
+// UserSettingFactory returns a new empty user setting repository for a single test.
+type UserSettingFactory func(t *testing.T) mysql.UserSettingRepositoryInterface
+
+// ChannelSettingFactory returns a new empty channel setting repository for a single test.
+type ChannelSettingFactory func(t *testing.T) mysql.ChannelSettingRepositoryInterface
+
+// actor is the Slack user ID changing the tasks in the conformance tests.
+const actor = "UACTOR"
+
+// ctx is the context of the repository calls in the conformance tests.
+var ctx = context.Background()
+
+// RunConformance runs every conformance test against repositories created by newRepo.
+func RunConformance(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo mysql.TaskRepositoryInterface)
+	}{
+		{"PersistTask", testPersistTask},
+		{"PersistTaskDueDate", testPersistTaskDueDate},
+		{"PersistTaskPermalink", testPersistTaskPermalink},
+		{"GetTaskNoRows", testGetTaskNoRows},
+		{"GetAllInChannel", testGetAllInChannel},
+		{"GetAllAssignedTo", testGetAllAssignedTo},
+		{"AssignTaskTo", testAssignTaskTo},
+		{"AssignTaskToErrNoRow", testAssignTaskToErrNoRow},
+		{"SetStatus", testSetStatus},
+		{"SetStatusErrNoRow", testSetStatusErrNoRow},
+		{"SetStatusSameValue", testSetStatusSameValue},
+		{"UpdateTitle", testUpdateTitle},
+		{"UpdateTitleErrNoRow", testUpdateTitleErrNoRow},
+		{"SetPriority", testSetPriority},
+		{"UpdateDescription", testUpdateDescription},
+		{"SetDueDate", testSetDueDate},
+		{"SetDueDateErrNoRow", testSetDueDateErrNoRow},
+		{"UpdateTask", testUpdateTask},
+		{"UpdateTaskErrNoRow", testUpdateTaskErrNoRow},
+		{"Labels", testLabels},
+		{"LabelsErrNoRow", testLabelsErrNoRow},
+		{"DeleteTask", testDeleteTask},
+		{"DeletedTaskIsReadOnly", testDeletedTaskIsReadOnly},
+		{"RestoreTask", testRestoreTask},
+		{"RestoreTaskErrNoRow", testRestoreTaskErrNoRow},
+		{"CompletedAt", testCompletedAt},
+		{"TaskMetadata", testTaskMetadata},
+		{"FindTasksStatusAndAssignee", testFindTasksStatusAndAssignee},
+		{"FindTasksCompletedSince", testFindTasksCompletedSince},
+		{"FindTasksSortDue", testFindTasksSortDue},
+		{"FindTasksSortPriority", testFindTasksSortPriority},
+		{"FindTasksPage", testFindTasksPage},
+		{"FindTasksLabels", testFindTasksLabels},
+		{"LabelCounts", testLabelCounts},
+		{"NumberingPerChannel", testNumberingPerChannel},
+		{"ChannelIsolation", testChannelIsolation},
+		{"ConcurrentPersist", testConcurrentPersist},
+		{"TaskEvents", testTaskEvents},
+		{"TaskEventsFailedChange", testTaskEventsFailedChange},
+		{"TaskEventsChannelIsolation", testTaskEventsChannelIsolation},
+		{"TaskMessage", testTaskMessage},
+		{"TaskMessageErrNoRow", testTaskMessageErrNoRow},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newRepo(t))
+		})
+	}
+}
+
+func testPersistTask(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("Write release notes", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	assert.True(t, task.ID > 0)
+	assert.Equal(t, 1, task.Number)
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, task, res)
+}
+
+func testPersistTaskDueDate(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	due := time.Date(2026, 11, 2, 17, 30, 0, 0, time.UTC)
+	task := mysql.NewTask("Ship release notes", "C1", actor)
+	task.DueDate = &due
+	require.NoError(t, repo.PersistTask(ctx, task))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	require.NotNil(t, res.DueDate)
+	assert.True(t, due.Equal(*res.DueDate))
+}
+
+func testPersistTaskPermalink(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("Reply to the customer", "C1", actor)
+	task.Permalink = "https://acme.slack.com/archives/C1/p1700000000000100"
+	require.NoError(t, repo.PersistTask(ctx, task))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, task.Permalink, res.Permalink)
+}
+
+func testGetTaskNoRows(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	res, err := repo.GetTask(ctx, "C1", 404)
+	assert.Nil(t, res)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func testGetAllInChannel(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	first := mysql.NewTask("first", "C1", actor)
+	other := mysql.NewTask("other channel", "C2", actor)
+	second := mysql.NewTask("second", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, first))
+	require.NoError(t, repo.PersistTask(ctx, other))
+	require.NoError(t, repo.PersistTask(ctx, second))
+	res, err := repo.GetAllInChannel(ctx, "C1")
+	require.NoError(t, err)
+	assert.Equal(t, []*mysql.Task{first, second}, res)
+	empty, err := repo.GetAllInChannel(ctx, "C3")
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+}
+
+func testGetAllAssignedTo(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	second := mysql.NewTask("second channel", "C2", actor)
+	first := mysql.NewTask("first", "C1", actor)
+	other := mysql.NewTask("other assignee", "C1", actor)
+	deleted := mysql.NewTask("deleted", "C1", actor)
+	third := mysql.NewTask("third", "C1", actor)
+	second.AsigneeID, first.AsigneeID, other.AsigneeID, deleted.AsigneeID, third.AsigneeID = "U1", "U1", "U2", "U1", "U1"
+	for _, task := range []*mysql.Task{second, first, other, deleted, third} {
+		require.NoError(t, repo.PersistTask(ctx, task))
+	}
+	require.NoError(t, repo.DeleteTask(ctx, deleted.ChannelID, deleted.Number, actor))
+	res, err := repo.GetAllAssignedTo(ctx, "U1")
+	require.NoError(t, err)
+	assert.Equal(t, []*mysql.Task{first, third, second}, res)
+	empty, err := repo.GetAllAssignedTo(ctx, "U3")
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+}
+
+func testAssignTaskTo(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("assign me", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.AssignTaskTo(ctx, task.ChannelID, task.Number, "U1", actor))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, "U1", res.AsigneeID)
+}
+
+func testAssignTaskToErrNoRow(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.AssignTaskTo(ctx, "C1", 404, "U1", actor))
+}
+
+func testSetStatus(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("start me", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusInProgress, false, actor))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, mysql.StatusInProgress, res.Status)
+}
+
+func testSetStatusErrNoRow(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.SetStatus(ctx, "C1", 404, mysql.StatusDone, true, actor))
+}
+
+func testSetStatusSameValue(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("already open", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	assert.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusOpen, false, actor))
+}
+
+func testUpdateTitle(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("Fix tpyo", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.UpdateTitle(ctx, task.ChannelID, task.Number, "Fix typo", actor))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, "Fix typo", res.Title)
+}
+
+func testUpdateTitleErrNoRow(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateTitle(ctx, "C1", 404, "title", actor))
+}
+
+func testSetPriority(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("Fix prod login", "C1", actor)
+	task.Priority = mysql.PriorityP2
+	require.NoError(t, repo.PersistTask(ctx, task))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, mysql.PriorityP2, res.Priority)
+	require.NoError(t, repo.SetPriority(ctx, task.ChannelID, task.Number, mysql.PriorityP1, "U1"))
+	res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, mysql.PriorityP1, res.Priority)
+	events, err := repo.GetTaskEvents(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, mysql.FieldPriority, events[1].Field)
+	assert.Equal(t, "2", events[1].OldValue)
+	assert.Equal(t, "1", events[1].NewValue)
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.SetPriority(ctx, "C1", 404, mysql.PriorityP1, actor))
+}
+
+func testUpdateDescription(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("Rotate TLS certs", "C1", actor)
+	task.Description = "Steps in the wiki"
+	require.NoError(t, repo.PersistTask(ctx, task))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, "Steps in the wiki", res.Description)
+	require.NoError(t, repo.UpdateDescription(ctx, task.ChannelID, task.Number, "", "U1"))
+	res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, "", res.Description)
+	events, err := repo.GetTaskEvents(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, mysql.FieldDescription, events[1].Field)
+	assert.Equal(t, "Steps in the wiki", events[1].OldValue)
+	assert.Equal(t, "", events[1].NewValue)
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateDescription(ctx, "C1", 404, "description", actor))
+}
+
+func testSetDueDate(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("Ship release notes", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	due := time.Date(2026, 11, 6, 17, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.SetDueDate(ctx, task.ChannelID, task.Number, &due, "U1"))
+	require.NoError(t, repo.SetDueDate(ctx, task.ChannelID, task.Number, &due, "U1"))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	require.NotNil(t, res.DueDate)
+	assert.True(t, due.Equal(*res.DueDate))
+	require.NoError(t, repo.SetDueDate(ctx, task.ChannelID, task.Number, nil, "U1"))
+	res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Nil(t, res.DueDate)
+	events, err := repo.GetTaskEvents(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, mysql.FieldDue, events[1].Field)
+	assert.Equal(t, "", events[1].OldValue)
+	assert.Equal(t, "2026-11-06T17:00:00Z", events[1].NewValue)
+	assert.Equal(t, "2026-11-06T17:00:00Z", events[2].OldValue)
+	assert.Equal(t, "", events[2].NewValue)
+}
+
+func testSetDueDateErrNoRow(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	due := time.Date(2026, 11, 6, 17, 0, 0, 0, time.UTC)
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.SetDueDate(ctx, "C1", 404, &due, actor))
+	task := mysql.NewTask("deleted", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.SetDueDate(ctx, task.ChannelID, task.Number, &due, actor))
+}
+
+func testUpdateTask(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("Ship release notes", "C1", actor)
+	task.Priority = mysql.PriorityP2
+	require.NoError(t, repo.PersistTask(ctx, task))
+	title, assignee, priority, description := "Ship release notes v2", "U2", mysql.PriorityP2, "Changelog and upgrade steps"
+	due := time.Date(2026, 11, 6, 17, 0, 0, 0, time.UTC)
+	update := &mysql.TaskUpdate{Title: &title, AsigneeID: &assignee, SetDue: true, DueDate: &due, Priority: &priority, Description: &description}
+	require.NoError(t, repo.UpdateTask(ctx, task.ChannelID, task.Number, update, "U1"))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, title, res.Title)
+	assert.Equal(t, assignee, res.AsigneeID)
+	require.NotNil(t, res.DueDate)
+	assert.True(t, due.Equal(*res.DueDate))
+	assert.Equal(t, priority, res.Priority)
+	assert.Equal(t, description, res.Description)
+	events, err := repo.GetTaskEvents(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	fields := make([]string, 0)
+	for _, event := range events[1:] {
+		assert.Equal(t, "U1", event.ActorID)
+		fields = append(fields, event.Field)
+	}
+	assert.Equal(t, []string{mysql.FieldTitle, mysql.FieldAssignee, mysql.FieldDue, mysql.FieldDescription}, fields)
+	assert.Equal(t, "2026-11-06T17:00:00Z", events[3].NewValue)
+
+	require.NoError(t, repo.UpdateTask(ctx, task.ChannelID, task.Number, &mysql.TaskUpdate{SetDue: true}, "U1"))
+	res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Nil(t, res.DueDate)
+	assert.Equal(t, title, res.Title)
+}
+
+func testUpdateTaskErrNoRow(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	title := "missing"
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateTask(ctx, "C1", 404, &mysql.TaskUpdate{Title: &title}, actor))
+	task := mysql.NewTask("deleted", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateTask(ctx, task.ChannelID, task.Number, &mysql.TaskUpdate{Title: &title}, actor))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, "deleted", res.Title)
+}
+
+func testLabels(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("Update TLS certs", "C1", "U1")
+	task.Labels = []string{"q4", "infra", "q4"}
+	require.NoError(t, repo.PersistTask(ctx, task))
+	assert.Equal(t, []string{"infra", "q4"}, task.Labels)
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, task, res)
+	require.NoError(t, repo.UpdateLabels(ctx, task.ChannelID, task.Number, []string{"security"}, []string{"q4", "missing"}, "U2"))
+	require.NoError(t, repo.UpdateLabels(ctx, task.ChannelID, task.Number, []string{"infra"}, nil, "U2"))
+	res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"infra", "security"}, res.Labels)
+	require.NoError(t, repo.UpdateLabels(ctx, task.ChannelID, task.Number, nil, []string{"infra", "security"}, "U2"))
+	res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Empty(t, res.Labels)
+	events, err := repo.GetTaskEvents(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Equal(t, mysql.FieldLabels, events[1].Field)
+	assert.Equal(t, "U1", events[1].ActorID)
+	assert.Equal(t, "infra q4", events[1].NewValue)
+	assert.Equal(t, "U2", events[2].ActorID)
+	assert.Equal(t, "infra q4", events[2].OldValue)
+	assert.Equal(t, "infra security", events[2].NewValue)
+	assert.Equal(t, "", events[3].NewValue)
+}
+
+func testLabelsErrNoRow(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateLabels(ctx, "C1", 404, []string{"infra"}, nil, actor))
+	task := mysql.NewTask("deleted", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateLabels(ctx, task.ChannelID, task.Number, []string{"infra"}, nil, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateLabels(ctx, "C2", task.Number, []string{"infra"}, nil, actor))
+}
+
+func testDeleteTask(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("added by mistake", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	res, err := repo.GetAllInChannel(ctx, "C1")
+	require.NoError(t, err)
+	assert.Empty(t, res)
+	deleted, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.True(t, deleted.Deleted)
+}
+
+func testDeletedTaskIsReadOnly(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("deleted", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusDone, true, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.AssignTaskTo(ctx, task.ChannelID, task.Number, "U1", actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateTitle(ctx, task.ChannelID, task.Number, "title", actor))
+}
+
+func testRestoreTask(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("deleted by mistake", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	require.NoError(t, repo.RestoreTask(ctx, task.ChannelID, task.Number, actor))
+	res, err := repo.GetAllInChannel(ctx, "C1")
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, task.ID, res[0].ID)
+	assert.False(t, res[0].Deleted)
+}
+
+func testRestoreTaskErrNoRow(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("not deleted", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.RestoreTask(ctx, task.ChannelID, task.Number, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.RestoreTask(ctx, "C1", 404, actor))
+}
+
+func testCompletedAt(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("ship it", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	before := time.Now().UTC().Add(-time.Second)
+	require.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusDone, true, actor))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	require.NotNil(t, res.CompletedAt)
+	assert.True(t, res.CompletedAt.After(before))
+	require.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusInProgress, false, actor))
+	res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Nil(t, res.CompletedAt)
+	require.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, "Won't Fix", true, actor))
+	res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, "Won't Fix", res.Status)
+	assert.NotNil(t, res.CompletedAt)
+}
+
+func testTaskMetadata(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("who and when", "C1", "U1")
+	require.NoError(t, repo.PersistTask(ctx, task))
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, "U1", res.CreatorID)
+	require.NotNil(t, res.CreatedAt)
+	require.NotNil(t, res.UpdatedAt)
+	assert.WithinDuration(t, time.Now(), *res.CreatedAt, time.Minute)
+	assert.True(t, res.CreatedAt.Equal(*res.UpdatedAt))
+	created := *res.CreatedAt
+	writes := []func() error{
+		func() error { return repo.AssignTaskTo(ctx, task.ChannelID, task.Number, "U2", actor) },
+		func() error { return repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusDone, true, actor) },
+		func() error { return repo.UpdateTitle(ctx, task.ChannelID, task.Number, "renamed", actor) },
+		func() error { return repo.DeleteTask(ctx, task.ChannelID, task.Number, actor) },
+		func() error { return repo.RestoreTask(ctx, task.ChannelID, task.Number, actor) },
+	}
+	for _, write := range writes {
+		before := mysql.Now()
+		require.NoError(t, write())
+		res, err = repo.GetTask(ctx, task.ChannelID, task.Number)
+		require.NoError(t, err)
+		assert.Equal(t, "U1", res.CreatorID)
+		require.NotNil(t, res.CreatedAt)
+		assert.True(t, created.Equal(*res.CreatedAt))
+		require.NotNil(t, res.UpdatedAt)
+		assert.False(t, res.UpdatedAt.Before(before))
+	}
+}
+
+// persistTasks saves a task per title in channel C1 with status and assignee taken from the maps
+func persistTasks(t *testing.T, repo mysql.TaskRepositoryInterface, titles []string, statuses map[string]string, assignees map[string]string) {
+	for _, title := range titles {
+		task := mysql.NewTask(title, "C1", actor)
+		if assignee, ok := assignees[title]; ok {
+			task.AsigneeID = assignee
+		}
+		require.NoError(t, repo.PersistTask(ctx, task))
+		if status, ok := statuses[title]; ok {
+			require.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, status, status == mysql.StatusDone, actor))
+		}
+	}
+}
+
+// titles returns the titles of tasks in order
+func titles(tasks []*mysql.Task) []string {
+	res := make([]string, 0)
+	for _, task := range tasks {
+		res = append(res, task.Title)
+	}
+	return res
+}
+
+func testFindTasksStatusAndAssignee(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	persistTasks(t, repo, []string{"a", "b", "c", "d"},
+		map[string]string{"b": mysql.StatusInProgress, "c": mysql.StatusDone},
+		map[string]string{"a": "U1", "b": "U1", "c": "U1"})
+	other := mysql.NewTask("other channel", "C2", actor)
+	require.NoError(t, repo.PersistTask(ctx, other))
+	deleted := mysql.NewTask("deleted", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, deleted))
+	require.NoError(t, repo.DeleteTask(ctx, deleted.ChannelID, deleted.Number, actor))
+
+	res, err := repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, titles(res))
+	res, err = repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Statuses: []string{mysql.StatusOpen, mysql.StatusInProgress}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "d"}, titles(res))
+	res, err = repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Statuses: []string{mysql.StatusOpen, mysql.StatusInProgress}, AsigneeID: "U1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, titles(res))
+	res, err = repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", AsigneeID: "U2"})
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testFindTasksCompletedSince(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	persistTasks(t, repo, []string{"open", "done"}, map[string]string{"done": mysql.StatusDone}, nil)
+	since := time.Now().Add(-time.Hour)
+	res, err := repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", CompletedSince: &since})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"done"}, titles(res))
+	future := time.Now().Add(time.Hour)
+	res, err = repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", CompletedSince: &future})
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testFindTasksSortDue(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	early := time.Date(2026, 11, 2, 17, 0, 0, 0, time.UTC)
+	late := early.Add(48 * time.Hour)
+	for _, tc := range []struct {
+		title string
+		due   *time.Time
+	}{{"no due", nil}, {"late", &late}, {"early", &early}, {"no due either", nil}} {
+		task := mysql.NewTask(tc.title, "C1", actor)
+		task.DueDate = tc.due
+		require.NoError(t, repo.PersistTask(ctx, task))
+	}
+	res, err := repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Sort: mysql.SortDue})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"early", "late", "no due", "no due either"}, titles(res))
+	res, err = repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Sort: mysql.SortNumber})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"no due", "late", "early", "no due either"}, titles(res))
+}
+
+func testFindTasksSortPriority(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	for _, tc := range []struct {
+		title    string
+		priority int
+	}{{"none", mysql.PriorityNone}, {"p3", mysql.PriorityP3}, {"p1", mysql.PriorityP1}, {"p3 again", mysql.PriorityP3}, {"p4", mysql.PriorityP4}} {
+		task := mysql.NewTask(tc.title, "C1", actor)
+		task.Priority = tc.priority
+		require.NoError(t, repo.PersistTask(ctx, task))
+	}
+	res, err := repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Sort: mysql.SortPriority})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1", "p3", "p3 again", "p4", "none"}, titles(res))
+	res, err = repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Sort: mysql.SortPriority, Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p3 again", "p4"}, titles(res))
+}
+
+func testFindTasksPage(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	persistTasks(t, repo, []string{"a", "b", "c", "d", "e"}, map[string]string{"b": mysql.StatusDone}, nil)
+	filter := &mysql.TaskFilter{ChannelID: "C1", Statuses: []string{mysql.StatusOpen}, Limit: 2}
+	res, err := repo.FindTasks(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, titles(res))
+	filter.Offset = 2
+	res, err = repo.FindTasks(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "e"}, titles(res))
+	filter.Offset = 4
+	res, err = repo.FindTasks(ctx, filter)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+	filter.Limit = 0
+	res, err = repo.FindTasks(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "d", "e"}, titles(res))
+}
+
+// persistLabeled saves a task per title in channel with the labels of the title
+func persistLabeled(t *testing.T, repo mysql.TaskRepositoryInterface, channelID string, labels map[string][]string, titles ...string) {
+	for _, title := range titles {
+		task := mysql.NewTask(title, channelID, actor)
+		task.Labels = labels[title]
+		require.NoError(t, repo.PersistTask(ctx, task))
+	}
+}
+
+func testFindTasksLabels(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	labels := map[string][]string{"a": {"infra", "q4"}, "b": {"infra"}, "c": {"q4"}, "other": {"infra"}}
+	persistLabeled(t, repo, "C1", labels, "a", "b", "c", "d")
+	persistLabeled(t, repo, "C2", labels, "other")
+	res, err := repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Labels: []string{"infra"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, titles(res))
+	assert.Equal(t, []string{"infra", "q4"}, res[0].Labels)
+	res, err = repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Labels: []string{"infra", "q4"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, titles(res))
+	res, err = repo.FindTasks(ctx, &mysql.TaskFilter{ChannelID: "C1", Labels: []string{"security"}})
+	require.NoError(t, err)
+	assert.Empty(t, res)
+	all, err := repo.GetAllInChannel(ctx, "C1")
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	assert.Equal(t, []string{"q4"}, all[2].Labels)
+	assert.Empty(t, all[3].Labels)
+}
+
+func testLabelCounts(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	labels := map[string][]string{"a": {"infra", "q4"}, "b": {"infra"}, "deleted": {"infra", "q1"}, "other": {"security"}}
+	persistLabeled(t, repo, "C1", labels, "a", "b", "c", "deleted")
+	persistLabeled(t, repo, "C2", labels, "other")
+	require.NoError(t, repo.DeleteTask(ctx, "C1", 4, actor))
+	counts, err := repo.GetLabelCounts(ctx, "C1")
+	require.NoError(t, err)
+	assert.Equal(t, []*mysql.LabelCount{{Label: "infra", Count: 2}, {Label: "q4", Count: 1}}, counts)
+	empty, err := repo.GetLabelCounts(ctx, "C3")
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+}
+
+func testNumberingPerChannel(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	numbers := make([]int, 0)
+	for _, channelID := range []string{"C1", "C2", "C1", "C1", "C2"} {
+		task := mysql.NewTask("numbered", channelID, actor)
+		require.NoError(t, repo.PersistTask(ctx, task))
+		numbers = append(numbers, task.Number)
+	}
+	assert.Equal(t, []int{1, 1, 2, 3, 2}, numbers)
+	deleted := mysql.NewTask("deleted", "C2", actor)
+	require.NoError(t, repo.PersistTask(ctx, deleted))
+	require.NoError(t, repo.DeleteTask(ctx, deleted.ChannelID, deleted.Number, actor))
+	next := mysql.NewTask("numbers are not reused", "C2", actor)
+	require.NoError(t, repo.PersistTask(ctx, next))
+	assert.Equal(t, 4, next.Number)
+}
+
+func testChannelIsolation(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("ops task", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	other := mysql.NewTask("dev task", "C2", actor)
+	require.NoError(t, repo.PersistTask(ctx, other))
+	require.Equal(t, task.Number, other.Number)
+
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.SetStatus(ctx, "C3", task.Number, mysql.StatusDone, true, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.AssignTaskTo(ctx, "C3", task.Number, "U1", actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.UpdateTitle(ctx, "C3", task.Number, "title", actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.DeleteTask(ctx, "C3", task.Number, actor))
+	_, err := repo.GetTask(ctx, "C3", task.Number)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	require.NoError(t, repo.SetStatus(ctx, "C2", other.Number, mysql.StatusDone, true, actor))
+	res, err := repo.GetTask(ctx, "C1", task.Number)
+	require.NoError(t, err)
+	assert.Equal(t, mysql.StatusOpen, res.Status)
+}
+
+func testConcurrentPersist(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	const count = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, count)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- repo.PersistTask(ctx, mysql.NewTask("concurrent", "C1", actor))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	res, err := repo.GetAllInChannel(ctx, "C1")
+	require.NoError(t, err)
+	assert.Len(t, res, count)
+	for i, task := range res {
+		assert.Equal(t, i+1, task.Number)
+	}
+}
+
+func testTaskEvents(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("draft", "C1", "U1")
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.AssignTaskTo(ctx, task.ChannelID, task.Number, "U2", "U1"))
+	require.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusInProgress, false, "U2"))
+	require.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusInProgress, false, "U2"))
+	require.NoError(t, repo.UpdateTitle(ctx, task.ChannelID, task.Number, "final", "U2"))
+	require.NoError(t, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusDone, true, "U2"))
+	require.NoError(t, repo.DeleteTask(ctx, task.ChannelID, task.Number, "U3"))
+	require.NoError(t, repo.RestoreTask(ctx, task.ChannelID, task.Number, "U1"))
+	events, err := repo.GetTaskEvents(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	expected := []mysql.TaskEvent{
+		{TaskID: task.ID, ActorID: "U1", Field: mysql.FieldCreated, OldValue: "", NewValue: "draft"},
+		{TaskID: task.ID, ActorID: "U1", Field: mysql.FieldAssignee, OldValue: "", NewValue: "U2"},
+		{TaskID: task.ID, ActorID: "U2", Field: mysql.FieldStatus, OldValue: mysql.StatusOpen, NewValue: mysql.StatusInProgress},
+		{TaskID: task.ID, ActorID: "U2", Field: mysql.FieldTitle, OldValue: "draft", NewValue: "final"},
+		{TaskID: task.ID, ActorID: "U2", Field: mysql.FieldStatus, OldValue: mysql.StatusInProgress, NewValue: mysql.StatusDone},
+		{TaskID: task.ID, ActorID: "U3", Field: mysql.FieldDeleted, OldValue: "0", NewValue: "1"},
+		{TaskID: task.ID, ActorID: "U1", Field: mysql.FieldDeleted, OldValue: "1", NewValue: "0"},
+	}
+	require.Len(t, events, len(expected))
+	for i, event := range events {
+		assert.True(t, event.ID > 0)
+		assert.WithinDuration(t, time.Now(), event.CreatedAt, time.Minute)
+		event.ID = 0
+		event.CreatedAt = time.Time{}
+		assert.Equal(t, expected[i], *event)
+	}
+}
+
+func testTaskEventsFailedChange(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("deleted", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.SetStatus(ctx, task.ChannelID, task.Number, mysql.StatusDone, true, actor))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.DeleteTask(ctx, task.ChannelID, task.Number, actor))
+	events, err := repo.GetTaskEvents(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+	missing, err := repo.GetTaskEvents(ctx, "C1", 404)
+	require.NoError(t, err)
+	assert.NotNil(t, missing)
+	assert.Empty(t, missing)
+}
+
+func testTaskEventsChannelIsolation(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	mine := mysql.NewTask("mine", "C1", actor)
+	theirs := mysql.NewTask("theirs", "C2", actor)
+	require.NoError(t, repo.PersistTask(ctx, mine))
+	require.NoError(t, repo.PersistTask(ctx, theirs))
+	require.NoError(t, repo.UpdateTitle(ctx, theirs.ChannelID, theirs.Number, "renamed", actor))
+	events, err := repo.GetTaskEvents(ctx, mine.ChannelID, mine.Number)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "mine", events[0].NewValue)
+}
+
+func testTaskMessage(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	first := mysql.NewTask("first", "C1", actor)
+	second := mysql.NewTask("second", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, first))
+	require.NoError(t, repo.PersistTask(ctx, second))
+	require.NoError(t, repo.AddTaskMessage(ctx, "C1", second.Number, "1700000000.000100"))
+	require.NoError(t, repo.AddTaskMessage(ctx, "C1", second.Number, "1700000000.000200"))
+	for _, ts := range []string{"1700000000.000100", "1700000000.000200"} {
+		res, err := repo.GetTaskByMessage(ctx, "C1", ts)
+		require.NoError(t, err)
+		assert.Equal(t, second, res)
+	}
+	_, err := repo.GetTaskByMessage(ctx, "C2", "1700000000.000100")
+	assert.Equal(t, sql.ErrNoRows, err)
+	_, err = repo.GetTaskByMessage(ctx, "C1", "1700000000.000300")
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func testTaskMessageErrNoRow(t *testing.T, repo mysql.TaskRepositoryInterface) {
+	task := mysql.NewTask("mine", "C1", actor)
+	require.NoError(t, repo.PersistTask(ctx, task))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.AddTaskMessage(ctx, "C2", task.Number, "1700000000.000100"))
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, repo.AddTaskMessage(ctx, "C1", 404, "1700000000.000100"))
+}
+
+// RunUserSettingConformance runs every conformance test against user setting repositories created by newRepo.
+func RunUserSettingConformance(t *testing.T, newRepo UserSettingFactory) {
+	t.Run("Timezone", func(t *testing.T) {
+		repo := newRepo(t)
+		timezone, err := repo.GetTimezone(ctx, "U1")
+		require.NoError(t, err)
+		assert.Equal(t, "", timezone)
+		require.NoError(t, repo.SetTimezone(ctx, "U1", "Europe/Sofia"))
+		require.NoError(t, repo.SetTimezone(ctx, "U1", "America/New_York"))
+		require.NoError(t, repo.SetTimezone(ctx, "U2", "Asia/Tokyo"))
+		timezone, err = repo.GetTimezone(ctx, "U1")
+		require.NoError(t, err)
+		assert.Equal(t, "America/New_York", timezone)
+	})
+}
+
+// RunChannelSettingConformance runs every conformance test against channel setting repositories created by newRepo.
+func RunChannelSettingConformance(t *testing.T, newRepo ChannelSettingFactory) {
+	t.Run("Workflow", func(t *testing.T) {
+		repo := newRepo(t)
+		workflow, err := repo.GetWorkflow(ctx, "C1")
+		require.NoError(t, err)
+		assert.Nil(t, workflow)
+		review := &mysql.Workflow{
+			States: []*mysql.WorkflowState{
+				{Name: "Open", Emoji: ":question:"},
+				{Name: "In Review", Emoji: ":eyes:"},
+				{Name: "Done", Emoji: ":white_check_mark:", Terminal: true},
+			},
+			Transitions: []*mysql.WorkflowTransition{{From: "Open", To: "In Review"}, {From: "In Review", To: "Open"}, {From: "In Review", To: "Done"}},
+		}
+		require.NoError(t, repo.SetWorkflow(ctx, "C1", &mysql.Workflow{States: []*mysql.WorkflowState{{Name: "Todo", Emoji: ":memo:"}}}))
+		require.NoError(t, repo.SetWorkflow(ctx, "C1", review))
+		require.NoError(t, repo.SetWorkflow(ctx, "C2", review))
+		workflow, err = repo.GetWorkflow(ctx, "C1")
+		require.NoError(t, err)
+		assert.Equal(t, review.States, workflow.States)
+		assert.Equal(t, []*mysql.WorkflowTransition{{From: "In Review", To: "Done"}, {From: "In Review", To: "Open"}, {From: "Open", To: "In Review"}}, workflow.Transitions)
+		require.NoError(t, repo.SetWorkflow(ctx, "C1", nil))
+		workflow, err = repo.GetWorkflow(ctx, "C1")
+		require.NoError(t, err)
+		assert.Nil(t, workflow)
+		workflow, err = repo.GetWorkflow(ctx, "C2")
+		require.NoError(t, err)
+		assert.Len(t, workflow.States, 3)
+	})
+}
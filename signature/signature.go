@@ -0,0 +1,100 @@
+// Package signature verifies that incoming HTTP requests are sent by Slack using the signing secret of the app.
+// Refer to https://api.slack.com/authentication/verifying-requests-from-slack for details.
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Header names and defaults used by Slack request signing.
+const (
+	HeaderSignature = "X-Slack-Signature"
+	HeaderTimestamp = "X-Slack-Request-Timestamp"
+	Version         = "v0"
+	DefaultMaxAge   = 5 * time.Minute
+	MaxBodyBytes    = 1 << 20
+)
+
+// Errors returned when a request can't be verified.
+var (
+	ErrMissingHeaders   = errors.New("signature: missing signature or timestamp header")
+	ErrBadTimestamp     = errors.New("signature: timestamp is not a valid unix time")
+	ErrExpiredTimestamp = errors.New("signature: timestamp is outside of the allowed window")
+	ErrBadSignature     = errors.New("signature: signature does not match")
+)
+
+// Verifier checks X-Slack-Signature of requests against the signing secret.
+// Requests with X-Slack-Request-Timestamp older or newer than MaxAge are rejected to prevent replay attacks.
+type Verifier struct {
+	SigningSecret string
+	MaxAge        time.Duration
+	Now           func() time.Time
+}
+
+// NewVerifier constructs a verifier. Pass the signing secret of the Slack app.
+// Default replay window: 5 minutes, default clock: time.Now.
+func NewVerifier(signingSecret string) *Verifier {
+	verifier := Verifier{}
+	verifier.SigningSecret = signingSecret
+	verifier.MaxAge = DefaultMaxAge
+	verifier.Now = time.Now
+	return &verifier
+}
+
+// Sign computes the v0 signature of body sent at timestamp, in the format of X-Slack-Signature header.
+func (v *Verifier) Sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(v.SigningSecret))
+	fmt.Fprintf(mac, "%s:%s:", Version, timestamp)
+	mac.Write(body)
+	return Version + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify validates the signature and timestamp headers of the raw request body. Returns nil if the request is valid.
+func (v *Verifier) Verify(header http.Header, body []byte) error {
+	signature := header.Get(HeaderSignature)
+	timestamp := header.Get(HeaderTimestamp)
+	if signature == "" || timestamp == "" {
+		return ErrMissingHeaders
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrBadTimestamp
+	}
+	age := v.Now().Sub(time.Unix(seconds, 0))
+	if age > v.MaxAge || age < -v.MaxAge {
+		return ErrExpiredTimestamp
+	}
+	if !hmac.Equal([]byte(signature), []byte(v.Sign(timestamp, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Middleware verifies every request before passing it to next. Responds with 401 Unauthorized if the request is not signed by Slack.
+// The body is read once and restored, so next can parse it as usual.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = v.Verify(r.Header, body)
+		if err != nil {
+			fmt.Printf("Can't verify request to %s: %s\n", r.URL.Path, err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,105 @@
+package signature
+
+// refer to https://api.slack.com/authentication/verifying-requests-from-slack for the example request
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret    = "8f742231b10e8888abcd99yyyzzz85a5"
+	testTimestamp = "1531420618"
+	testBody      = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	testSignature = "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+)
+
+func newTestVerifier(now int64) *Verifier {
+	verifier := NewVerifier(testSecret)
+	verifier.Now = func() time.Time { return time.Unix(now, 0) }
+	return verifier
+}
+
+func newTestHeader(timestamp string, signature string) http.Header {
+	header := http.Header{}
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderSignature, signature)
+	return header
+}
+
+func TestSign(t *testing.T) {
+	verifier := newTestVerifier(1531420618)
+	assert.Equal(t, testSignature, verifier.Sign(testTimestamp, []byte(testBody)))
+}
+
+func TestVerify(t *testing.T) {
+	verifier := newTestVerifier(1531420618 + 60)
+	err := verifier.Verify(newTestHeader(testTimestamp, testSignature), []byte(testBody))
+	assert.NoError(t, err)
+}
+
+func TestVerifyBadSignature(t *testing.T) {
+	verifier := newTestVerifier(1531420618)
+	err := verifier.Verify(newTestHeader(testTimestamp, testSignature), []byte(testBody+"&x=1"))
+	assert.Equal(t, ErrBadSignature, err)
+}
+
+func TestVerifyWrongSecret(t *testing.T) {
+	verifier := newTestVerifier(1531420618)
+	verifier.SigningSecret = "another secret"
+	err := verifier.Verify(newTestHeader(testTimestamp, testSignature), []byte(testBody))
+	assert.Equal(t, ErrBadSignature, err)
+}
+
+func TestVerifyExpiredTimestamp(t *testing.T) {
+	old := newTestVerifier(1531420618 + 301)
+	future := newTestVerifier(1531420618 - 301)
+	assert.Equal(t, ErrExpiredTimestamp, old.Verify(newTestHeader(testTimestamp, testSignature), []byte(testBody)))
+	assert.Equal(t, ErrExpiredTimestamp, future.Verify(newTestHeader(testTimestamp, testSignature), []byte(testBody)))
+}
+
+func TestVerifyMissingHeaders(t *testing.T) {
+	verifier := newTestVerifier(1531420618)
+	assert.Equal(t, ErrMissingHeaders, verifier.Verify(http.Header{}, []byte(testBody)))
+	assert.Equal(t, ErrMissingHeaders, verifier.Verify(newTestHeader(testTimestamp, ""), []byte(testBody)))
+}
+
+func TestVerifyBadTimestamp(t *testing.T) {
+	verifier := newTestVerifier(1531420618)
+	err := verifier.Verify(newTestHeader("yesterday", testSignature), []byte(testBody))
+	assert.Equal(t, ErrBadTimestamp, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	verifier := newTestVerifier(1531420618)
+	var received string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		byt, _ := ioutil.ReadAll(r.Body)
+		received = string(byt)
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/tododo", strings.NewReader(testBody))
+	req.Header = newTestHeader(testTimestamp, testSignature)
+	rec := httptest.NewRecorder()
+	verifier.Middleware(next).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, testBody, received)
+}
+
+func TestMiddlewareUnauthorized(t *testing.T) {
+	verifier := newTestVerifier(1531420618)
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	req := httptest.NewRequest(http.MethodPost, "/tododo", strings.NewReader(testBody))
+	req.Header = newTestHeader(testTimestamp, "v0=deadbeef")
+	rec := httptest.NewRecorder()
+	verifier.Middleware(next).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.False(t, called)
+}
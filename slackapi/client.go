@@ -0,0 +1,287 @@
+// Package slackapi is a small client of the Slack Web API for the outbound calls of the bot - posting and updating messages and linking to them,
+// looking up users and channels and opening modals. Refer to https://api.slack.com/web for details.
+package slackapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of Client.
+const (
+	DefaultBaseURL    = "https://slack.com/api/"
+	DefaultMaxRetries = 3
+	DefaultMaxWait    = 20 * time.Second
+	DefaultRetryAfter = time.Second
+	DefaultTimeout    = 10 * time.Second
+)
+
+// ErrRateLimited is returned when Slack keeps answering 429 Too Many Requests after all retries,
+// or when waiting for the next retry would take longer than MaxWait or the deadline of the context.
+var ErrRateLimited = errors.New("slackapi: rate limited")
+
+// APIError is an error returned by Slack in the "error" field of a response with "ok": false, e.g. channel_not_found.
+type APIError struct {
+	Method string
+	Code   string
+}
+
+func (err *APIError) Error() string {
+	return "slackapi: " + err.Method + ": " + err.Code
+}
+
+// Message is a message posted by chat.postMessage or updated by chat.update.
+// Text is shown in notifications and is the fallback if Blocks can't be shown. Blocks is marshaled to json as it is, e.g. []*tododo.Block.
+// TS is the timestamp identifying the message, set only for chat.update.
+type Message struct {
+	Channel string      `json:"channel"`
+	TS      string      `json:"ts,omitempty"`
+	Text    string      `json:"text,omitempty"`
+	Blocks  interface{} `json:"blocks,omitempty"`
+}
+
+// MessageRef identifies a posted message. Channel is the ID of the channel, also when the message was posted to a user ID.
+type MessageRef struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// User is the part of a Slack user returned by users.info used by the bot. TZ is the IANA timezone name of the user.
+type User struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	TZ       string `json:"tz"`
+	IsBot    bool   `json:"is_bot"`
+	Deleted  bool   `json:"deleted"`
+}
+
+// Conversation is the part of a Slack channel returned by conversations.info used by the bot.
+type Conversation struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IsPrivate bool   `json:"is_private"`
+	IsIM      bool   `json:"is_im"`
+}
+
+// ClientInterface provides the Slack Web API methods used by the bot.
+type ClientInterface interface {
+	PostMessage(ctx context.Context, msg *Message) (*MessageRef, error)
+	UpdateMessage(ctx context.Context, msg *Message) error
+	GetUserInfo(ctx context.Context, userID string) (*User, error)
+	GetConversationInfo(ctx context.Context, channelID string) (*Conversation, error)
+	GetPermalink(ctx context.Context, channelID string, ts string) (string, error)
+	OpenView(ctx context.Context, triggerID string, view interface{}) (string, error)
+	PublishView(ctx context.Context, userID string, view interface{}) error
+}
+
+// Client implements ClientInterface with the bot token of the app.
+// Requests answered with 429 Too Many Requests are retried up to MaxRetries times after the time in the Retry-After header,
+// waiting no longer than MaxWait in total. Requests and waits stop when the context passed to the methods is done.
+type Client struct {
+	Token      string
+	BaseURL    string
+	HTTPClient *http.Client
+	MaxRetries int
+	MaxWait    time.Duration
+	// Sleep waits between retries, a timer stopped when the context is done if not set
+	Sleep func(d time.Duration)
+}
+
+// NewClient constructs a client. Pass the bot token of the app, xoxb-...
+// Default base url: https://slack.com/api/, 10 seconds timeout, 3 retries waiting at most 20 seconds.
+func NewClient(token string) *Client {
+	client := Client{}
+	client.Token = token
+	client.BaseURL = DefaultBaseURL
+	client.HTTPClient = &http.Client{Timeout: DefaultTimeout}
+	client.MaxRetries = DefaultMaxRetries
+	client.MaxWait = DefaultMaxWait
+	return &client
+}
+
+// PostMessage posts msg to msg.Channel, a channel or a user ID for a direct message. Returns the channel and timestamp of the message.
+func (client *Client) PostMessage(ctx context.Context, msg *Message) (*MessageRef, error) {
+	var ref MessageRef
+	err := client.postJSON(ctx, "chat.postMessage", msg, &ref)
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// UpdateMessage replaces text and blocks of the message identified by msg.Channel and msg.TS.
+func (client *Client) UpdateMessage(ctx context.Context, msg *Message) error {
+	return client.postJSON(ctx, "chat.update", msg, nil)
+}
+
+// GetUserInfo returns the user with userID. Returns APIError with code user_not_found if there is no such user.
+func (client *Client) GetUserInfo(ctx context.Context, userID string) (*User, error) {
+	var resp struct {
+		User *User `json:"user"`
+	}
+	err := client.postForm(ctx, "users.info", url.Values{"user": {userID}}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.User, nil
+}
+
+// GetConversationInfo returns the channel with channelID. Returns APIError with code channel_not_found if there is no such channel or the bot is not in it.
+func (client *Client) GetConversationInfo(ctx context.Context, channelID string) (*Conversation, error) {
+	var resp struct {
+		Channel *Conversation `json:"channel"`
+	}
+	err := client.postForm(ctx, "conversations.info", url.Values{"channel": {channelID}}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Channel, nil
+}
+
+// GetPermalink returns the link to the message with timestamp ts in the channel.
+// Returns APIError with code channel_not_found if there is no such channel or the bot can't see it.
+func (client *Client) GetPermalink(ctx context.Context, channelID string, ts string) (string, error) {
+	var resp struct {
+		Permalink string `json:"permalink"`
+	}
+	err := client.postForm(ctx, "chat.getPermalink", url.Values{"channel": {channelID}, "message_ts": {ts}}, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.Permalink, nil
+}
+
+// OpenView opens the modal view for the user who triggered the interaction with triggerID. Returns the ID of the view.
+// Trigger IDs expire 3 seconds after the interaction.
+func (client *Client) OpenView(ctx context.Context, triggerID string, view interface{}) (string, error) {
+	var resp struct {
+		View struct {
+			ID string `json:"id"`
+		} `json:"view"`
+	}
+	body := map[string]interface{}{"trigger_id": triggerID, "view": view}
+	err := client.postJSON(ctx, "views.open", body, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.View.ID, nil
+}
+
+// PublishView publishes the view of type home to the App Home of the user with userID, replacing the view published before.
+func (client *Client) PublishView(ctx context.Context, userID string, view interface{}) error {
+	body := map[string]interface{}{"user_id": userID, "view": view}
+	return client.postJSON(ctx, "views.publish", body, nil)
+}
+
+// postJSON calls method with body marshaled to json and unmarshals the response into result, if not nil.
+func (client *Client) postJSON(ctx context.Context, method string, body interface{}, result interface{}) error {
+	byt, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return client.call(ctx, method, "application/json; charset=utf-8", byt, result)
+}
+
+// postForm calls method with url encoded form. Some methods like users.info don't accept json.
+func (client *Client) postForm(ctx context.Context, method string, form url.Values, result interface{}) error {
+	return client.call(ctx, method, "application/x-www-form-urlencoded", []byte(form.Encode()), result)
+}
+
+// call posts body to method, retrying when rate limited, and unmarshals the response into result, if not nil.
+// Returns APIError if the response is not ok.
+func (client *Client) call(ctx context.Context, method string, contentType string, body []byte, result interface{}) error {
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(client.BaseURL, "/")+"/"+method, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+client.Token)
+		resp, err := client.HTTPClient.Do(req)
+		if err != nil {
+			return err
+		}
+		byt, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			wait := retryAfter(resp.Header.Get("Retry-After"))
+			if attempt >= client.MaxRetries || waited+wait > client.MaxWait || pastDeadline(ctx, wait) {
+				return fmt.Errorf("%w: %s", ErrRateLimited, method)
+			}
+			err = client.sleep(ctx, wait)
+			if err != nil {
+				return err
+			}
+			waited += wait
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("slackapi: %s returned %s", method, resp.Status)
+		}
+		return decode(method, byt, result)
+	}
+}
+
+// decode checks the ok field of a response and unmarshals it into result, if not nil.
+func decode(method string, byt []byte, result interface{}) error {
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	err := json.Unmarshal(byt, &status)
+	if err != nil {
+		return err
+	}
+	if !status.OK {
+		return &APIError{Method: method, Code: status.Error}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(byt, result)
+}
+
+// retryAfter parses the seconds in Retry-After header, DefaultRetryAfter if missing or invalid.
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return DefaultRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// pastDeadline reports whether the deadline of ctx passes before d from now.
+func pastDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Now().Add(d).After(deadline)
+}
+
+// sleep waits d with Sleep, if set, or until d passes or ctx is done. Returns the error of ctx if it is done.
+func (client *Client) sleep(ctx context.Context, d time.Duration) error {
+	if client.Sleep != nil {
+		client.Sleep(d)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,183 @@
+package slackapi_test
+
+import (
+	"context"
+	"errors"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/slackapi"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/slacktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var ctx = context.Background()
+
+func TestPostAndUpdateMessage(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddConversation(&slackapi.Conversation{ID: "C1", Name: "ops"})
+	client := server.NewClient()
+
+	ref, err := client.PostMessage(ctx, &slackapi.Message{Channel: "C1", Text: "Task added"})
+	require.NoError(t, err)
+	assert.Equal(t, "C1", ref.Channel)
+	assert.NotEmpty(t, ref.TS)
+
+	blocks := []map[string]string{{"type": "divider"}}
+	err = client.UpdateMessage(ctx, &slackapi.Message{Channel: ref.Channel, TS: ref.TS, Text: "Task done", Blocks: blocks})
+	require.NoError(t, err)
+	messages := server.Messages()
+	require.Equal(t, 1, len(messages))
+	assert.Equal(t, "Task done", messages[0].Text)
+	assert.NotNil(t, messages[0].Blocks)
+}
+
+func TestPostMessageErrors(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	_, err := client.PostMessage(ctx, &slackapi.Message{Channel: "C404", Text: "hello"})
+	assert.Equal(t, &slackapi.APIError{Method: "chat.postMessage", Code: "channel_not_found"}, err)
+	err = client.UpdateMessage(ctx, &slackapi.Message{Channel: "C1", TS: "1.2", Text: "hello"})
+	assert.Equal(t, &slackapi.APIError{Method: "chat.update", Code: "message_not_found"}, err)
+
+	client.Token = "xoxb-wrong"
+	_, err = client.GetUserInfo(ctx, "U1")
+	assert.Equal(t, &slackapi.APIError{Method: "users.info", Code: "invalid_auth"}, err)
+}
+
+func TestGetUserInfo(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(&slackapi.User{ID: "U1ABC", Name: "bob", TZ: "Europe/Sofia"})
+	client := server.NewClient()
+	user, err := client.GetUserInfo(ctx, "U1ABC")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Name)
+	assert.Equal(t, "Europe/Sofia", user.TZ)
+	_, err = client.GetUserInfo(ctx, "U404")
+	assert.Equal(t, &slackapi.APIError{Method: "users.info", Code: "user_not_found"}, err)
+}
+
+func TestGetConversationInfo(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddConversation(&slackapi.Conversation{ID: "C1", Name: "ops", IsPrivate: true})
+	client := server.NewClient()
+	channel, err := client.GetConversationInfo(ctx, "C1")
+	require.NoError(t, err)
+	assert.Equal(t, "ops", channel.Name)
+	assert.True(t, channel.IsPrivate)
+	_, err = client.GetConversationInfo(ctx, "C404")
+	assert.Equal(t, &slackapi.APIError{Method: "conversations.info", Code: "channel_not_found"}, err)
+}
+
+func TestGetPermalink(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddConversation(&slackapi.Conversation{ID: "C1", Name: "ops"})
+	client := server.NewClient()
+	link, err := client.GetPermalink(ctx, "C1", "1700000000.000100")
+	require.NoError(t, err)
+	assert.Equal(t, "https://slacktest.slack.com/archives/C1/p1700000000000100", link)
+	_, err = client.GetPermalink(ctx, "C404", "1700000000.000100")
+	assert.Equal(t, &slackapi.APIError{Method: "chat.getPermalink", Code: "channel_not_found"}, err)
+}
+
+func TestOpenView(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	id, err := client.OpenView(ctx, "T123", map[string]string{"type": "modal"})
+	require.NoError(t, err)
+	assert.Equal(t, "V1", id)
+	views := server.Views()
+	require.Equal(t, 1, len(views))
+	assert.Equal(t, "T123", views[0].TriggerID)
+	assert.JSONEq(t, `{"type":"modal"}`, string(views[0].View))
+	_, err = client.OpenView(ctx, "", map[string]string{"type": "modal"})
+	assert.Equal(t, &slackapi.APIError{Method: "views.open", Code: "invalid_trigger_id"}, err)
+}
+
+func TestPublishView(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	require.NoError(t, client.PublishView(ctx, "U1ABC", map[string]string{"type": "home"}))
+	published := server.Published()
+	require.Equal(t, 1, len(published))
+	assert.Equal(t, "U1ABC", published[0].UserID)
+	assert.JSONEq(t, `{"type":"home"}`, string(published[0].View))
+	assert.Empty(t, server.Views())
+	err := client.PublishView(ctx, "", map[string]string{"type": "home"})
+	assert.Equal(t, &slackapi.APIError{Method: "views.publish", Code: "invalid_arguments"}, err)
+}
+
+func TestRetryAfter(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(&slackapi.User{ID: "U1ABC", Name: "bob"})
+	server.RateLimit(2, 7)
+	client := server.NewClient()
+	waits := make([]time.Duration, 0)
+	client.Sleep = func(d time.Duration) { waits = append(waits, d) }
+	user, err := client.GetUserInfo(ctx, "U1ABC")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Name)
+	assert.Equal(t, []time.Duration{7 * time.Second, 7 * time.Second}, waits)
+	assert.Equal(t, []string{"users.info", "users.info", "users.info"}, server.Calls())
+}
+
+func TestRateLimited(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.RateLimit(10, 1)
+	client := server.NewClient()
+	client.MaxRetries = 2
+	_, err := client.GetUserInfo(ctx, "U1ABC")
+	assert.True(t, errors.Is(err, slackapi.ErrRateLimited))
+	assert.Equal(t, 3, len(server.Calls()))
+}
+
+func TestRateLimitedMaxWait(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(&slackapi.User{ID: "U1ABC", Name: "bob"})
+	server.RateLimit(2, 15)
+	client := server.NewClient()
+	waits := make([]time.Duration, 0)
+	client.Sleep = func(d time.Duration) { waits = append(waits, d) }
+	_, err := client.GetUserInfo(ctx, "U1ABC")
+	assert.True(t, errors.Is(err, slackapi.ErrRateLimited))
+	assert.Equal(t, []time.Duration{15 * time.Second}, waits)
+	assert.Equal(t, 2, len(server.Calls()))
+}
+
+func TestRateLimitedDeadline(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(&slackapi.User{ID: "U1ABC", Name: "bob"})
+	server.RateLimit(1, 5)
+	client := server.NewClient()
+	deadline, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	_, err := client.GetUserInfo(deadline, "U1ABC")
+	assert.True(t, errors.Is(err, slackapi.ErrRateLimited))
+	assert.Equal(t, 1, len(server.Calls()))
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.RateLimit(1, 5)
+	client := server.NewClient()
+	client.Sleep = nil
+	cancelled, cancel := context.WithCancel(ctx)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := client.GetUserInfo(cancelled, "U1ABC")
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, 1, len(server.Calls()))
+}
@@ -0,0 +1,15 @@
+package sqlite
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+)
+
+// ChannelSettingRepository implements mysql.ChannelSettingRepositoryInterface on top of a SQLite database file
+type ChannelSettingRepository struct {
+	mysql.ChannelSettingRepository
+}
+
+// NewChannelSettingRepository returns a repository sharing the database of tasks.
+func NewChannelSettingRepository(tasks *TaskRepository) *ChannelSettingRepository {
+	return &ChannelSettingRepository{mysql.ChannelSettingRepository{DB: tasks.DB}}
+}
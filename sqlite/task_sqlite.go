@@ -0,0 +1,36 @@
+// Package sqlite provides a pure-Go SQLite implementation of mysql.TaskRepositoryInterface for small installs.
+// The queries of the MySQL repository are portable, so TaskRepository reuses them and only owns the connection and the upsert of channel sequences.
+package sqlite
+
+import (
+	"database/sql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	//SQL Driver
+	_ "modernc.org/sqlite"
+)
+
+const driverName = "sqlite"
+
+// SequenceQuery increments the task number sequence of a channel, starting from 1.
+const SequenceQuery = "INSERT INTO CHANNEL_SEQUENCE (CHANNEL_ID, LAST_NUMBER) VALUES (?, 1) ON CONFLICT (CHANNEL_ID) DO UPDATE SET LAST_NUMBER = LAST_NUMBER + 1"
+
+// TaskRepository implements mysql.TaskRepositoryInterface on top of a SQLite database file
+type TaskRepository struct {
+	mysql.TaskRepository
+}
+
+// Open opens the SQLite database at path and returns a repository. The schema is managed by package migrate.
+// SQLite allows one writer at a time, so the pool is limited to a single connection.
+func Open(path string) (*TaskRepository, error) {
+	db, err := sql.Open(driverName, "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return &TaskRepository{mysql.TaskRepository{DB: db, SequenceQuery: SequenceQuery}}, nil
+}
+
+// Close closes the underlying database.
+func (repo *TaskRepository) Close() error {
+	return repo.DB.Close()
+}
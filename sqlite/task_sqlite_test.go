@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/migrate"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/repotest"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+var ctx = context.Background()
+
+func openMigrated(t *testing.T, path string) *TaskRepository {
+	repo, err := Open(path)
+	require.NoError(t, err)
+	m, err := migrate.New(repo.DB, migrate.DialectSQLite)
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+	return repo
+}
+
+func newTestRepository(t *testing.T) *TaskRepository {
+	repo := openMigrated(t, filepath.Join(t.TempDir(), "tododo.db"))
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestConformance(t *testing.T) {
+	repotest.RunConformance(t, func(t *testing.T) mysql.TaskRepositoryInterface {
+		return newTestRepository(t)
+	})
+}
+
+func TestUserSettingConformance(t *testing.T) {
+	repotest.RunUserSettingConformance(t, func(t *testing.T) mysql.UserSettingRepositoryInterface {
+		return NewUserSettingRepository(newTestRepository(t))
+	})
+}
+
+func TestChannelSettingConformance(t *testing.T) {
+	repotest.RunChannelSettingConformance(t, func(t *testing.T) mysql.ChannelSettingRepositoryInterface {
+		return NewChannelSettingRepository(newTestRepository(t))
+	})
+}
+
+func TestOpenExistingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tododo.db")
+	repo := openMigrated(t, path)
+	task := mysql.NewTask("survives reopen", "C1", "U1")
+	require.NoError(t, repo.PersistTask(ctx, task))
+	require.NoError(t, repo.Close())
+
+	repo, err := Open(path)
+	require.NoError(t, err)
+	defer repo.Close()
+	res, err := repo.GetTask(ctx, task.ChannelID, task.Number)
+	require.NoError(t, err)
+	require.Equal(t, task, res)
+}
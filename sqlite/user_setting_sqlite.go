@@ -0,0 +1,15 @@
+package sqlite
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+)
+
+// UserSettingRepository implements mysql.UserSettingRepositoryInterface on top of a SQLite database file
+type UserSettingRepository struct {
+	mysql.UserSettingRepository
+}
+
+// NewUserSettingRepository returns a repository sharing the database of tasks.
+func NewUserSettingRepository(tasks *TaskRepository) *UserSettingRepository {
+	return &UserSettingRepository{mysql.UserSettingRepository{DB: tasks.DB}}
+}
@@ -1,15 +1,81 @@
 package tododo
 
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits of Block Kit enforced by Slack. Refer to https://api.slack.com/reference/block-kit/blocks for details.
+// Lengths are in characters.
+const (
+	MaxBlocks             = 50
+	MaxHeaderTextLength   = 150
+	MaxSectionTextLength  = 3000
+	MaxSectionFields      = 10
+	MaxFieldTextLength    = 2000
+	MaxActionsElements    = 25
+	MaxContextElements    = 10
+	MaxContextTextLength  = 3000
+	MaxButtonTextLength   = 75
+	MaxButtonValueLength  = 2000
+	MaxActionIDLength     = 255
+	MaxBlockIDLength      = 255
+	MaxViewBlocks         = 100
+	MaxViewTitleLength    = 24
+	MaxInputLabelLength   = 2000
+	MaxPlaceholderLength  = 150
+	MaxOptions            = 100
+	MaxOptionTextLength   = 75
+	MaxOptionValueLength  = 75
+	MaxMetadataLength     = 3000
+	truncatedTextEllipsis = "…"
+)
+
+// Response types of a response to a slash command. An ephemeral response is visible only to the user who sent the command,
+// an in_channel response is posted to the channel.
+const (
+	ResponseEphemeral = "ephemeral"
+	ResponseInChannel = "in_channel"
+)
+
 // Response is an object used to visualize server's response in slack chat. Refer to https://app.slack.com/block-kit-builder for details.
+// ResponseType is ResponseEphemeral or ResponseInChannel.
+// Set ReplaceOriginal or DeleteOriginal when the response is sent to response_url of an interaction to update or delete the original message,
+// the visibility of the original message is kept then.
 type Response struct {
-	Blocks []*Block `json:"blocks"`
+	ResponseType    string   `json:"response_type,omitempty"`
+	ReplaceOriginal bool     `json:"replace_original,omitempty"`
+	DeleteOriginal  bool     `json:"delete_original,omitempty"`
+	Blocks          []*Block `json:"blocks"`
 }
 
-// Block has a type(section, header, divider), can have text of type BlockText, can have fields of type BlockField
+// Block has a type(section, header, divider, actions, context, input), can have text of type BlockText, can have fields of type BlockField.
+// Actions block has interactive elements, section can have one element as accessory. Context block has small texts in ContextTexts.
+// Input block of a modal has a Label and one input Element, Slack requires a value unless it is Optional.
 type Block struct {
-	Type    string        `json:"type"`
-	BText   *BlockText    `json:"text,omitempty"`
-	BFields []*BlockField `json:"fields,omitempty"`
+	Type         string          `json:"type"`
+	BlockID      string          `json:"block_id,omitempty"`
+	BText        *BlockText      `json:"text,omitempty"`
+	BFields      []*BlockField   `json:"fields,omitempty"`
+	Accessory    *BlockElement   `json:"accessory,omitempty"`
+	Elements     []*BlockElement `json:"elements,omitempty"`
+	ContextTexts []*BlockText    `json:"-"`
+	Label        *BlockText      `json:"label,omitempty"`
+	Element      *BlockElement   `json:"element,omitempty"`
+	Optional     bool            `json:"optional,omitempty"`
+}
+
+// MarshalJSON writes the texts of a context block as its elements.
+func (block Block) MarshalJSON() ([]byte, error) {
+	type plainBlock Block
+	if block.Type != "context" {
+		return json.Marshal(plainBlock(block))
+	}
+	return json.Marshal(struct {
+		plainBlock
+		Elements []*BlockText `json:"elements"`
+	}{plainBlock(block), block.ContextTexts})
 }
 
 // BlockText is text element in block. Example types "plain_text", "mrkdwn".
@@ -24,12 +90,49 @@ type BlockField struct {
 	Text string `json:"text"`
 }
 
+// BlockElement is an interactive element of type "button" or an input element of a modal - "plain_text_input", "users_select", "datepicker" or "static_select".
+// Value of a button is sent back to the interactivity endpoint when the button is clicked. Style can be empty, "primary" or "danger".
+// Input elements are shown with InitialValue, InitialUser, InitialDate in format YYYY-MM-DD or InitialOption, one of Options, if set.
+type BlockElement struct {
+	Type          string     `json:"type"`
+	Text          *BlockText `json:"text,omitempty"`
+	ActionID      string     `json:"action_id,omitempty"`
+	Value         string     `json:"value,omitempty"`
+	Style         string     `json:"style,omitempty"`
+	Placeholder   *BlockText `json:"placeholder,omitempty"`
+	InitialValue  string     `json:"initial_value,omitempty"`
+	InitialUser   string     `json:"initial_user,omitempty"`
+	InitialDate   string     `json:"initial_date,omitempty"`
+	InitialOption *Option    `json:"initial_option,omitempty"`
+	Options       []*Option  `json:"options,omitempty"`
+	Multiline     bool       `json:"multiline,omitempty"`
+	MaxLength     int        `json:"max_length,omitempty"`
+}
+
+// Option is a choice of a static_select element. Value is sent back when the option is selected.
+type Option struct {
+	Text  *BlockText `json:"text"`
+	Value string     `json:"value"`
+}
+
+// View is a modal opened with views.open or a home view published with views.publish. Refer to https://api.slack.com/reference/surfaces/views for details.
+// CallbackID identifies the modal in the view_submission payload, PrivateMetadata is sent back with it unchanged.
+type View struct {
+	Type            string     `json:"type"`
+	CallbackID      string     `json:"callback_id,omitempty"`
+	PrivateMetadata string     `json:"private_metadata,omitempty"`
+	Title           *BlockText `json:"title,omitempty"`
+	Submit          *BlockText `json:"submit,omitempty"`
+	Close           *BlockText `json:"close,omitempty"`
+	Blocks          []*Block   `json:"blocks"`
+}
+
 // NewSectionTextBlock constructs block of type "section" with one text element.
-// Pass text type - "plain_text" or "markdown" and text.
+// Pass text type - "plain_text" or "markdown" and text. Text longer than MaxSectionTextLength is truncated.
 func NewSectionTextBlock(textType string, text string) *Block {
 	block := Block{}
 	block.Type = "section"
-	block.BText = &BlockText{Type: textType, Text: text}
+	block.BText = &BlockText{Type: textType, Text: Truncate(text, MaxSectionTextLength)}
 	return &block
 }
 
@@ -47,11 +150,11 @@ func NewSectionFieldsBlock(fields ...*BlockField) *Block {
 }
 
 // NewHeaderBlock constructs a block of type "header".
-// Pass the text of the header.
+// Pass the text of the header. Text longer than MaxHeaderTextLength is truncated.
 func NewHeaderBlock(text string) *Block {
 	block := Block{}
 	block.Type = "header"
-	block.BText = &BlockText{Type: "plain_text", Text: text}
+	block.BText = &BlockText{Type: "plain_text", Text: Truncate(text, MaxHeaderTextLength)}
 	return &block
 }
 
@@ -64,18 +167,144 @@ func NewDividerBlock() *Block {
 }
 
 // NewField constructs a field that will be put inside of block.
-// Pass field type and text.
+// Pass field type and text. Text longer than MaxFieldTextLength is truncated.
 func NewField(fieldType string, text string) *BlockField {
 	field := BlockField{}
 	field.Type = fieldType
-	field.Text = text
+	field.Text = Truncate(text, MaxFieldTextLength)
 	return &field
 }
 
-// NewResponse constructs the final response to be returned to slack client.
+// NewButton constructs an element of type "button".
+// Pass the label of the button, action id to identify the action and value, e.g. task id. A label longer than MaxButtonTextLength is truncated.
+func NewButton(text string, actionID string, value string) *BlockElement {
+	button := BlockElement{}
+	button.Type = "button"
+	button.Text = &BlockText{Type: "plain_text", Text: Truncate(text, MaxButtonTextLength)}
+	button.ActionID = actionID
+	button.Value = value
+	return &button
+}
+
+// NewActionsBlock constructs a block of type "actions".
+// Pass block id and any number of BlockElement objects.
+func NewActionsBlock(blockID string, elements ...*BlockElement) *Block {
+	block := Block{}
+	block.Type = "actions"
+	block.BlockID = blockID
+	arr := make([]*BlockElement, 0)
+	for _, e := range elements {
+		arr = append(arr, e)
+	}
+	block.Elements = arr
+	return &block
+}
+
+// NewContextBlock constructs a block of type "context" showing small texts in a row, e.g. a line of a history.
+// Pass any number of BlockText objects. Texts longer than MaxContextTextLength are truncated.
+func NewContextBlock(texts ...*BlockText) *Block {
+	block := Block{}
+	block.Type = "context"
+	arr := make([]*BlockText, 0)
+	for _, t := range texts {
+		arr = append(arr, &BlockText{Type: t.Type, Text: Truncate(t.Text, MaxContextTextLength)})
+	}
+	block.ContextTexts = arr
+	return &block
+}
+
+// NewInputBlock constructs a block of type "input" of a modal.
+// Pass block id, the label, whether the input is optional and the input element. A label longer than MaxInputLabelLength is truncated.
+func NewInputBlock(blockID string, label string, optional bool, element *BlockElement) *Block {
+	block := Block{}
+	block.Type = "input"
+	block.BlockID = blockID
+	block.Label = &BlockText{Type: "plain_text", Text: Truncate(label, MaxInputLabelLength)}
+	block.Optional = optional
+	block.Element = element
+	return &block
+}
+
+// NewPlainTextInput constructs an element of type "plain_text_input".
+// Pass action id, initial value, maximum length of the value and whether it has several lines.
+func NewPlainTextInput(actionID string, initialValue string, maxLength int, multiline bool) *BlockElement {
+	element := BlockElement{}
+	element.Type = "plain_text_input"
+	element.ActionID = actionID
+	element.InitialValue = initialValue
+	element.MaxLength = maxLength
+	element.Multiline = multiline
+	return &element
+}
+
+// NewUsersSelect constructs an element of type "users_select" choosing a user of the workspace.
+// Pass action id and the Slack user ID of the initially selected user, empty for none.
+func NewUsersSelect(actionID string, initialUser string) *BlockElement {
+	element := BlockElement{}
+	element.Type = "users_select"
+	element.ActionID = actionID
+	element.InitialUser = initialUser
+	return &element
+}
+
+// NewDatePicker constructs an element of type "datepicker".
+// Pass action id and the initially selected date in format YYYY-MM-DD, empty for none.
+func NewDatePicker(actionID string, initialDate string) *BlockElement {
+	element := BlockElement{}
+	element.Type = "datepicker"
+	element.ActionID = actionID
+	element.InitialDate = initialDate
+	return &element
+}
+
+// NewStaticSelect constructs an element of type "static_select".
+// Pass action id, the initially selected option, nil for none, and the options.
+func NewStaticSelect(actionID string, initialOption *Option, options ...*Option) *BlockElement {
+	element := BlockElement{}
+	element.Type = "static_select"
+	element.ActionID = actionID
+	element.InitialOption = initialOption
+	element.Options = append([]*Option{}, options...)
+	return &element
+}
+
+// WithPlaceholder sets the placeholder shown by the input element while it is empty and returns the element.
+// A placeholder longer than MaxPlaceholderLength is truncated.
+func (element *BlockElement) WithPlaceholder(text string) *BlockElement {
+	element.Placeholder = &BlockText{Type: "plain_text", Text: Truncate(text, MaxPlaceholderLength)}
+	return element
+}
+
+// NewOption constructs an option of a static_select element. Text longer than MaxOptionTextLength is truncated.
+func NewOption(text string, value string) *Option {
+	return &Option{Text: &BlockText{Type: "plain_text", Text: Truncate(text, MaxOptionTextLength)}, Value: value}
+}
+
+// NewModal constructs a view of type "modal".
+// Pass callback id, the title and the label of the submit button, both truncated to MaxViewTitleLength, and any number of Block objects.
+func NewModal(callbackID string, title string, submit string, blocks ...*Block) *View {
+	view := View{}
+	view.Type = "modal"
+	view.CallbackID = callbackID
+	view.Title = &BlockText{Type: "plain_text", Text: Truncate(title, MaxViewTitleLength)}
+	view.Submit = &BlockText{Type: "plain_text", Text: Truncate(submit, MaxViewTitleLength)}
+	view.Blocks = append([]*Block{}, blocks...)
+	return &view
+}
+
+// NewHomeView constructs a view of type "home" for the App Home tab. Pass any number of Block objects.
+func NewHomeView(blocks ...*Block) *View {
+	view := View{}
+	view.Type = "home"
+	view.Blocks = append([]*Block{}, blocks...)
+	return &view
+}
+
+// NewResponse constructs the final response to be returned to slack client. The response is ephemeral.
 // Pass any number of Block objects
 func NewResponse(blocks ...*Block) *Response {
 	resp := Response{}
+	resp.ResponseType = ResponseEphemeral
 	arr := make([]*Block, 0)
 	for _, b := range blocks {
 		arr = append(arr, b)
@@ -83,3 +312,184 @@ func NewResponse(blocks ...*Block) *Response {
 	resp.Blocks = arr
 	return &resp
 }
+
+// Truncate shortens text to max characters, the last one is replaced with an ellipsis. Text within the limit is returned as it is.
+func Truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:max-1]) + truncatedTextEllipsis
+}
+
+// Validate checks the response against the limits of Block Kit, so it is not rejected by Slack.
+// Returns error describing the first block that violates a limit.
+func (resp *Response) Validate() error {
+	if len(resp.Blocks) > MaxBlocks {
+		return fmt.Errorf("response has %d blocks, Slack allows %d", len(resp.Blocks), MaxBlocks)
+	}
+	for i, block := range resp.Blocks {
+		err := block.Validate()
+		if err != nil {
+			return fmt.Errorf("block %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the view against the limits of Block Kit, so views.open and views.publish don't fail.
+// A modal needs a title, a home view can have none. Returns error describing the first violated limit.
+func (view *View) Validate() error {
+	if view.Type == "modal" && view.Title == nil {
+		return fmt.Errorf("view needs a title")
+	}
+	if view.Title != nil {
+		err := checkLength("view title", view.Title.Text, MaxViewTitleLength)
+		if err != nil {
+			return err
+		}
+	}
+	err := checkLength("private_metadata", view.PrivateMetadata, MaxMetadataLength)
+	if err != nil {
+		return err
+	}
+	if len(view.Blocks) > MaxViewBlocks {
+		return fmt.Errorf("view has %d blocks, Slack allows %d", len(view.Blocks), MaxViewBlocks)
+	}
+	for i, block := range view.Blocks {
+		err = block.Validate()
+		if err != nil {
+			return fmt.Errorf("block %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the block and its elements against the limits of Block Kit.
+func (block *Block) Validate() error {
+	if utf8.RuneCountInString(block.BlockID) > MaxBlockIDLength {
+		return fmt.Errorf("block_id is longer than %d characters", MaxBlockIDLength)
+	}
+	switch block.Type {
+	case "divider":
+		return nil
+	case "header":
+		if block.BText == nil || block.BText.Type != PlainTextType {
+			return fmt.Errorf("header needs plain_text text")
+		}
+		return checkLength("header text", block.BText.Text, MaxHeaderTextLength)
+	case "section":
+		if block.BText == nil && len(block.BFields) == 0 {
+			return fmt.Errorf("section needs text or fields")
+		}
+		if block.BText != nil {
+			err := checkLength("section text", block.BText.Text, MaxSectionTextLength)
+			if err != nil {
+				return err
+			}
+		}
+		if len(block.BFields) > MaxSectionFields {
+			return fmt.Errorf("section has %d fields, Slack allows %d", len(block.BFields), MaxSectionFields)
+		}
+		for _, field := range block.BFields {
+			err := checkLength("field text", field.Text, MaxFieldTextLength)
+			if err != nil {
+				return err
+			}
+		}
+		if block.Accessory != nil {
+			return block.Accessory.Validate()
+		}
+		return nil
+	case "context":
+		if len(block.ContextTexts) == 0 || len(block.ContextTexts) > MaxContextElements {
+			return fmt.Errorf("context has %d elements, Slack allows 1 to %d", len(block.ContextTexts), MaxContextElements)
+		}
+		for _, text := range block.ContextTexts {
+			err := checkLength("context text", text.Text, MaxContextTextLength)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "actions":
+		if len(block.Elements) == 0 || len(block.Elements) > MaxActionsElements {
+			return fmt.Errorf("actions has %d elements, Slack allows 1 to %d", len(block.Elements), MaxActionsElements)
+		}
+		for _, element := range block.Elements {
+			err := element.Validate()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "input":
+		if block.Label == nil || block.Element == nil {
+			return fmt.Errorf("input needs a label and an element")
+		}
+		err := checkLength("label", block.Label.Text, MaxInputLabelLength)
+		if err != nil {
+			return err
+		}
+		return block.Element.Validate()
+	}
+	return fmt.Errorf("unknown block type %s", block.Type)
+}
+
+// Validate checks the button or the input element against the limits of Block Kit.
+func (element *BlockElement) Validate() error {
+	switch element.Type {
+	case "button":
+	case "plain_text_input", "users_select", "datepicker":
+		return element.validateInput()
+	case "static_select":
+		if len(element.Options) == 0 || len(element.Options) > MaxOptions {
+			return fmt.Errorf("static_select has %d options, Slack allows 1 to %d", len(element.Options), MaxOptions)
+		}
+		for _, option := range element.Options {
+			err := checkLength("option text", option.Text.Text, MaxOptionTextLength)
+			if err != nil {
+				return err
+			}
+			err = checkLength("option value", option.Value, MaxOptionValueLength)
+			if err != nil {
+				return err
+			}
+		}
+		return element.validateInput()
+	default:
+		return fmt.Errorf("unknown element type %s", element.Type)
+	}
+	if element.Text == nil || element.Text.Type != PlainTextType {
+		return fmt.Errorf("button needs plain_text text")
+	}
+	err := checkLength("button text", element.Text.Text, MaxButtonTextLength)
+	if err != nil {
+		return err
+	}
+	err = checkLength("button value", element.Value, MaxButtonValueLength)
+	if err != nil {
+		return err
+	}
+	return checkLength("action_id", element.ActionID, MaxActionIDLength)
+}
+
+// validateInput checks the action id and the placeholder of an input element.
+func (element *BlockElement) validateInput() error {
+	if element.Placeholder != nil {
+		err := checkLength("placeholder", element.Placeholder.Text, MaxPlaceholderLength)
+		if err != nil {
+			return err
+		}
+	}
+	return checkLength("action_id", element.ActionID, MaxActionIDLength)
+}
+
+// checkLength returns error if text is longer than max characters.
+func checkLength(name string, text string, max int) error {
+	length := utf8.RuneCountInString(text)
+	if length > max {
+		return fmt.Errorf("%s is %d characters, Slack allows %d", name, length, max)
+	}
+	return nil
+}
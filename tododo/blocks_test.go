@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestNewSectionTextBlock(t *testing.T) {
@@ -100,6 +102,67 @@ func ExampleNewField() {
 	// Output: {"type":"mrkdwn","text":"hello"}
 }
 
+func TestNewButton(t *testing.T) {
+	expected := &BlockElement{
+		Type:     "button",
+		Text:     &BlockText{Type: "plain_text", Text: "Start"},
+		ActionID: "start",
+		Value:    "1",
+	}
+	real := NewButton("Start", "start", "1")
+	if !reflect.DeepEqual(expected, real) {
+		t.Errorf("Expected equal but not equal, expected: %v , real: %v", expected, real)
+	}
+}
+
+func ExampleNewButton() {
+	button := NewButton("Start", "start", "1")
+	byt, _ := json.Marshal(button)
+	fmt.Println(string(byt))
+	// Output: {"type":"button","text":{"type":"plain_text","text":"Start"},"action_id":"start","value":"1"}
+}
+
+func TestNewActionsBlock(t *testing.T) {
+	button1 := NewButton("Start", "start", "1")
+	button2 := NewButton("Done", "done", "1")
+	expected := &Block{
+		Type:     "actions",
+		BlockID:  "task_1",
+		Elements: []*BlockElement{button1, button2},
+	}
+	real := NewActionsBlock("task_1", button1, button2)
+	if !reflect.DeepEqual(expected, real) {
+		t.Errorf("Expected equal but not equal, expected: %v , real: %v", expected, real)
+	}
+}
+
+func ExampleNewActionsBlock() {
+	block := NewActionsBlock("task_1", NewButton("Done", "done", "1"))
+	byt, _ := json.Marshal(block)
+	fmt.Println(string(byt))
+	// Output: {"type":"actions","block_id":"task_1","elements":[{"type":"button","text":{"type":"plain_text","text":"Done"},"action_id":"done","value":"1"}]}
+}
+
+func TestNewContextBlock(t *testing.T) {
+	text1 := &BlockText{Type: MarkdownType, Text: "yesterday"}
+	text2 := &BlockText{Type: PlainTextType, Text: strings.Repeat("a", MaxContextTextLength+1)}
+	expected := &Block{
+		Type:         "context",
+		ContextTexts: []*BlockText{text1, &BlockText{Type: PlainTextType, Text: strings.Repeat("a", MaxContextTextLength-1) + "…"}},
+	}
+	real := NewContextBlock(text1, text2)
+	if !reflect.DeepEqual(expected, real) {
+		t.Errorf("Expected equal but not equal, expected: %v , real: %v", expected, real)
+	}
+}
+
+func ExampleNewContextBlock() {
+	block := NewContextBlock(&BlockText{Type: MarkdownType, Text: "yesterday"}, &BlockText{Type: MarkdownType, Text: "*done*"})
+	byt, _ := json.Marshal(block)
+	fmt.Println(string(byt))
+	// Output: {"type":"context","elements":[{"type":"mrkdwn","text":"yesterday"},{"type":"mrkdwn","text":"*done*"}]}
+}
+
 func TestNewResponse(t *testing.T) {
 	block1 := &Block{
 		Type: "divider",
@@ -109,7 +172,8 @@ func TestNewResponse(t *testing.T) {
 		BText: &BlockText{Type: "plain_text", Text: "hello"},
 	}
 	expected := &Response{
-		Blocks: []*Block{block1, block2},
+		ResponseType: ResponseEphemeral,
+		Blocks:       []*Block{block1, block2},
 	}
 	real := NewResponse(block1, block2)
 	if !reflect.DeepEqual(expected, real) {
@@ -124,5 +188,139 @@ func ExampleNewResponse() {
 	resp := NewResponse(block1, div, block2)
 	byt, _ := json.Marshal(resp)
 	fmt.Println(string(byt))
-	// Output: {"blocks":[{"type":"header","text":{"type":"plain_text","text":"hello"}},{"type":"divider"},{"type":"section","text":{"type":"mrkdwn","text":"welcome"}}]}
+	// Output: {"response_type":"ephemeral","blocks":[{"type":"header","text":{"type":"plain_text","text":"hello"}},{"type":"divider"},{"type":"section","text":{"type":"mrkdwn","text":"welcome"}}]}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text     string
+		max      int
+		expected string
+	}{
+		{"hello", 5, "hello"},
+		{"hello", 10, "hello"},
+		{"hello world", 5, "hell…"},
+		{"здравей свят", 8, "здравей…"},
+	}
+	for _, tc := range tests {
+		real := Truncate(tc.text, tc.max)
+		if real != tc.expected {
+			t.Errorf("Expected %q but got %q", tc.expected, real)
+		}
+	}
+}
+
+func ExampleTruncate() {
+	fmt.Println(Truncate("Update TLS certificates on all load balancers", 20))
+	// Output: Update TLS certific…
+}
+
+func TestBuildersTruncate(t *testing.T) {
+	long := strings.Repeat("a", 5000)
+	lengths := map[string]int{
+		"header":  utf8.RuneCountInString(NewHeaderBlock(long).BText.Text),
+		"section": utf8.RuneCountInString(NewSectionTextBlock(MarkdownType, long).BText.Text),
+		"field":   utf8.RuneCountInString(NewField(MarkdownType, long).Text),
+		"button":  utf8.RuneCountInString(NewButton(long, "start", "1").Text.Text),
+	}
+	expected := map[string]int{
+		"header":  MaxHeaderTextLength,
+		"section": MaxSectionTextLength,
+		"field":   MaxFieldTextLength,
+		"button":  MaxButtonTextLength,
+	}
+	if !reflect.DeepEqual(expected, lengths) {
+		t.Errorf("Expected equal but not equal, expected: %v , real: %v", expected, lengths)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	long := strings.Repeat("a", 3001)
+	fields := make([]*BlockField, 0)
+	for i := 0; i < MaxSectionFields+1; i++ {
+		fields = append(fields, NewField(MarkdownType, "field"))
+	}
+	buttons := make([]*BlockElement, 0)
+	for i := 0; i < MaxActionsElements+1; i++ {
+		buttons = append(buttons, NewButton("Done", "done", "1"))
+	}
+	tooManyBlocks := make([]*Block, 0)
+	for i := 0; i < MaxBlocks+1; i++ {
+		tooManyBlocks = append(tooManyBlocks, NewDividerBlock())
+	}
+	tests := []struct {
+		resp *Response
+		err  string
+	}{
+		{NewResponse(NewHeaderBlock("hello"), NewDividerBlock(), NewSectionTextBlock(MarkdownType, "welcome"), NewActionsBlock("task_1", NewButton("Done", "done", "1"))), ""},
+		{NewResponse(tooManyBlocks...), "response has 51 blocks, Slack allows 50"},
+		{NewResponse(&Block{Type: "header", BText: &BlockText{Type: PlainTextType, Text: long}}), "block 0: header text is 3001 characters, Slack allows 150"},
+		{NewResponse(&Block{Type: "header", BText: &BlockText{Type: MarkdownType, Text: "hello"}}), "block 0: header needs plain_text text"},
+		{NewResponse(NewDividerBlock(), &Block{Type: "section", BText: &BlockText{Type: MarkdownType, Text: long}}), "block 1: section text is 3001 characters, Slack allows 3000"},
+		{NewResponse(&Block{Type: "section"}), "block 0: section needs text or fields"},
+		{NewResponse(NewSectionFieldsBlock(fields...)), "block 0: section has 11 fields, Slack allows 10"},
+		{NewResponse(NewSectionFieldsBlock(&BlockField{Type: MarkdownType, Text: long})), "block 0: field text is 3001 characters, Slack allows 2000"},
+		{NewResponse(NewActionsBlock("task_1")), "block 0: actions has 0 elements, Slack allows 1 to 25"},
+		{NewResponse(NewActionsBlock("task_1", buttons...)), "block 0: actions has 26 elements, Slack allows 1 to 25"},
+		{NewResponse(NewActionsBlock("task_1", NewButton("Done", "done", long))), "block 0: button value is 3001 characters, Slack allows 2000"},
+		{NewResponse(NewActionsBlock(long, NewButton("Done", "done", "1"))), "block 0: block_id is longer than 255 characters"},
+		{NewResponse(NewContextBlock()), "block 0: context has 0 elements, Slack allows 1 to 10"},
+		{NewResponse(&Block{Type: "context", ContextTexts: []*BlockText{&BlockText{Type: MarkdownType, Text: long + "a"}}}), "block 0: context text is 3002 characters, Slack allows 3000"},
+		{NewResponse(&Block{Type: "image"}), "block 0: unknown block type image"},
+	}
+	for _, tc := range tests {
+		err := tc.resp.Validate()
+		if tc.err == "" && err != nil {
+			t.Errorf("Expected valid response but got %s", err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("Expected error %q but got %v", tc.err, err)
+		}
+	}
+}
+
+func ExampleNewInputBlock() {
+	block := NewInputBlock("tododo_due", "Due date", true, NewDatePicker("tododo_due", "2026-11-02"))
+	byt, _ := json.Marshal(block)
+	fmt.Println(string(byt))
+	// Output: {"type":"input","block_id":"tododo_due","label":{"type":"plain_text","text":"Due date"},"element":{"type":"datepicker","action_id":"tododo_due","initial_date":"2026-11-02"},"optional":true}
+}
+
+func TestValidateView(t *testing.T) {
+	long := strings.Repeat("a", 3001)
+	options := make([]*Option, 0)
+	for i := 0; i < MaxOptions+1; i++ {
+		options = append(options, NewOption("P1", "1"))
+	}
+	withMetadata := NewModal("modal", "Add task", "Add")
+	withMetadata.PrivateMetadata = long
+	blocks := make([]*Block, 0)
+	for i := 0; i < MaxViewBlocks+1; i++ {
+		blocks = append(blocks, NewDividerBlock())
+	}
+	tests := []struct {
+		view *View
+		err  string
+	}{
+		{NewModal("modal", "Add task", "Add", NewInputBlock("title", "Title", false, NewPlainTextInput("title", "", 60, false)), NewInputBlock("assignee", "Assignee", true, NewUsersSelect("assignee", "U1").WithPlaceholder("Not assigned"))), ""},
+		{&View{Type: "modal"}, "view needs a title"},
+		{NewHomeView(NewHeaderBlock("My tasks")), ""},
+		{NewHomeView(blocks...), "view has 101 blocks, Slack allows 100"},
+		{&View{Type: "modal", Title: &BlockText{Type: PlainTextType, Text: "A title longer than the limit"}}, "view title is 29 characters, Slack allows 24"},
+		{withMetadata, "private_metadata is 3001 characters, Slack allows 3000"},
+		{NewModal("modal", "Add task", "Add", &Block{Type: "input", Label: &BlockText{Type: PlainTextType, Text: "Title"}}), "block 0: input needs a label and an element"},
+		{NewModal("modal", "Add task", "Add", NewInputBlock("priority", "Priority", true, NewStaticSelect("priority", nil))), "block 0: static_select has 0 options, Slack allows 1 to 100"},
+		{NewModal("modal", "Add task", "Add", NewInputBlock("priority", "Priority", true, NewStaticSelect("priority", nil, options...))), "block 0: static_select has 101 options, Slack allows 1 to 100"},
+		{NewModal("modal", "Add task", "Add", NewInputBlock("priority", "Priority", true, NewStaticSelect("priority", nil, &Option{Text: &BlockText{Type: PlainTextType, Text: "P1"}, Value: long}))), "block 0: option value is 3001 characters, Slack allows 75"},
+		{NewModal("modal", "Add task", "Add", NewInputBlock("channel", "Channel", false, &BlockElement{Type: "channels_select"})), "block 0: unknown element type channels_select"},
+	}
+	for _, tc := range tests {
+		err := tc.view.Validate()
+		if tc.err == "" && err != nil {
+			t.Errorf("Expected valid view but got %s", err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("Expected error %q but got %v", tc.err, err)
+		}
+	}
 }
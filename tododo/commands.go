@@ -3,49 +3,133 @@ Response body is structured in json format that conforms to Slack's Block Kit UI
 package tododo
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/slackapi"
 	"github.com/nlopes/slack"
+	"log"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // CommandHandlerInterface introduces functions to pass commands to the proper command handlers and return body of response to be forwarded and displayed in Slack.
 type CommandHandlerInterface interface {
-	HandleCommand(c *slack.SlashCommand) ([]byte, error)
+	HandleCommand(ctx context.Context, c *slack.SlashCommand) ([]byte, error)
 	HandleHelpCommand() ([]byte, error)
-	HandleAddCommand(text string, channelID string) ([]byte, error)
-	HandleShowCommand(text string, channelID string) ([]byte, error)
-	HandleAssignCommand(text string) ([]byte, error)
-	HandleProgressCommand(text string) ([]byte, error)
-	HandleDoneCommand(text string) ([]byte, error)
+	HandleAddCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleShowCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleAssignCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleProgressCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleDoneCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleTimezoneCommand(ctx context.Context, text string, userID string) ([]byte, error)
+	HandleInteraction(ctx context.Context, payload *InteractionPayload) ([]byte, error)
+	HandleEditCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleDeleteCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleRestoreCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleVisibilityCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleHistoryCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandlePolicyCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleAdminCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandlePriorityCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleTagCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleLabelsCommand(ctx context.Context, channelID string) ([]byte, error)
+	HandleMoveCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleWorkflowCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleMineCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error)
+	HandleEvent(ctx context.Context, payload *EventPayload) error
+	HandleAddFromMessage(ctx context.Context, payload *InteractionPayload) ([]byte, error)
+	OpenTaskModal(ctx context.Context, triggerID string, channelID string, userID string, number int) ([]byte, error)
+	HandleViewSubmission(ctx context.Context, payload *InteractionPayload) ([]byte, error)
 }
 
-// CommandHandler implements CommandHandlerInterface
+// CommandHandler implements CommandHandlerInterface.
+// Help, timezone, task lists and errors are ephemeral, changes of tasks are posted in the channel. Channels overrides this per channel, refer to HandleVisibilityCommand.
+// Repository is expected to enforce the permission rules of the channel, refer to PolicyRepository. Denied changes are shown only to the user.
+// If Slack is set, changes of a task posted in the channel are posted by the bot, so reactions to them change the task, refer to HandleEvent.
 type CommandHandler struct {
 	Repository mysql.TaskRepositoryInterface
+	Settings   mysql.UserSettingRepositoryInterface
+	Channels   mysql.ChannelSettingRepositoryInterface
+	Slack      slackapi.ClientInterface
+	// Now returns the current time, time.Now if not set
+	Now func() time.Time
 }
 
 // HandleCommand passes the command to the proper command handlers
-func (handler *CommandHandler) HandleCommand(c *slack.SlashCommand) ([]byte, error) {
+func (handler *CommandHandler) HandleCommand(ctx context.Context, c *slack.SlashCommand) ([]byte, error) {
+	if number, ok := modalTaskNumber(c); ok {
+		return handler.OpenTaskModal(ctx, c.TriggerID, c.ChannelID, c.UserID, number)
+	}
 	switch c.Command {
 	case "/tododo-help":
 		return handler.HandleHelpCommand()
 	case "/tododo-add":
-		return handler.HandleAddCommand(c.Text, c.ChannelID)
+		return handler.HandleAddCommand(ctx, c.Text, c.ChannelID, c.UserID)
 	case "/tododo-show":
-		return handler.HandleShowCommand(c.Text, c.ChannelID)
+		return handler.HandleShowCommand(ctx, c.Text, c.ChannelID, c.UserID)
 	case "/tododo-assign":
-		return handler.HandleAssignCommand(c.Text)
+		return handler.HandleAssignCommand(ctx, c.Text, c.ChannelID, c.UserID)
 	case "/tododo-start":
-		return handler.HandleProgressCommand(c.Text)
+		return handler.HandleProgressCommand(ctx, c.Text, c.ChannelID, c.UserID)
 	case "/tododo-done":
-		return handler.HandleDoneCommand(c.Text)
+		return handler.HandleDoneCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-timezone":
+		return handler.HandleTimezoneCommand(ctx, c.Text, c.UserID)
+	case "/tododo-edit":
+		return handler.HandleEditCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-delete":
+		return handler.HandleDeleteCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-restore":
+		return handler.HandleRestoreCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-visibility":
+		return handler.HandleVisibilityCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-history":
+		return handler.HandleHistoryCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-policy":
+		return handler.HandlePolicyCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-admin":
+		return handler.HandleAdminCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-priority":
+		return handler.HandlePriorityCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-tag":
+		return handler.HandleTagCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-labels":
+		return handler.HandleLabelsCommand(ctx, c.ChannelID)
+	case "/tododo-move":
+		return handler.HandleMoveCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-workflow":
+		return handler.HandleWorkflowCommand(ctx, c.Text, c.ChannelID, c.UserID)
+	case "/tododo-mine":
+		return handler.HandleMineCommand(ctx, c.Text, c.ChannelID, c.UserID)
 	}
 	return nil, fmt.Errorf("Can't handle command")
 }
 
+// IsModalCommand reports whether the command opens the task modal - /tododo-add without text and /tododo-edit with only a task ID.
+// Trigger IDs expire 3 seconds after the command, so these commands should be handled before the command is acknowledged.
+func IsModalCommand(c *slack.SlashCommand) bool {
+	_, ok := modalTaskNumber(c)
+	return ok
+}
+
+// modalTaskNumber returns the number of the task the command opens the task modal for, 0 for a new task, and whether the command opens it.
+func modalTaskNumber(c *slack.SlashCommand) (int, bool) {
+	switch c.Command {
+	case "/tododo-add":
+		return 0, strings.TrimSpace(c.Text) == ""
+	case "/tododo-edit":
+		if ValidateStatusText(c.Text) {
+			id, _ := strconv.Atoi(c.Text)
+			return id, true
+		}
+	}
+	return 0, false
+}
+
 // HandleHelpCommand handles /tododo-help and returns proper response or error
 func (handler *CommandHandler) HandleHelpCommand() ([]byte, error) {
 	header := NewHeaderBlock(HelpHeader)
@@ -55,7 +139,21 @@ func (handler *CommandHandler) HandleHelpCommand() ([]byte, error) {
 	block3 := NewSectionTextBlock(MarkdownType, HelpBlock3Text)
 	block4 := NewSectionTextBlock(MarkdownType, HelpBlock4Text)
 	block5 := NewSectionTextBlock(MarkdownType, HelpBlock5Text)
-	resp := NewResponse(header, div, block1, block2, block3, block4, block5)
+	block6 := NewSectionTextBlock(MarkdownType, HelpBlock6Text)
+	block7 := NewSectionTextBlock(MarkdownType, HelpBlock7Text)
+	block8 := NewSectionTextBlock(MarkdownType, HelpBlock8Text)
+	block9 := NewSectionTextBlock(MarkdownType, HelpBlock9Text)
+	block10 := NewSectionTextBlock(MarkdownType, HelpBlock10Text)
+	block11 := NewSectionTextBlock(MarkdownType, HelpBlock11Text)
+	block12 := NewSectionTextBlock(MarkdownType, HelpBlock12Text)
+	block13 := NewSectionTextBlock(MarkdownType, HelpBlock13Text)
+	block14 := NewSectionTextBlock(MarkdownType, HelpBlock14Text)
+	block15 := NewSectionTextBlock(MarkdownType, HelpBlock15Text)
+	block16 := NewSectionTextBlock(MarkdownType, HelpBlock16Text)
+	block17 := NewSectionTextBlock(MarkdownType, HelpBlock17Text)
+	block18 := NewSectionTextBlock(MarkdownType, HelpBlock18Text)
+	block19 := NewSectionTextBlock(MarkdownType, HelpBlock19Text)
+	resp := NewResponse(header, div, block1, block2, block3, block4, block5, block6, block7, block8, block9, block10, block11, block12, block13, block14, block15, block16, block17, block18, block19)
 	byt, err := json.Marshal(resp)
 	if err != nil {
 		return nil, err
@@ -63,40 +161,133 @@ func (handler *CommandHandler) HandleHelpCommand() ([]byte, error) {
 	return byt, nil
 }
 
-// HandleAddCommand handles /tododo-add and returns proper response or error
-func (handler *CommandHandler) HandleAddCommand(text string, channelID string) ([]byte, error) {
-	task := mysql.NewTask(text, channelID)
-	err := handler.Repository.PersistTask(task)
+// HandleAddCommand handles /tododo-add and returns proper response or error.
+// A priority like !p1 is parsed from any word, refer to ParsePriority, and labels like #infra, refer to ParseLabels.
+// A due date after the word "due" is parsed in the timezone of the user, refer to ParseDueDate.
+// The task starts in the first state of the workflow of the channel. Titles which are empty after these words or longer than mysql.MaxTitleLength are rejected.
+func (handler *CommandHandler) HandleAddCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	loc, err := handler.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	workflow, err := handler.channelWorkflow(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	text, priority := ParsePriority(text)
+	text, labels := ParseLabels(text)
+	err = ValidateLabels(labels)
+	if err != nil {
+		return textResponse(AddHeader, PlainTextType, err.Error())
+	}
+	title, due := ParseDueDate(text, handler.now().In(loc))
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return textResponse(AddHeader, PlainTextType, AddBadArgsText)
+	}
+	if utf8.RuneCountInString(title) > mysql.MaxTitleLength {
+		return textResponse(AddHeader, PlainTextType, fmt.Sprintf(TitleTooLongText, mysql.MaxTitleLength))
+	}
+	task := mysql.NewTask(title, channelID, userID)
+	task.Status = workflow.States[0].Name
+	task.Priority = priority
+	task.Labels = labels
+	if due != nil {
+		utc := due.UTC()
+		task.DueDate = &utc
+	}
+	err = handler.Repository.PersistTask(ctx, task)
 	if err != nil {
 		return nil, err
 	}
-	header := NewHeaderBlock(AddHeader)
+	return handler.respondTask(ctx, addResponse(task, loc), channelID, task.Number)
+}
+
+// addResponse constructs the confirmation of an added task, refer to taskResponse.
+func addResponse(task *mysql.Task, loc *time.Location) *Response {
+	return taskResponse(AddHeader, "*Task added*: ", task, loc)
+}
+
+// taskResponse constructs the confirmation of a change of a task with its number and title after prefix,
+// followed by its assignee, priority, labels, due date in loc, description and the message it was added from, if set.
+func taskResponse(headerText string, prefix string, task *mysql.Task, loc *time.Location) *Response {
+	header := NewHeaderBlock(headerText)
 	div := NewDividerBlock()
-	block1 := NewSectionTextBlock(MarkdownType, "*Task added*: "+task.Title)
-	resp := NewResponse(header, div, block1)
-	byt, err := json.Marshal(resp)
+	blocks := []*Block{header, div, NewSectionTextBlock(MarkdownType, prefix+strconv.Itoa(task.Number)+" - "+task.Title)}
+	if task.AsigneeID != "" {
+		blocks = append(blocks, NewSectionTextBlock(MarkdownType, "*Assignee*: "+FormatUserMention(task.AsigneeID)))
+	}
+	if task.Priority != mysql.PriorityNone {
+		blocks = append(blocks, NewSectionTextBlock(MarkdownType, "*Priority*: "+formatPriority(task.Priority)))
+	}
+	if len(task.Labels) > 0 {
+		blocks = append(blocks, NewSectionTextBlock(MarkdownType, "*Labels*: "+formatLabels(task.Labels)))
+	}
+	if task.DueDate != nil {
+		blocks = append(blocks, NewSectionTextBlock(MarkdownType, "*Due*: "+formatDate(*task.DueDate, loc)))
+	}
+	if task.Description != "" {
+		blocks = append(blocks, NewSectionTextBlock(MarkdownType, "*Description*: "+task.Description))
+	}
+	if task.Permalink != "" {
+		blocks = append(blocks, NewSectionTextBlock(MarkdownType, "*Source*: <"+task.Permalink+"|"+SourceLinkText+">"))
+	}
+	return NewResponse(blocks...)
+}
+
+// HandleShowCommand handles /tododo-show and returns proper response or error.
+// Text filters and sorts the tasks, refer to ParseShowFilter. Unfinished tasks ordered by number are shown if text is empty.
+// Statuses are shown with the emoji of the workflow of the channel. Due dates are shown in the timezone of the user, unfinished tasks past their due date are flagged as overdue.
+// Every task shows its labels and who added it and how long ago, e.g. "Added by @x 3d ago".
+// Every task has buttons to start, finish and assign it to the user who clicks, refer to HandleInteraction.
+func (handler *CommandHandler) HandleShowCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	workflow, err := handler.channelWorkflow(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
-	return byt, nil
+	filter, err := ParseShowFilter(text, channelID, userID, handler.now(), workflow)
+	if err != nil {
+		return textResponse(ShowHeader, PlainTextType, err.Error()+". "+ShowBadArgsText)
+	}
+	resp, err := handler.showResponse(ctx, text, filter, userID, workflow)
+	if err != nil {
+		return nil, err
+	}
+	return handler.respond(ctx, resp, channelID, ResponseEphemeral)
 }
 
-// HandleShowCommand handles /tododo-show and returns proper response or error
-func (handler *CommandHandler) HandleShowCommand(text string, channelID string) ([]byte, error) {
-	tasks, err := handler.Repository.GetAllInChannel(channelID)
+// showResponse constructs the task list matching filter as seen by the user in a channel with the workflow. Query is the text filter was parsed from, refer to ParseShowFilter.
+// The list is split in pages of ShowPageSize tasks with buttons to the previous and the next page.
+// Returns error if the list violates the limits of Block Kit, refer to Response.Validate.
+func (handler *CommandHandler) showResponse(ctx context.Context, query string, filter *mysql.TaskFilter, userID string, workflow *mysql.Workflow) (*Response, error) {
+	// one more task is requested to know if there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+	tasks, err := handler.Repository.FindTasks(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
+	hasNext := len(tasks) > pageSize
+	if hasNext {
+		tasks = tasks[:pageSize]
+	}
+	loc, err := handler.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := handler.now()
 	header := NewHeaderBlock(ShowHeader)
 	div := NewDividerBlock()
 	blocks := make([]*Block, 0)
 	for _, t := range tasks {
-		idTitle := NewField(MarkdownType, "*"+strconv.Itoa(t.ID)+"*: "+t.Title)
-		emoji := NewField(MarkdownType, getStatusEmoji(t.Status))
-		assignee := NewField(MarkdownType, t.AsigneeID)
-		status := NewField(MarkdownType, getStatusName(t.Status))
-		block := NewSectionFieldsBlock(idTitle, emoji, assignee, status)
-		blocks = append(blocks, block)
+		blocks = append(blocks, taskBlock(t, workflow, loc, now), taskActionsBlock(t, query, workflow))
+	}
+	if len(tasks) == 0 {
+		blocks = append(blocks, NewSectionTextBlock(PlainTextType, NoTasksText))
+	}
+	page := filter.Offset/pageSize + 1
+	if page > 1 || hasNext {
+		blocks = append(blocks, pageActionsBlock(query, page, hasNext))
 	}
 	args := make([]*Block, 0)
 	args = append(args, header)
@@ -105,15 +296,44 @@ func (handler *CommandHandler) HandleShowCommand(text string, channelID string)
 		args = append(args, b)
 	}
 	resp := NewResponse(args...)
-	byt, err := json.Marshal(resp)
+	err = resp.Validate()
 	if err != nil {
 		return nil, err
 	}
-	return byt, nil
+	return resp, nil
+}
+
+// taskBlock constructs the section showing a task in a list - number, title, state, priority, assignee, labels, due date and when it was added.
+// The due date is shown in loc, with OverdueText if the task is unfinished and its due date is before now.
+func taskBlock(t *mysql.Task, workflow *mysql.Workflow, loc *time.Location, now time.Time) *Block {
+	idTitle := NewField(MarkdownType, "*"+strconv.Itoa(t.Number)+"*: "+t.Title+sourceLink(t))
+	emojis := stateEmoji(workflow, t.Status)
+	if t.Priority != mysql.PriorityNone {
+		emojis += " " + formatPriority(t.Priority)
+	}
+	emoji := NewField(MarkdownType, emojis)
+	assignee := NewField(MarkdownType, FormatUserMention(t.AsigneeID))
+	status := NewField(MarkdownType, getStatusName(t.Status))
+	block := NewSectionFieldsBlock(idTitle, emoji, assignee, status)
+	if len(t.Labels) > 0 {
+		block.BFields = append(block.BFields, NewField(MarkdownType, "*Labels*: "+formatLabels(t.Labels)))
+	}
+	if t.DueDate != nil {
+		due := "*Due*: " + formatDate(*t.DueDate, loc)
+		if !isTerminal(workflow, t.Status) && t.DueDate.Before(now) {
+			due = OverdueText + " " + due
+		}
+		block.BFields = append(block.BFields, NewField(MarkdownType, due))
+	}
+	if t.CreatedAt != nil {
+		block.BFields = append(block.BFields, NewField(MarkdownType, formatAdded(t.CreatorID, *t.CreatedAt, now)))
+	}
+	return block
 }
 
 // HandleAssignCommand handles /tododo-assign and returns proper response or error.
-func (handler *CommandHandler) HandleAssignCommand(text string) ([]byte, error) {
+// The assignee must be a user mention, its user ID is stored, refer to ParseUserMention.
+func (handler *CommandHandler) HandleAssignCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
 	header := NewHeaderBlock(UpdateHeader)
 	div := NewDividerBlock()
 	if !ValidateAssignCommandText(text) {
@@ -127,7 +347,17 @@ func (handler *CommandHandler) HandleAssignCommand(text string) ([]byte, error)
 	}
 	args := strings.Split(text, " ")
 	id, _ := strconv.Atoi(args[0])
-	err := handler.Repository.AssignTaskTo(id, args[1])
+	assigneeID, ok := ParseUserMention(args[1])
+	if !ok {
+		errBlock := NewSectionTextBlock(PlainTextType, NotAUserText)
+		response := NewResponse(header, div, errBlock)
+		byt, err := json.Marshal(response)
+		if err != nil {
+			return nil, err
+		}
+		return byt, nil
+	}
+	err := handler.Repository.AssignTaskTo(ctx, channelID, id, assigneeID, userID)
 	if err == mysql.ErrNoRowOrMoreThanOne {
 		errBlock := NewSectionTextBlock("plain_text", NoSuchTaskIDText)
 		response := NewResponse(header, div, errBlock)
@@ -136,55 +366,149 @@ func (handler *CommandHandler) HandleAssignCommand(text string) ([]byte, error)
 			return nil, err
 		}
 		return byt, nil
+	} else if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
 	} else if err != nil {
 		return nil, err
 	}
-	task, err := handler.Repository.GetTaskByID(id)
+	task, err := handler.Repository.GetTask(ctx, channelID, id)
 	if err != nil {
 		return nil, err
 	}
-	block1 := NewSectionTextBlock("mrkdwn", "Assigned: "+task.Title+" - "+task.AsigneeID)
-	resp := NewResponse(header, div, block1)
-	byt, err := json.Marshal(resp)
+	block1 := NewSectionTextBlock("mrkdwn", "Assigned: "+task.Title+" - "+FormatUserMention(task.AsigneeID))
+	return handler.respondTask(ctx, NewResponse(header, div, block1), channelID, id)
+}
+
+// HandleProgressCommand handles /tododo-start command and returns proper response or error.
+// The task is moved to the start state of the workflow of the channel, refer to startState.
+func (handler *CommandHandler) HandleProgressCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if !ValidateStatusText(text) {
+		return textResponse(UpdateHeader, PlainTextType, ProgressBadArgsText)
+	}
+	id, _ := strconv.Atoi(text)
+	workflow, err := handler.channelWorkflow(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
-	return byt, nil
+	state := startState(workflow)
+	if state == nil {
+		return textResponse(UpdateHeader, PlainTextType, NoStartStateText)
+	}
+	return handler.moveResponse(ctx, workflow, channelID, id, state, userID)
 }
 
-// HandleProgressCommand handles /tododo-start command and returns proper response or error.
-func (handler *CommandHandler) HandleProgressCommand(text string) ([]byte, error) {
-	header := NewHeaderBlock(UpdateHeader)
-	div := NewDividerBlock()
+// HandleDoneCommand handles /tododo-done command and returns proper response or error.
+// The task is moved to the first terminal state of the workflow of the channel, refer to doneState.
+func (handler *CommandHandler) HandleDoneCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
 	if !ValidateStatusText(text) {
-		errBlock := NewSectionTextBlock("plain_text", ProgressBadArgsText)
-		response := NewResponse(header, div, errBlock)
-		byt, err := json.Marshal(response)
-		if err != nil {
-			return nil, err
-		}
-		return byt, nil
+		return textResponse(UpdateHeader, PlainTextType, DoneBadArgsText)
 	}
-	args := strings.Split(text, " ")
+	id, _ := strconv.Atoi(text)
+	workflow, err := handler.channelWorkflow(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	return handler.moveResponse(ctx, workflow, channelID, id, doneState(workflow), userID)
+}
+
+// HandleEditCommand handles /tododo-edit command and returns proper response or error.
+// Titles longer than mysql.MaxTitleLength are rejected.
+func (handler *CommandHandler) HandleEditCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if !ValidateEditCommandText(text) {
+		return textResponse(UpdateHeader, PlainTextType, EditBadArgsText)
+	}
+	args := strings.SplitN(text, " ", 2)
 	id, _ := strconv.Atoi(args[0])
-	err := handler.Repository.SetStatus(id, mysql.StatusInProgress)
+	title := strings.TrimSpace(args[1])
+	if utf8.RuneCountInString(title) > mysql.MaxTitleLength {
+		return textResponse(UpdateHeader, PlainTextType, fmt.Sprintf(TitleTooLongText, mysql.MaxTitleLength))
+	}
+	err := handler.Repository.UpdateTitle(ctx, channelID, id, title, userID)
 	if err == mysql.ErrNoRowOrMoreThanOne {
-		errBlock := NewSectionTextBlock("plain_text", NoSuchTaskIDText)
-		response := NewResponse(header, div, errBlock)
-		byt, err := json.Marshal(response)
+		return textResponse(UpdateHeader, PlainTextType, NoSuchTaskIDText)
+	} else if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	} else if err != nil {
+		return nil, err
+	}
+	return handler.respondTask(ctx, newTextResponse(UpdateHeader, MarkdownType, "*Title*: "+title), channelID, id)
+}
+
+// HandleDeleteCommand handles /tododo-delete command and returns proper response or error.
+// The task is hidden from /tododo-show and can be restored with /tododo-restore.
+func (handler *CommandHandler) HandleDeleteCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if !ValidateStatusText(text) {
+		return textResponse(DeleteHeader, PlainTextType, DeleteBadArgsText)
+	}
+	id, _ := strconv.Atoi(text)
+	err := handler.Repository.DeleteTask(ctx, channelID, id, userID)
+	if err == mysql.ErrNoRowOrMoreThanOne {
+		return textResponse(DeleteHeader, PlainTextType, NoSuchTaskIDText)
+	} else if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	} else if err != nil {
+		return nil, err
+	}
+	task, err := handler.Repository.GetTask(ctx, channelID, id)
+	if err != nil {
+		return nil, err
+	}
+	return handler.respond(ctx, newTextResponse(DeleteHeader, MarkdownType, "*Deleted*: "+task.Title+". Undo with /tododo-restore "+text), channelID, ResponseInChannel)
+}
+
+// HandleRestoreCommand handles /tododo-restore command and returns proper response or error.
+func (handler *CommandHandler) HandleRestoreCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if !ValidateStatusText(text) {
+		return textResponse(RestoreHeader, PlainTextType, RestoreBadArgsText)
+	}
+	id, _ := strconv.Atoi(text)
+	err := handler.Repository.RestoreTask(ctx, channelID, id, userID)
+	if err == mysql.ErrNoRowOrMoreThanOne {
+		return textResponse(RestoreHeader, PlainTextType, NoSuchDeletedTaskIDText)
+	} else if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	} else if err != nil {
+		return nil, err
+	}
+	task, err := handler.Repository.GetTask(ctx, channelID, id)
+	if err != nil {
+		return nil, err
+	}
+	return handler.respond(ctx, newTextResponse(RestoreHeader, MarkdownType, "*Restored*: "+task.Title), channelID, ResponseInChannel)
+}
+
+// HandleVisibilityCommand handles /tododo-visibility. Shows the visibility of the responses in the channel if text is empty, otherwise sets it.
+// default keeps the visibility of each command, private shows all responses only to the user who sent the command, public posts them to the channel.
+// Errors and the responses of /tododo-help and /tododo-timezone stay private. A change of the visibility is posted to the channel.
+// Only task admins may change the visibility, refer to Rules.CheckSettings.
+func (handler *CommandHandler) HandleVisibilityCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if text == "" {
+		visibility, err := handler.channelVisibility(ctx, channelID)
 		if err != nil {
 			return nil, err
 		}
-		return byt, nil
-	} else if err != nil {
+		return textResponse(VisibilityHeader, MarkdownType, "*Visibility*: "+visibility)
+	}
+	if !ValidateVisibilityText(text) {
+		return textResponse(VisibilityHeader, PlainTextType, VisibilityBadArgsText)
+	}
+	if handler.Channels == nil {
+		return nil, fmt.Errorf("Channel settings are not available")
+	}
+	rules, err := LoadRules(ctx, handler.Channels, channelID)
+	if err != nil {
 		return nil, err
 	}
-	task, err := handler.Repository.GetTaskByID(id)
+	err = rules.CheckSettings(userID)
+	if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	}
+	err = handler.Channels.SetVisibility(ctx, channelID, text)
 	if err != nil {
 		return nil, err
 	}
-	block1 := NewSectionTextBlock(MarkdownType, "Status: "+task.Title+" - "+task.Status)
-	resp := NewResponse(header, div, block1)
+	resp := newTextResponse(VisibilityHeader, MarkdownType, "*Visibility set*: "+text)
+	resp.ResponseType = ResponseInChannel
 	byt, err := json.Marshal(resp)
 	if err != nil {
 		return nil, err
@@ -192,38 +516,26 @@ func (handler *CommandHandler) HandleProgressCommand(text string) ([]byte, error
 	return byt, nil
 }
 
-// HandleDoneCommand handles /tododo-done command and returns proper response or error.
-func (handler *CommandHandler) HandleDoneCommand(text string) ([]byte, error) {
-	header := NewHeaderBlock(UpdateHeader)
+// HandleTimezoneCommand handles /tododo-timezone. Shows the timezone of the user if text is empty, otherwise sets it to the IANA timezone name in text.
+func (handler *CommandHandler) HandleTimezoneCommand(ctx context.Context, text string, userID string) ([]byte, error) {
+	header := NewHeaderBlock(TimezoneHeader)
 	div := NewDividerBlock()
-	if !ValidateStatusText(text) {
-		errBlock := NewSectionTextBlock("plain_text", DoneBadArgsText)
-		response := NewResponse(header, div, errBlock)
-		byt, err := json.Marshal(response)
+	var block1 *Block
+	if text == "" {
+		loc, err := handler.userLocation(ctx, userID)
 		if err != nil {
 			return nil, err
 		}
-		return byt, nil
-	}
-	args := strings.Split(text, " ")
-	id, _ := strconv.Atoi(args[0])
-	err := handler.Repository.SetStatus(id, mysql.StatusDone)
-	if err == mysql.ErrNoRowOrMoreThanOne {
-		errBlock := NewSectionTextBlock("plain_text", NoSuchTaskIDText)
-		response := NewResponse(header, div, errBlock)
-		byt, err := json.Marshal(response)
+		block1 = NewSectionTextBlock(MarkdownType, "*Your timezone*: "+loc.String())
+	} else if !ValidateTimezoneText(text) {
+		block1 = NewSectionTextBlock(PlainTextType, TimezoneBadArgsText)
+	} else {
+		err := handler.Settings.SetTimezone(ctx, userID, text)
 		if err != nil {
 			return nil, err
 		}
-		return byt, nil
-	} else if err != nil {
-		return nil, err
-	}
-	task, err := handler.Repository.GetTaskByID(id)
-	if err != nil {
-		return nil, err
+		block1 = NewSectionTextBlock(MarkdownType, "*Timezone set*: "+text)
 	}
-	block1 := NewSectionTextBlock(MarkdownType, "Status: "+task.Title+" - "+task.Status)
 	resp := NewResponse(header, div, block1)
 	byt, err := json.Marshal(resp)
 	if err != nil {
@@ -232,6 +544,38 @@ func (handler *CommandHandler) HandleDoneCommand(text string) ([]byte, error) {
 	return byt, nil
 }
 
+// ValidateTimezoneText validates the arg of /tododo-timezone is exactly 1 - an IANA timezone name like Europe/Sofia or UTC. Local is not accepted. Return true if the text is valid.
+func ValidateTimezoneText(text string) bool {
+	args := strings.Split(text, " ")
+	if len(args) != 1 || args[0] == "" || args[0] == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(args[0])
+	return err == nil
+}
+
+// ValidateVisibilityText validates the arg of /tododo-visibility is exactly 1 - default, private or public. Return true if the text is valid.
+func ValidateVisibilityText(text string) bool {
+	switch text {
+	case mysql.VisibilityDefault, mysql.VisibilityPrivate, mysql.VisibilityPublic:
+		return true
+	}
+	return false
+}
+
+// ValidateEditCommandText validates the args of /tododo-edit are a positive integer followed by a non-empty title. Return true if the text is valid.
+func ValidateEditCommandText(text string) bool {
+	args := strings.SplitN(text, " ", 2)
+	if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+		return false
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 1 {
+		return false
+	}
+	return true
+}
+
 // ValidateAssignCommandText validates the args of /tododo-assign are exactly 2 - positive integer and a string represetation of assignee. Return true if the text is valid.
 func ValidateAssignCommandText(text string) bool {
 	args := strings.Split(text, " ")
@@ -258,19 +602,138 @@ func ValidateStatusText(text string) bool {
 	return true
 }
 
-func getStatusEmoji(status string) string {
-	switch status {
-	case mysql.StatusOpen:
-		return StatusOpenEmoji
-	case mysql.StatusInProgress:
-		return StatusInProgressEmoji
-	case mysql.StatusDone:
-		return StatusDoneEmoji
+// textResponse constructs an ephemeral response with header, divider and one section of text.
+func textResponse(headerText string, textType string, text string) ([]byte, error) {
+	resp := newTextResponse(headerText, textType, text)
+	byt, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return byt, nil
+}
+
+func newTextResponse(headerText string, textType string, text string) *Response {
+	header := NewHeaderBlock(headerText)
+	div := NewDividerBlock()
+	block1 := NewSectionTextBlock(textType, text)
+	return NewResponse(header, div, block1)
+}
+
+// respond sets the response type of resp to responseType, unless the visibility of the channel overrides it, and marshals it.
+func (handler *CommandHandler) respond(ctx context.Context, resp *Response, channelID string, responseType string) ([]byte, error) {
+	visibility, err := handler.channelVisibility(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	switch visibility {
+	case mysql.VisibilityPrivate:
+		responseType = ResponseEphemeral
+	case mysql.VisibilityPublic:
+		responseType = ResponseInChannel
+	}
+	resp.ResponseType = responseType
+	byt, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return byt, nil
+}
+
+// respondTask is respond for a change of the task with this number, posted in the channel unless its visibility is private.
+// If Slack is set, the response is posted by the bot with chat.postMessage instead of response_url and the message is recorded,
+// so reactions to it change the task. Returns nil then, there is nothing left to send. Falls back to response_url if posting fails.
+func (handler *CommandHandler) respondTask(ctx context.Context, resp *Response, channelID string, number int) ([]byte, error) {
+	byt, err := handler.respond(ctx, resp, channelID, ResponseInChannel)
+	if err != nil || handler.Slack == nil || resp.ResponseType != ResponseInChannel {
+		return byt, err
+	}
+	ref, err := handler.Slack.PostMessage(ctx, &slackapi.Message{Channel: channelID, Text: fallbackText(resp), Blocks: resp.Blocks})
+	if err != nil {
+		log.Printf("[ERROR] Can't post message of task %d in %s: %s", number, channelID, err)
+		return byt, nil
+	}
+	err = handler.Repository.AddTaskMessage(ctx, ref.Channel, number, ref.TS)
+	if err != nil {
+		log.Printf("[ERROR] Can't record message of task %d in %s: %s", number, channelID, err)
+	}
+	return nil, nil
+}
+
+// fallbackText returns the texts of the header and sections of resp, shown in notifications.
+func fallbackText(resp *Response) string {
+	texts := make([]string, 0)
+	for _, block := range resp.Blocks {
+		if block.BText != nil && (block.Type == "header" || block.Type == "section") {
+			texts = append(texts, block.BText.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// channelVisibility returns the visibility of the responses set for the channel, mysql.VisibilityDefault if not set.
+func (handler *CommandHandler) channelVisibility(ctx context.Context, channelID string) (string, error) {
+	if handler.Channels == nil {
+		return mysql.VisibilityDefault, nil
+	}
+	return handler.Channels.GetVisibility(ctx, channelID)
+}
+
+// userLocation returns the timezone set by the user, UTC if not set.
+func (handler *CommandHandler) userLocation(ctx context.Context, userID string) (*time.Location, error) {
+	if handler.Settings == nil {
+		return time.UTC, nil
+	}
+	timezone, err := handler.Settings.GetTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+func (handler *CommandHandler) now() time.Time {
+	if handler.Now == nil {
+		return time.Now()
+	}
+	return handler.Now()
+}
+
+// formatDate renders t in the local time of the viewer using Slack date formatting, falls back to loc for old clients.
+func formatDate(t time.Time, loc *time.Location) string {
+	fallback := t.In(loc).Format("Mon Jan 2 15:04 MST")
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", t.Unix(), fallback)
+}
+
+// formatAdded renders who added a task and how long before now, the creator is left out for tasks added before it was recorded.
+func formatAdded(creatorID string, created time.Time, now time.Time) string {
+	if creatorID == "" {
+		return AddedText + " " + formatAge(created, now)
+	}
+	return AddedByText + " " + FormatUserMention(creatorID) + " " + formatAge(created, now)
+}
+
+// formatAge renders the time passed from t to now in the largest whole unit, e.g. "5m ago", "3h ago", "3d ago".
+func formatAge(t time.Time, now time.Time) string {
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return JustNowText
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
 	default:
-		return ""
+		return fmt.Sprintf("%dd ago", int(age/(24*time.Hour)))
 	}
 }
 
+// getStatusName returns the name of the status shown to users, the status itself for the states of custom workflows.
 func getStatusName(status string) string {
 	switch status {
 	case mysql.StatusOpen:
@@ -280,6 +743,6 @@ func getStatusName(status string) string {
 	case mysql.StatusDone:
 		return StatusDoneText
 	default:
-		return ""
+		return status
 	}
 }
@@ -1,42 +1,325 @@
 package tododo
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
 	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
-type MockRepo struct{}
+var ctx = context.Background()
 
-func (repo *MockRepo) PersistTask(t *mysql.Task) error {
+var mockNow = time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+// mockPagedTasks is the number of tasks in channel CH4
+const mockPagedTasks = 45
+
+type MockRepo struct {
+	persisted *mysql.Task
+	filter    *mysql.TaskFilter
+	actor     string
+	status    string
+	terminal  bool
+	messages  map[string]int
+	changes   []string
+}
+
+func (repo *MockRepo) PersistTask(ctx context.Context, t *mysql.Task) error {
+	repo.persisted = t
 	return nil
 }
 
-func (repo *MockRepo) GetTaskByID(ID int) (*mysql.Task, error) {
-	return &mysql.Task{ID: 1, Status: mysql.StatusOpen, Title: "MockTitle", AsigneeID: "U1", ChannelID: "CH1"}, nil
+func (repo *MockRepo) GetTask(ctx context.Context, channelID string, number int) (*mysql.Task, error) {
+	if number != 1 {
+		return nil, sql.ErrNoRows
+	}
+	return &mysql.Task{ID: 1, Number: 1, Status: mysql.StatusOpen, Title: "MockTitle", AsigneeID: "U1ABC", ChannelID: "CH1", CreatorID: "U0AAA"}, nil
 }
 
-func (repo *MockRepo) GetAllInChannel(channelID string) ([]*mysql.Task, error) {
-	tasks := []*mysql.Task{&mysql.Task{ID: 1, Status: mysql.StatusOpen, Title: "MockTitle", AsigneeID: "U1", ChannelID: "CH1"}}
+func (repo *MockRepo) GetTaskEvents(ctx context.Context, channelID string, number int) ([]*mysql.TaskEvent, error) {
+	created := mockNow.Add(-48 * time.Hour)
+	if channelID == "CH3" {
+		return []*mysql.TaskEvent{}, nil
+	}
+	if channelID == "CH4" {
+		events := make([]*mysql.TaskEvent, 0)
+		for i := 1; i <= HistorySize+5; i++ {
+			events = append(events, &mysql.TaskEvent{ID: i, TaskID: 1, ActorID: "U0AAA", CreatedAt: created, Field: mysql.FieldTitle, OldValue: "Title" + strconv.Itoa(i-1), NewValue: "Title" + strconv.Itoa(i)})
+		}
+		return events, nil
+	}
+	return []*mysql.TaskEvent{
+		&mysql.TaskEvent{ID: 1, TaskID: 1, ActorID: "U0AAA", CreatedAt: created, Field: mysql.FieldCreated, NewValue: "MockTitle"},
+		&mysql.TaskEvent{ID: 2, TaskID: 1, ActorID: "U0AAA", CreatedAt: created.Add(time.Hour), Field: mysql.FieldAssignee, NewValue: "U1ABC"},
+		&mysql.TaskEvent{ID: 3, TaskID: 1, ActorID: "U1ABC", CreatedAt: created.Add(2 * time.Hour), Field: mysql.FieldStatus, OldValue: mysql.StatusOpen, NewValue: mysql.StatusDone},
+		&mysql.TaskEvent{ID: 4, TaskID: 1, ActorID: "U0BBB", CreatedAt: created.Add(3 * time.Hour), Field: mysql.FieldDeleted, OldValue: "0", NewValue: "1"},
+	}, nil
+}
+
+func (repo *MockRepo) GetAllInChannel(ctx context.Context, channelID string) ([]*mysql.Task, error) {
+	overdue := mockNow.Add(-time.Hour)
+	created := mockNow.Add(-75 * time.Hour)
+	tasks := []*mysql.Task{&mysql.Task{ID: 1, Number: 1, Status: mysql.StatusOpen, Title: "MockTitle", AsigneeID: "U1ABC", ChannelID: "CH1", CreatorID: "U0AAA", CreatedAt: &created, Labels: []string{"infra", "q4"}}}
+	if channelID == "CH2" {
+		legacy := mockNow.Add(-5 * time.Hour)
+		tasks = append(tasks, &mysql.Task{ID: 2, Number: 2, Status: mysql.StatusOpen, Title: "MockOverdue", AsigneeID: "U1", ChannelID: "CH2", Priority: mysql.PriorityP1, DueDate: &overdue, CreatedAt: &legacy})
+	}
 	return tasks, nil
 }
 
-func (repo *MockRepo) AssignTaskTo(taskID int, assigneeID string) error {
-	if taskID != 1 {
+func (repo *MockRepo) GetAllAssignedTo(ctx context.Context, assigneeID string) ([]*mysql.Task, error) {
+	if assigneeID == "U9MANY" {
+		tasks := make([]*mysql.Task, 0)
+		for i := 1; i <= mockPagedTasks; i++ {
+			tasks = append(tasks, &mysql.Task{ID: i, Number: i, Status: mysql.StatusOpen, Title: "MockPaged" + strconv.Itoa(i), AsigneeID: assigneeID, ChannelID: "CH" + strconv.Itoa(i/10)})
+		}
+		return tasks, nil
+	}
+	if assigneeID != "U1ABC" {
+		return []*mysql.Task{}, nil
+	}
+	due := mockNow.Add(-time.Hour)
+	return []*mysql.Task{
+		&mysql.Task{ID: 1, Number: 1, Status: mysql.StatusOpen, Title: "MockTitle", AsigneeID: "U1ABC", ChannelID: "CH1"},
+		&mysql.Task{ID: 3, Number: 3, Status: mysql.StatusDone, Title: "MockDone", AsigneeID: "U1ABC", ChannelID: "CH1"},
+		&mysql.Task{ID: 4, Number: 4, Status: mysql.StatusInProgress, Title: "MockStarted", AsigneeID: "U1ABC", ChannelID: "CH1", DueDate: &due},
+		&mysql.Task{ID: 2, Number: 2, Status: mysql.StatusOpen, Title: "MockOtherChannel", AsigneeID: "U1ABC", ChannelID: "CH2", Priority: mysql.PriorityP1},
+	}, nil
+}
+
+func (repo *MockRepo) FindTasks(ctx context.Context, filter *mysql.TaskFilter) ([]*mysql.Task, error) {
+	repo.filter = filter
+	if filter.ChannelID == "CH3" {
+		return []*mysql.Task{}, nil
+	}
+	if filter.ChannelID == "CH5" {
+		title := strings.Repeat("Very long title ", 200)
+		return []*mysql.Task{&mysql.Task{ID: 1, Number: 1, Status: mysql.StatusOpen, Title: title, AsigneeID: "U1", ChannelID: "CH5"}}, nil
+	}
+	if filter.ChannelID == "CH4" {
+		tasks := make([]*mysql.Task, 0)
+		for i := filter.Offset + 1; i <= mockPagedTasks && len(tasks) < filter.Limit; i++ {
+			tasks = append(tasks, &mysql.Task{ID: i, Number: i, Status: mysql.StatusOpen, Title: "MockPaged" + strconv.Itoa(i), ChannelID: "CH4"})
+		}
+		return tasks, nil
+	}
+	return repo.GetAllInChannel(ctx, filter.ChannelID)
+}
+
+func (repo *MockRepo) SetPriority(ctx context.Context, channelID string, number int, priority int, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	repo.changes = append(repo.changes, mysql.FieldPriority)
+	return nil
+}
+
+func (repo *MockRepo) UpdateLabels(ctx context.Context, channelID string, number int, added []string, removed []string, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	return nil
+}
+
+func (repo *MockRepo) GetLabelCounts(ctx context.Context, channelID string) ([]*mysql.LabelCount, error) {
+	if channelID != "CH1" {
+		return []*mysql.LabelCount{}, nil
+	}
+	return []*mysql.LabelCount{{Label: "infra", Count: 2}, {Label: "q4", Count: 1}}, nil
+}
+
+func (repo *MockRepo) AssignTaskTo(ctx context.Context, channelID string, number int, assigneeID string, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	repo.changes = append(repo.changes, mysql.FieldAssignee)
+	return nil
+}
+
+func (repo *MockRepo) UpdateTitle(ctx context.Context, channelID string, number int, title string, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	repo.changes = append(repo.changes, mysql.FieldTitle)
+	return nil
+}
+
+func (repo *MockRepo) UpdateDescription(ctx context.Context, channelID string, number int, description string, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	repo.changes = append(repo.changes, mysql.FieldDescription)
+	return nil
+}
+
+func (repo *MockRepo) SetDueDate(ctx context.Context, channelID string, number int, due *time.Time, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	repo.changes = append(repo.changes, mysql.FieldDue)
+	return nil
+}
+
+func (repo *MockRepo) UpdateTask(ctx context.Context, channelID string, number int, update *mysql.TaskUpdate, actorID string) error {
+	repo.actor = actorID
+	task, err := repo.GetTask(ctx, channelID, number)
+	if channelID != "CH1" || err != nil {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	for _, c := range update.Changes(task) {
+		repo.changes = append(repo.changes, c.Field)
+	}
+	return nil
+}
+
+func (repo *MockRepo) DeleteTask(ctx context.Context, channelID string, number int, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
+		return mysql.ErrNoRowOrMoreThanOne
+	}
+	return nil
+}
+
+func (repo *MockRepo) RestoreTask(ctx context.Context, channelID string, number int, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
 		return mysql.ErrNoRowOrMoreThanOne
 	}
 	return nil
 }
 
-func (repo *MockRepo) SetStatus(taskID int, status string) error {
-	if taskID != 1 {
+func (repo *MockRepo) SetStatus(ctx context.Context, channelID string, number int, status string, terminal bool, actorID string) error {
+	repo.actor = actorID
+	if channelID != "CH1" || number != 1 {
 		return mysql.ErrNoRowOrMoreThanOne
 	}
+	repo.status = status
+	repo.terminal = terminal
+	return nil
+}
+
+func (repo *MockRepo) AddTaskMessage(ctx context.Context, channelID string, number int, ts string) error {
+	if repo.messages == nil {
+		repo.messages = map[string]int{}
+	}
+	repo.messages[channelID+" "+ts] = number
+	return nil
+}
+
+func (repo *MockRepo) GetTaskByMessage(ctx context.Context, channelID string, ts string) (*mysql.Task, error) {
+	number, ok := repo.messages[channelID+" "+ts]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return repo.GetTask(ctx, channelID, number)
+}
+
+type MockSettings struct {
+	timezones map[string]string
+}
+
+func (settings *MockSettings) GetTimezone(ctx context.Context, userID string) (string, error) {
+	return settings.timezones[userID], nil
+}
+
+func (settings *MockSettings) SetTimezone(ctx context.Context, userID string, timezone string) error {
+	settings.timezones[userID] = timezone
+	return nil
+}
+
+type MockChannels struct {
+	visibilities map[string]string
+	policies     map[string]string
+	admins       map[string][]string
+	workflows    map[string]*mysql.Workflow
+}
+
+func (channels *MockChannels) GetVisibility(ctx context.Context, channelID string) (string, error) {
+	visibility, ok := channels.visibilities[channelID]
+	if !ok {
+		return mysql.VisibilityDefault, nil
+	}
+	return visibility, nil
+}
+
+func (channels *MockChannels) SetVisibility(ctx context.Context, channelID string, visibility string) error {
+	channels.visibilities[channelID] = visibility
+	return nil
+}
+
+func (channels *MockChannels) GetPolicy(ctx context.Context, channelID string) (string, error) {
+	policy, ok := channels.policies[channelID]
+	if !ok {
+		return mysql.PolicyOpen, nil
+	}
+	return policy, nil
+}
+
+func (channels *MockChannels) SetPolicy(ctx context.Context, channelID string, policy string) error {
+	channels.policies[channelID] = policy
+	return nil
+}
+
+func (channels *MockChannels) GetAdmins(ctx context.Context, channelID string) ([]string, error) {
+	return append([]string{}, channels.admins[channelID]...), nil
+}
+
+func (channels *MockChannels) AddAdmin(ctx context.Context, channelID string, userID string) error {
+	channels.admins[channelID] = append(channels.admins[channelID], userID)
+	return nil
+}
+
+func (channels *MockChannels) RemoveAdmin(ctx context.Context, channelID string, userID string) error {
+	admins := make([]string, 0)
+	for _, admin := range channels.admins[channelID] {
+		if admin != userID {
+			admins = append(admins, admin)
+		}
+	}
+	channels.admins[channelID] = admins
+	return nil
+}
+
+func (channels *MockChannels) GetWorkflow(ctx context.Context, channelID string) (*mysql.Workflow, error) {
+	return channels.workflows[channelID], nil
+}
+
+func (channels *MockChannels) SetWorkflow(ctx context.Context, channelID string, workflow *mysql.Workflow) error {
+	if workflow == nil {
+		delete(channels.workflows, channelID)
+		return nil
+	}
+	channels.workflows[channelID] = workflow
 	return nil
 }
 
+func newMockChannels() *MockChannels {
+	return &MockChannels{visibilities: map[string]string{}, policies: map[string]string{}, admins: map[string][]string{}, workflows: map[string]*mysql.Workflow{}}
+}
+
+func newMockHandler() *CommandHandler {
+	return &CommandHandler{
+		Repository: &MockRepo{},
+		Settings:   &MockSettings{timezones: map[string]string{"U2": "America/New_York"}},
+		Channels:   newMockChannels(),
+		Now:        func() time.Time { return mockNow },
+	}
+}
+
 func TestHandleHelpCommand(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
+	mockHandler := newMockHandler()
 	result, err := mockHandler.HandleHelpCommand()
 	stringRes := string(result)
 	assert.NoError(t, err)
@@ -46,11 +329,26 @@ func TestHandleHelpCommand(t *testing.T) {
 	assert.Contains(t, stringRes, HelpBlock3Text)
 	assert.Contains(t, stringRes, HelpBlock4Text)
 	assert.Contains(t, stringRes, HelpBlock5Text)
+	assert.Contains(t, stringRes, HelpBlock6Text)
+	assert.Contains(t, stringRes, HelpBlock7Text)
+	assert.Contains(t, stringRes, HelpBlock8Text)
+	assert.Contains(t, stringRes, HelpBlock9Text)
+	assert.Contains(t, stringRes, HelpBlock10Text)
+	assert.Contains(t, stringRes, HelpBlock11Text)
+	assert.Contains(t, stringRes, HelpBlock12Text)
+	assert.Contains(t, stringRes, HelpBlock13Text)
+	assert.Contains(t, stringRes, HelpBlock14Text)
+	assert.Contains(t, stringRes, HelpBlock15Text)
+	assert.Contains(t, stringRes, HelpBlock16Text)
+	assert.Contains(t, stringRes, HelpBlock17Text)
+	assert.Contains(t, stringRes, HelpBlock18Text)
+	assert.Contains(t, stringRes, HelpBlock19Text)
+	assert.Contains(t, stringRes, `"response_type":"ephemeral"`)
 }
 
 func TestHandleAddCommand(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleAddCommand("MockTitle", "CH1")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleAddCommand(ctx, "MockTitle", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, AddHeader)
@@ -58,44 +356,227 @@ func TestHandleAddCommand(t *testing.T) {
 	assert.Contains(t, stringRes, "MockTitle")
 }
 
+func TestHandleAddCommandLongTitle(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleAddCommand(ctx, strings.Repeat("a", mysql.MaxTitleLength+1)+" due tomorrow", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), fmt.Sprintf(TitleTooLongText, mysql.MaxTitleLength))
+	assert.Nil(t, mockHandler.Repository.(*MockRepo).persisted)
+}
+
+func TestHandleAddCommandNoTitle(t *testing.T) {
+	mockHandler := newMockHandler()
+	for _, text := range []string{"!p1 #infra", " !p2 ", "#q4"} {
+		result, err := mockHandler.HandleAddCommand(ctx, text, "CH1", "U1")
+		assert.NoError(t, err)
+		assert.Contains(t, string(result), AddBadArgsText, text)
+	}
+	assert.Nil(t, mockHandler.Repository.(*MockRepo).persisted)
+}
+
 func TestHandleShowCommand(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleShowCommand("", "CH1")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleShowCommand(ctx, "", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, ShowHeader)
 	assert.Contains(t, stringRes, "MockTitle")
+	assert.Contains(t, stringRes, `\u003c@U1ABC\u003e`)
+	assert.Contains(t, stringRes, `Added by \u003c@U0AAA\u003e 3d ago`)
+	assert.Contains(t, stringRes, "*Labels*: #infra #q4")
+	assert.Contains(t, stringRes, ActionStart)
+	assert.Contains(t, stringRes, ActionDone)
+	assert.Contains(t, stringRes, ActionAssignMe)
+}
+
+func TestHandleShowCommandFilter(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleShowCommand(ctx, "done last 7d sort:due", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, "MockTitle")
+	assert.Contains(t, stringRes, `"value":"1 done last 7d sort:due"`)
+	filter := mockHandler.Repository.(*MockRepo).filter
+	assert.Equal(t, []string{mysql.StatusDone}, filter.Statuses)
+	assert.Equal(t, mockNow.Add(-7*24*time.Hour), *filter.CompletedSince)
+	assert.Equal(t, mysql.SortDue, filter.Sort)
+}
+
+func TestHandleShowCommandBadFilter(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleShowCommand(ctx, "urgent", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, "Unknown filter urgent")
+	assert.Contains(t, stringRes, ShowBadArgsText)
+	assert.Nil(t, mockHandler.Repository.(*MockRepo).filter)
+}
+
+func TestHandleShowCommandNoTasks(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleShowCommand(ctx, "", "CH3", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoTasksText)
+}
+
+func TestHandleShowCommandPages(t *testing.T) {
+	mockHandler := newMockHandler()
+	tests := []struct {
+		text     string
+		first    int
+		last     int
+		previous string
+		next     string
+	}{
+		{"", 1, 20, "", "page 2"},
+		{"page 2", 21, 40, "page 1", "page 3"},
+		{"page 3", 41, 45, "page 2", ""},
+	}
+	for _, tc := range tests {
+		result, err := mockHandler.HandleShowCommand(ctx, tc.text, "CH4", "U1")
+		if !assert.NoError(t, err) {
+			continue
+		}
+		var resp Response
+		assert.NoError(t, json.Unmarshal(result, &resp))
+		assert.True(t, len(resp.Blocks) <= 50, tc.text)
+		stringRes := string(result)
+		assert.Contains(t, stringRes, "MockPaged"+strconv.Itoa(tc.first)+`"`, tc.text)
+		assert.Contains(t, stringRes, "MockPaged"+strconv.Itoa(tc.last), tc.text)
+		assert.NotContains(t, stringRes, "MockPaged"+strconv.Itoa(tc.first-1)+`"`, tc.text)
+		assert.NotContains(t, stringRes, "MockPaged"+strconv.Itoa(tc.last+1), tc.text)
+		pages := resp.Blocks[len(resp.Blocks)-1]
+		buttons := make(map[string]string)
+		for _, button := range pages.Elements {
+			buttons[button.ActionID] = button.Value
+		}
+		assert.Equal(t, tc.previous, buttons[ActionPreviousPage], tc.text)
+		assert.Equal(t, tc.next, buttons[ActionNextPage], tc.text)
+	}
+}
+
+func TestHandleShowCommandLongTitle(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleShowCommand(ctx, "", "CH5", "U1")
+	if assert.NoError(t, err) {
+		var resp Response
+		assert.NoError(t, json.Unmarshal(result, &resp))
+		assert.NoError(t, resp.Validate())
+		assert.Contains(t, resp.Blocks[2].BFields[0].Text, "*1*: Very long title")
+		assert.True(t, strings.HasSuffix(resp.Blocks[2].BFields[0].Text, "…"))
+	}
+}
+
+func TestHandleShowCommandOnePage(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleShowCommand(ctx, "", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.NotContains(t, stringRes, ActionPreviousPage)
+	assert.NotContains(t, stringRes, ActionNextPage)
+}
+
+func TestHandleAddCommandDueDate(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleAddCommand(ctx, "Ship release notes due friday 5pm", "CH1", "U2")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, "Ship release notes")
+	assert.NotContains(t, stringRes, "friday")
+	assert.Contains(t, stringRes, "Fri Oct 16 17:00 EDT")
+	persisted := mockHandler.Repository.(*MockRepo).persisted
+	assert.Equal(t, "Ship release notes", persisted.Title)
+	assert.Equal(t, time.Date(2026, 10, 16, 21, 0, 0, 0, time.UTC), *persisted.DueDate)
+}
+
+func TestHandleShowCommandOverdue(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleShowCommand(ctx, "", "CH2", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, "MockOverdue")
+	assert.Contains(t, stringRes, OverdueText)
+	assert.Contains(t, stringRes, "Wed Oct 14 09:00 UTC")
+	assert.Contains(t, stringRes, "Added 5h ago")
+	assert.Contains(t, stringRes, StatusOpenEmoji+" "+PriorityP1Emoji+" P1")
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age      time.Duration
+		expected string
+	}{
+		{30 * time.Second, JustNowText},
+		{5 * time.Minute, "5m ago"},
+		{90 * time.Minute, "1h ago"},
+		{23 * time.Hour, "23h ago"},
+		{75 * time.Hour, "3d ago"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, formatAge(mockNow.Add(-tc.age), mockNow))
+	}
+}
+
+func TestHandleTimezoneCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleTimezoneCommand(ctx, "Europe/Sofia", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, TimezoneHeader)
+	assert.Contains(t, stringRes, "Europe/Sofia")
+	result, err = mockHandler.HandleTimezoneCommand(ctx, "", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "*Your timezone*: Europe/Sofia")
+}
+
+func TestHandleTimezoneCommandBadArgs(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleTimezoneCommand(ctx, "Mars/Olympus", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, TimezoneBadArgsText)
 }
 
 func TestHandleAssignCommand(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleAssignCommand("1 U1")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleAssignCommand(ctx, "1 <@U1ABC|bob>", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, UpdateHeader)
 	assert.Contains(t, stringRes, "Assigned:")
 	assert.Contains(t, stringRes, "MockTitle")
+	assert.Contains(t, stringRes, `\u003c@U1ABC\u003e`)
 }
 
 func TestHandleAssingCommandBadArgs(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleAssignCommand("1")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleAssignCommand(ctx, "1", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, AssignBadArgsText)
 }
 
+func TestHandleAssingCommandNotAUser(t *testing.T) {
+	mockHandler := newMockHandler()
+	for _, text := range []string{"1 @bob", "1 bob", "1 <#C1ABC|general>"} {
+		result, err := mockHandler.HandleAssignCommand(ctx, text, "CH1", "U1")
+		stringRes := string(result)
+		assert.NoError(t, err)
+		assert.Contains(t, stringRes, NotAUserText)
+	}
+}
+
 func TestHandleAssingCommandNoSuchTask(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleAssignCommand("2 U1")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleAssignCommand(ctx, "2 <@U1ABC|bob>", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, NoSuchTaskIDText)
 }
 
 func TestHandleProgressCommand(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleProgressCommand("1")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleProgressCommand(ctx, "1", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, UpdateHeader)
@@ -103,24 +584,24 @@ func TestHandleProgressCommand(t *testing.T) {
 }
 
 func TestHandleProgressCommandBadArgs(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleProgressCommand("1 one go")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleProgressCommand(ctx, "1 one go", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, ProgressBadArgsText)
 }
 
 func TestHandleProgressCommandNoSuchTask(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleProgressCommand("2")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleProgressCommand(ctx, "2", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, NoSuchTaskIDText)
 }
 
 func TestHandleDoneCommand(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleDoneCommand("1")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleDoneCommand(ctx, "1", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, UpdateHeader)
@@ -128,21 +609,119 @@ func TestHandleDoneCommand(t *testing.T) {
 }
 
 func TestHandleDoneCommandBadArgs(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleDoneCommand("wawa")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleDoneCommand(ctx, "wawa", "CH1", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, DoneBadArgsText)
 }
 
 func TestHandleDoneCommandNoSuchTask(t *testing.T) {
-	mockHandler := &CommandHandler{&MockRepo{}}
-	result, err := mockHandler.HandleDoneCommand("2")
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleDoneCommand(ctx, "2", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, NoSuchTaskIDText)
+}
+
+func TestHandleDoneCommandOtherChannel(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleDoneCommand(ctx, "1", "CH2", "U1")
 	stringRes := string(result)
 	assert.NoError(t, err)
 	assert.Contains(t, stringRes, NoSuchTaskIDText)
 }
 
+func TestHandleEditCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleEditCommand(ctx, "1 Fix  the typo", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, UpdateHeader)
+	assert.Contains(t, stringRes, "Fix  the typo")
+}
+
+func TestHandleEditCommandBadArgs(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleEditCommand(ctx, "1", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), EditBadArgsText)
+}
+
+func TestHandleEditCommandLongTitle(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleEditCommand(ctx, "1 "+strings.Repeat("a", mysql.MaxTitleLength+16), "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), fmt.Sprintf(TitleTooLongText, mysql.MaxTitleLength))
+	assert.Empty(t, mockHandler.Repository.(*MockRepo).changes)
+}
+
+func TestHandleEditCommandNoSuchTask(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleEditCommand(ctx, "2 title", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoSuchTaskIDText)
+}
+
+func TestHandleDeleteCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleDeleteCommand(ctx, "1", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, DeleteHeader)
+	assert.Contains(t, stringRes, "MockTitle")
+	assert.Contains(t, stringRes, "/tododo-restore 1")
+}
+
+func TestHandleDeleteCommandBadArgs(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleDeleteCommand(ctx, "one", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), DeleteBadArgsText)
+}
+
+func TestHandleDeleteCommandNoSuchTask(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleDeleteCommand(ctx, "2", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoSuchTaskIDText)
+}
+
+func TestHandleRestoreCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleRestoreCommand(ctx, "1", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, RestoreHeader)
+	assert.Contains(t, stringRes, "MockTitle")
+}
+
+func TestHandleRestoreCommandBadArgs(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleRestoreCommand(ctx, "1 2", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), RestoreBadArgsText)
+}
+
+func TestHandleRestoreCommandNoSuchTask(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleRestoreCommand(ctx, "2", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoSuchDeletedTaskIDText)
+}
+
+func TestValidateEditCommandTextValid(t *testing.T) {
+	assert.True(t, ValidateEditCommandText("1 new title"))
+	assert.True(t, ValidateEditCommandText("1 title"))
+}
+
+func TestValidateEditCommandTextNotValid(t *testing.T) {
+	assert.False(t, ValidateEditCommandText("1"))
+	assert.False(t, ValidateEditCommandText("1 "))
+	assert.False(t, ValidateEditCommandText("0 title"))
+	assert.False(t, ValidateEditCommandText("title 1"))
+}
+
 func TestValidateAssignCommandTextValid(t *testing.T) {
 	isValid := ValidateAssignCommandText("1 <@u1|hb>")
 	assert.True(t, isValid)
@@ -170,3 +749,97 @@ func TestValidateStatusTextNotValid(t *testing.T) {
 	assert.False(t, isValid2)
 	assert.False(t, isValid3)
 }
+
+func TestValidateTimezoneTextValid(t *testing.T) {
+	assert.True(t, ValidateTimezoneText("Europe/Sofia"))
+	assert.True(t, ValidateTimezoneText("UTC"))
+}
+
+func TestValidateTimezoneTextNotValid(t *testing.T) {
+	assert.False(t, ValidateTimezoneText("Local"))
+	assert.False(t, ValidateTimezoneText("Europe/Sofia UTC"))
+	assert.False(t, ValidateTimezoneText("Nowhere"))
+}
+
+func TestResponseTypeDefaults(t *testing.T) {
+	mockHandler := newMockHandler()
+	added, err := mockHandler.HandleAddCommand(ctx, "MockTitle", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(added), `"response_type":"in_channel"`)
+	done, err := mockHandler.HandleDoneCommand(ctx, "1", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(done), `"response_type":"in_channel"`)
+	shown, err := mockHandler.HandleShowCommand(ctx, "", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(shown), `"response_type":"ephemeral"`)
+	badArgs, err := mockHandler.HandleAssignCommand(ctx, "1", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(badArgs), `"response_type":"ephemeral"`)
+	noSuchTask, err := mockHandler.HandleDoneCommand(ctx, "5", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(noSuchTask), `"response_type":"ephemeral"`)
+}
+
+func TestResponseTypeChannelOverride(t *testing.T) {
+	mockHandler := newMockHandler()
+	mockHandler.Channels.SetVisibility(ctx, "CH1", mysql.VisibilityPrivate)
+	mockHandler.Channels.SetVisibility(ctx, "CH2", mysql.VisibilityPublic)
+	added, err := mockHandler.HandleAddCommand(ctx, "MockTitle", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(added), `"response_type":"ephemeral"`)
+	shown, err := mockHandler.HandleShowCommand(ctx, "", "CH2", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(shown), `"response_type":"in_channel"`)
+	badFilter, err := mockHandler.HandleShowCommand(ctx, "urgent", "CH2", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(badFilter), `"response_type":"ephemeral"`)
+	timezone, err := mockHandler.HandleTimezoneCommand(ctx, "", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(timezone), `"response_type":"ephemeral"`)
+}
+
+func TestHandleVisibilityCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleVisibilityCommand(ctx, "", "CH1", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "*Visibility*: default")
+	result, err = mockHandler.HandleVisibilityCommand(ctx, "public", "CH1", "U1ABC")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, VisibilityHeader)
+	assert.Contains(t, stringRes, "*Visibility set*: public")
+	assert.Contains(t, stringRes, `"response_type":"in_channel"`)
+	visibility, _ := mockHandler.Channels.GetVisibility(ctx, "CH1")
+	assert.Equal(t, mysql.VisibilityPublic, visibility)
+}
+
+func TestHandleVisibilityCommandBadArgs(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleVisibilityCommand(ctx, "everyone", "CH1", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), VisibilityBadArgsText)
+	visibility, _ := mockHandler.Channels.GetVisibility(ctx, "CH1")
+	assert.Equal(t, mysql.VisibilityDefault, visibility)
+}
+
+func TestHandleVisibilityCommandDenied(t *testing.T) {
+	mockHandler, _ := newPolicyHandler(mysql.PolicyOpen, "U0ADM")
+	result, err := mockHandler.HandleVisibilityCommand(ctx, "public", "CH1", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), PermissionDeniedHeader)
+	assert.Contains(t, string(result), DeniedSettingsText)
+	visibility, _ := mockHandler.Channels.GetVisibility(ctx, "CH1")
+	assert.Equal(t, mysql.VisibilityDefault, visibility)
+	_, err = mockHandler.HandleVisibilityCommand(ctx, "public", "CH1", "U0ADM")
+	assert.NoError(t, err)
+	visibility, _ = mockHandler.Channels.GetVisibility(ctx, "CH1")
+	assert.Equal(t, mysql.VisibilityPublic, visibility)
+}
+
+func TestValidateVisibilityText(t *testing.T) {
+	assert.True(t, ValidateVisibilityText("default"))
+	assert.True(t, ValidateVisibilityText("private"))
+	assert.True(t, ValidateVisibilityText("public"))
+	assert.False(t, ValidateVisibilityText("Public"))
+	assert.False(t, ValidateVisibilityText("public private"))
+}
@@ -2,26 +2,151 @@ package tododo
 
 // Constant to display proper messages as response to slack slash commands of tododo bot
 const (
-	MarkdownType          = "mrkdwn"
-	PlainTextType         = "plain_text"
-	DividerType           = "divider"
-	HelpHeader            = "Welcome! ToDo do can:"
-	ShowHeader            = "ToDo"
-	AddHeader             = "ToDo: Add task"
-	UpdateHeader          = "ToDo: Task updated"
-	AssignBadArgsText     = "Bad arguments. Please enter /tododo-assign [task ID] [@user]"
-	NoSuchTaskIDText      = "Bad arguments. No task with this ID"
-	ProgressBadArgsText   = "Bad arguments. Please enter /tododo-start [task ID]"
-	DoneBadArgsText       = "Bad arguments. Please enter /tododo-done [task ID]"
-	HelpBlock1Text        = "*/tododo-add [task]*: add a task to your ToDo list"
-	HelpBlock2Text        = "*/tododo-show*: show the tasks in your ToDo list"
-	HelpBlock3Text        = "*/tododo-assign [taskId] [@user]*: assign a task to a user"
-	HelpBlock4Text        = "*/tododo-start [taskId]*: start progress on a task"
-	HelpBlock5Text        = "*/tododo-done [taskId]*: finish a task"
-	StatusOpenEmoji       = ":question:"
-	StatusInProgressEmoji = ":hourglass_flowing_sand:"
-	StatusDoneEmoji       = ":white_check_mark:"
-	StatusOpenText        = "Open"
-	StatusInProgressText  = "In progress"
-	StatusDoneText        = "Done"
+	MarkdownType            = "mrkdwn"
+	PlainTextType           = "plain_text"
+	DividerType             = "divider"
+	HelpHeader              = "Welcome! ToDo do can:"
+	ShowHeader              = "ToDo"
+	AddHeader               = "ToDo: Add task"
+	UpdateHeader            = "ToDo: Task updated"
+	TimezoneHeader          = "ToDo: Timezone"
+	DeleteHeader            = "ToDo: Task deleted"
+	RestoreHeader           = "ToDo: Task restored"
+	VisibilityHeader        = "ToDo: Visibility"
+	HistoryHeader           = "ToDo: History"
+	PolicyHeader            = "ToDo: Permissions"
+	AdminHeader             = "ToDo: Task admins"
+	LabelsHeader            = "ToDo: Labels"
+	WorkflowHeader          = "ToDo: Workflow"
+	WorkflowSetHeader       = "ToDo: Workflow set"
+	MineHeader              = "ToDo: My tasks"
+	PermissionDeniedHeader  = "ToDo: Permission denied"
+	AssignBadArgsText       = "Bad arguments. Please enter /tododo-assign [task ID] [@user]"
+	NoSuchTaskIDText        = "Bad arguments. No task with this ID"
+	NotAUserText            = "Bad arguments. Please mention a user from the list Slack suggests after @, e.g. /tododo-assign 1 @bob"
+	ProgressBadArgsText     = "Bad arguments. Please enter /tododo-start [task ID]"
+	DoneBadArgsText         = "Bad arguments. Please enter /tododo-done [task ID]"
+	MoveBadArgsText         = "Bad arguments. Please enter /tododo-move [task ID] [state], e.g. /tododo-move 12 In Review"
+	WorkflowBadArgsText     = "Please enter /tododo-workflow [state :emoji: [terminal] -> [states]] | ... or /tododo-workflow reset, e.g. /tododo-workflow Open :question: -> In Review | In Review :eyes: -> Done, Open | Done :white_check_mark: terminal"
+	AddBadArgsText          = "Bad arguments. Please enter /tododo-add [task]"
+	EditBadArgsText         = "Bad arguments. Please enter /tododo-edit [task ID] [new title]"
+	DeleteBadArgsText       = "Bad arguments. Please enter /tododo-delete [task ID]"
+	RestoreBadArgsText      = "Bad arguments. Please enter /tododo-restore [task ID]"
+	NoSuchDeletedTaskIDText = "Bad arguments. No deleted task with this ID"
+	TimezoneBadArgsText     = "Bad arguments. Please enter /tododo-timezone [timezone], e.g. /tododo-timezone Europe/Sofia"
+	ShowBadArgsText         = "Please enter /tododo-show [open|started|done|all] [mine|@user] [#label] [state:name] [last 7d] [sort:priority|sort:number|sort:due] [page 2], e.g. /tododo-show done last 7d"
+	PriorityBadArgsText     = "Bad arguments. Please enter /tododo-priority [task ID] [p1|p2|p3|p4|none], e.g. /tododo-priority 3 p1"
+	VisibilityBadArgsText   = "Bad arguments. Please enter /tododo-visibility [default|private|public]"
+	HistoryBadArgsText      = "Bad arguments. Please enter /tododo-history [task ID]"
+	PolicyBadArgsText       = "Bad arguments. Please enter /tododo-policy [open|assignee|admins]"
+	AdminBadArgsText        = "Bad arguments. Please enter /tododo-admin [add|remove] [@user], e.g. /tododo-admin add @bob"
+	TagBadArgsText          = "Bad arguments. Please enter /tododo-tag [task ID] [+label] [-label], e.g. /tododo-tag 12 +security -q4"
+	DeniedAssigneeText      = "Only the assignee, the creator of the task or a task admin can change it in this channel."
+	DeniedAdminsText        = "Only task admins can change tasks in this channel."
+	DeniedSettingsText      = "Only task admins can change the permissions, the workflow and the task admins of this channel."
+	NoTasksText             = "No tasks"
+	NoAdminsText            = "No task admins"
+	NoHistoryText           = "No changes recorded"
+	NoLabelsText            = "No labels"
+	LabelsHintText          = "Show the tasks with a label with /tododo-show #label"
+	WorkflowHintText        = "Move a task to a state with /tododo-move [task ID] [state]"
+	NoStartStateText        = "The workflow of this channel has no state to start tasks in. Please enter /tododo-move [task ID] [state]"
+	TerminalText            = "terminal"
+	CommandErrorText        = "Sorry, something went wrong. Please try again."
+	BusyText                = "Too many commands are running right now. Please try again in a moment."
+	HelpBlock1Text          = "*/tododo-add [!p1] [task] [#label] due [date]*: add a task to your ToDo list, priority !p1 to !p4, labels and due date are optional - today, tomorrow, friday 5pm, in 3 days, 2026-11-02. Without a task it opens a form with assignee, due date, priority and description"
+	HelpBlock2Text          = "*/tododo-show [filters]*: show the unfinished tasks in your ToDo list, highest priority first - open, started, done, all, mine, @user, #label, state:in-review, last 7d, sort:number, sort:due"
+	HelpBlock3Text          = "*/tododo-assign [taskId] [@user]*: assign a task to a user"
+	HelpBlock4Text          = "*/tododo-start [taskId]*: start progress on a task, moves it to the first state after the state of new tasks"
+	HelpBlock5Text          = "*/tododo-done [taskId]*: finish a task, moves it to the first terminal state"
+	HelpBlock6Text          = "*/tododo-timezone [timezone]*: show or set your timezone for due dates"
+	HelpBlock7Text          = "*/tododo-edit [taskId] [title]*: change the title of a task. Without a title it opens the form of the task"
+	HelpBlock8Text          = "*/tododo-delete [taskId]*: delete a task"
+	HelpBlock9Text          = "*/tododo-restore [taskId]*: restore a deleted task"
+	HelpBlock10Text         = "*/tododo-visibility [default|private|public]*: show or set who sees the responses in this channel - by default changes of tasks are posted to the channel, lists and errors are shown only to you"
+	HelpBlock11Text         = "*/tododo-history [taskId]*: show who changed a task and when"
+	HelpBlock12Text         = "*/tododo-policy [open|assignee|admins]*: show or set who can change tasks in this channel - everybody, the assignee and the creator of a task, or only task admins"
+	HelpBlock13Text         = "*/tododo-admin [add|remove] [@user]*: show, add or remove the task admins of this channel, they can change every task and the permissions"
+	HelpBlock14Text         = "*/tododo-priority [taskId] [p1|p2|p3|p4|none]*: set the priority of a task, p1 is the highest"
+	HelpBlock15Text         = "*/tododo-tag [taskId] [+label] [-label]*: add or remove labels of a task"
+	HelpBlock16Text         = "*/tododo-labels*: show the labels used in this channel and how many tasks have them"
+	HelpBlock17Text         = "*/tododo-move [taskId] [state]*: move a task to a state of the workflow of this channel, e.g. In Review"
+	HelpBlock18Text         = "*/tododo-workflow [states]*: show, set or reset the states of tasks in this channel, their emoji and which states a task can move to"
+	HelpBlock19Text         = "*/tododo-mine [open|started|done|all|state:name]*: show the tasks assigned to you in all channels, unfinished tasks if no status is given"
+	StatusOpenEmoji         = ":question:"
+	StatusInProgressEmoji   = ":hourglass_flowing_sand:"
+	StatusDoneEmoji         = ":white_check_mark:"
+	PriorityP1Emoji         = ":red_circle:"
+	PriorityP2Emoji         = ":large_orange_circle:"
+	PriorityP3Emoji         = ":large_yellow_circle:"
+	PriorityP4Emoji         = ":large_blue_circle:"
+	NoPriorityText          = "No priority"
+	StatusOpenText          = "Open"
+	StatusInProgressText    = "In progress"
+	StatusDoneText          = "Done"
+	OverdueText             = ":warning: *Overdue*"
+	NotAssignedText         = "Not assigned"
+	AddedByText             = "Added by"
+	AddedText               = "Added"
+	JustNowText             = "just now"
+	StartButtonText         = "Start"
+	DoneButtonText          = "Done"
+	AssignMeButtonText      = "Assign to me"
+	PreviousButtonText      = "Previous"
+	NextButtonText          = "Next"
+	SourceLinkText          = "Open message"
+	NoMessageTextText       = "This message has no text to add as a task"
+	AddModalTitle           = "Add task"
+	EditModalTitle          = "Edit task"
+	AddModalSubmitText      = "Add"
+	EditModalSubmitText     = "Save"
+	ModalCloseText          = "Close"
+	TitleLabel              = "Title"
+	AssigneeLabel           = "Assignee"
+	DueDateLabel            = "Due date"
+	PriorityLabel           = "Priority"
+	DescriptionLabel        = "Description"
+	TitleRequiredText       = "Please enter a title"
+	TitleTooLongText        = "The title can have at most %d characters"
+	DescriptionTooLongText  = "The description can have at most %d characters"
+	BadDueDateText          = "Please pick a date"
+	PastDueDateText         = "The due date can't be in the past"
+	BadPriorityText         = "Please pick a priority from the list"
+	HomeHeader              = "My tasks"
+	HomeIntroText           = "Unfinished tasks assigned to you in all channels"
+	NoAssignedTasksText     = "No unfinished tasks are assigned to you"
+	MoreTasksText           = "%d more tasks not shown, find them with /tododo-show in their channels"
+	EditButtonText          = "Edit"
+	MineBadArgsText         = "Please enter /tododo-mine [open|started|done|all|state:name], e.g. /tododo-mine started"
+	MineMoreTasksText       = "%d more tasks not shown, show fewer with a status, e.g. /tododo-mine started"
+)
+
+// Action ids of the buttons in /tododo-show, sent back to the interactivity endpoint
+const (
+	ActionStart        = "tododo_start"
+	ActionDone         = "tododo_done"
+	ActionAssignMe     = "tododo_assign_me"
+	ActionPreviousPage = "tododo_previous_page"
+	ActionNextPage     = "tododo_next_page"
+)
+
+// Action ids of the buttons in the App Home tab. Their value is the channel and the number of the task.
+const (
+	ActionHomeStart = "tododo_home_start"
+	ActionHomeDone  = "tododo_home_done"
+	ActionHomeEdit  = "tododo_home_edit"
+)
+
+// Callback id of the "Add to ToDo" message shortcut, set when the shortcut is created in the settings of the app
+const ShortcutAddToDo = "tododo_add_to_todo"
+
+// Callback id of the task modal and the block ids of its inputs, sent back in the view_submission payload.
+// The action id of every input is its block id.
+const (
+	CallbackTaskModal = "tododo_task_modal"
+	InputTitle        = "tododo_title"
+	InputAssignee     = "tododo_assignee"
+	InputDue          = "tododo_due"
+	InputPriority     = "tododo_priority"
+	InputDescription  = "tododo_description"
 )
@@ -0,0 +1,176 @@
+package tododo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Defaults of Dispatcher. Slack accepts responses to response_url for 30 minutes, a command waiting longer than DefaultJobTimeout is not useful anymore.
+const (
+	DefaultWorkers    = 8
+	DefaultQueueSize  = 100
+	DefaultJobTimeout = 30 * time.Second
+)
+
+// Errors returned by Dispatcher.Submit.
+var (
+	ErrQueueFull         = errors.New("tododo: too many commands waiting")
+	ErrDispatcherStopped = errors.New("tododo: dispatcher is stopped")
+)
+
+// Job produces the body of the response to response_url, nil if there is nothing to send. It should stop when ctx is done.
+type Job func(ctx context.Context) ([]byte, error)
+
+// queuedJob is a job waiting for a worker.
+type queuedJob struct {
+	responseURL string
+	job         Job
+	deadline    time.Time
+}
+
+// Dispatcher runs jobs on a bounded pool of workers and sends their results to response_url,
+// so slash commands and interactions are acknowledged within the 3 seconds Slack waits.
+// A job gets a context which is done Timeout after the job was submitted or when Stop gives up waiting for the jobs.
+// A job which fails, e.g. because it stopped when its context was done, or is not started before its context is done
+// results in an error message visible only to the user.
+type Dispatcher struct {
+	Sender  ResponseSenderInterface
+	Timeout time.Duration
+	jobs    chan *queuedJob
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	// mu guards closing stopped against Submit, so no job is queued after the workers are told to stop
+	mu      sync.RWMutex
+	stopped chan struct{}
+}
+
+// NewDispatcher starts workers which run jobs from a queue of queueSize and send their results with sender.
+func NewDispatcher(sender ResponseSenderInterface, workers int, queueSize int) *Dispatcher {
+	dispatcher := Dispatcher{}
+	dispatcher.Sender = sender
+	dispatcher.Timeout = DefaultJobTimeout
+	dispatcher.jobs = make(chan *queuedJob, queueSize)
+	dispatcher.ctx, dispatcher.cancel = context.WithCancel(context.Background())
+	dispatcher.stopped = make(chan struct{})
+	for i := 0; i < workers; i++ {
+		dispatcher.wg.Add(1)
+		go dispatcher.work()
+	}
+	return &dispatcher
+}
+
+// Submit queues job to send its result to responseURL. Returns ErrQueueFull if all workers are busy and the queue is full.
+func (d *Dispatcher) Submit(responseURL string, job Job) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	select {
+	case <-d.stopped:
+		return ErrDispatcherStopped
+	default:
+	}
+	select {
+	case d.jobs <- &queuedJob{responseURL: responseURL, job: job, deadline: time.Now().Add(d.Timeout)}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Stop stops accepting jobs and waits for the workers to finish the queued ones until ctx is done.
+// Then it cancels the context of running jobs, the jobs left in the queue are not started and their users get the error message.
+func (d *Dispatcher) Stop(ctx context.Context) {
+	d.mu.Lock()
+	select {
+	case <-d.stopped:
+	default:
+		close(d.stopped)
+	}
+	d.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	d.cancel()
+	<-done
+}
+
+// work runs queued jobs until the dispatcher is stopped and the queue is empty.
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for {
+		select {
+		case q := <-d.jobs:
+			d.run(q)
+		case <-d.stopped:
+			select {
+			case q := <-d.jobs:
+				d.run(q)
+			default:
+				return
+			}
+		}
+	}
+}
+
+// run executes the job and sends its result or an error message to response_url.
+// Nothing is sent if the job has no response_url, e.g. an event, or returns no body because it posted its result itself.
+func (d *Dispatcher) run(q *queuedJob) {
+	ctx, cancel := context.WithDeadline(d.ctx, q.deadline)
+	defer cancel()
+	body, err := execute(ctx, q.job)
+	if err != nil {
+		log.Printf("[ERROR] Command failed: %s", err)
+		body, err = ErrorResponse()
+		if err != nil {
+			return
+		}
+	}
+	if q.responseURL == "" || body == nil {
+		return
+	}
+	// the result of a job which ran past its deadline is still sent, so the response is not bound to the context of the job
+	err = d.Sender.Send(context.Background(), q.responseURL, body)
+	if err != nil {
+		log.Printf("[ERROR] Can't send response: %s", err)
+	}
+}
+
+// execute runs job unless ctx is already done. The result of a job which succeeds is returned even if ctx is done by then,
+// because its changes are already saved.
+func execute(ctx context.Context, job Job) ([]byte, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return job(ctx)
+}
+
+// ErrorResponse constructs the message shown to the user when a command fails.
+// Messages sent to response_url are visible only to the user by default.
+func ErrorResponse() ([]byte, error) {
+	resp := NewResponse(NewSectionTextBlock(PlainTextType, CommandErrorText))
+	byt, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return byt, nil
+}
+
+// BusyResponse constructs the message shown to the user when the command can't be queued.
+func BusyResponse() ([]byte, error) {
+	resp := NewResponse(NewSectionTextBlock(PlainTextType, BusyText))
+	byt, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return byt, nil
+}
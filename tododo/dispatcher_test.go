@@ -0,0 +1,204 @@
+package tododo
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type sentResponse struct {
+	url  string
+	body string
+}
+
+type MockSender struct {
+	sent chan sentResponse
+}
+
+func (sender *MockSender) Send(ctx context.Context, responseURL string, body []byte) error {
+	sender.sent <- sentResponse{url: responseURL, body: string(body)}
+	return nil
+}
+
+func newMockSender() *MockSender {
+	return &MockSender{sent: make(chan sentResponse, 10)}
+}
+
+func receive(t *testing.T, sender *MockSender) sentResponse {
+	select {
+	case resp := <-sender.sent:
+		return resp
+	case <-time.After(5 * time.Second):
+		t.Fatal("no response sent")
+	}
+	return sentResponse{}
+}
+
+func TestDispatcherSendsResult(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 2, 2)
+	defer dispatcher.Stop(ctx)
+	err := dispatcher.Submit("https://hooks.slack.com/1", func(ctx context.Context) ([]byte, error) {
+		return []byte(`{"blocks":[]}`), nil
+	})
+	require.NoError(t, err)
+	resp := receive(t, sender)
+	assert.Equal(t, "https://hooks.slack.com/1", resp.url)
+	assert.Equal(t, `{"blocks":[]}`, resp.body)
+}
+
+func TestDispatcherSendsNothingWithoutBodyOrURL(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 1, 3)
+	defer dispatcher.Stop(ctx)
+	err := dispatcher.Submit("https://hooks.slack.com/1", func(ctx context.Context) ([]byte, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	err = dispatcher.Submit("", func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("db is down")
+	})
+	require.NoError(t, err)
+	err = dispatcher.Submit("https://hooks.slack.com/2", func(ctx context.Context) ([]byte, error) {
+		return []byte(`{"blocks":[]}`), nil
+	})
+	require.NoError(t, err)
+	resp := receive(t, sender)
+	assert.Equal(t, "https://hooks.slack.com/2", resp.url)
+}
+
+func TestDispatcherSendsErrorMessage(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 1, 1)
+	defer dispatcher.Stop(ctx)
+	err := dispatcher.Submit("https://hooks.slack.com/1", func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("db is down")
+	})
+	require.NoError(t, err)
+	resp := receive(t, sender)
+	assert.Contains(t, resp.body, CommandErrorText)
+	assert.NotContains(t, resp.body, "db is down")
+}
+
+func TestDispatcherTimeout(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 1, 1)
+	defer dispatcher.Stop(ctx)
+	dispatcher.Timeout = 10 * time.Millisecond
+	err := dispatcher.Submit("https://hooks.slack.com/1", func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	require.NoError(t, err)
+	resp := receive(t, sender)
+	assert.Contains(t, resp.body, CommandErrorText)
+}
+
+func TestDispatcherSendsResultAfterTimeout(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 1, 1)
+	defer dispatcher.Stop(ctx)
+	dispatcher.Timeout = 10 * time.Millisecond
+	err := dispatcher.Submit("https://hooks.slack.com/1", func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		return []byte(`{"blocks":[]}`), nil
+	})
+	require.NoError(t, err)
+	resp := receive(t, sender)
+	assert.Equal(t, `{"blocks":[]}`, resp.body)
+}
+
+func TestDispatcherQueueFull(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 1, 1)
+	defer dispatcher.Stop(ctx)
+	started := make(chan bool)
+	release := make(chan bool)
+	blocking := func(ctx context.Context) ([]byte, error) {
+		started <- true
+		<-release
+		return []byte(`{"blocks":[]}`), nil
+	}
+	require.NoError(t, dispatcher.Submit("https://hooks.slack.com/1", blocking))
+	<-started
+	require.NoError(t, dispatcher.Submit("https://hooks.slack.com/2", func(ctx context.Context) ([]byte, error) {
+		return []byte(`{"blocks":[]}`), nil
+	}))
+	err := dispatcher.Submit("https://hooks.slack.com/3", blocking)
+	assert.Equal(t, ErrQueueFull, err)
+	close(release)
+	assert.Equal(t, "https://hooks.slack.com/1", receive(t, sender).url)
+	assert.Equal(t, "https://hooks.slack.com/2", receive(t, sender).url)
+}
+
+func TestDispatcherStop(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 1, 1)
+	cancelled := make(chan error, 1)
+	started := make(chan bool)
+	require.NoError(t, dispatcher.Submit("https://hooks.slack.com/1", func(ctx context.Context) ([]byte, error) {
+		started <- true
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	}))
+	<-started
+	stopCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	dispatcher.Stop(stopCtx)
+	assert.Equal(t, context.Canceled, <-cancelled)
+	err := dispatcher.Submit("https://hooks.slack.com/2", func(ctx context.Context) ([]byte, error) {
+		return nil, nil
+	})
+	assert.Equal(t, ErrDispatcherStopped, err)
+}
+
+func TestDispatcherStopDrainsQueue(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 1, 2)
+	release := make(chan bool)
+	require.NoError(t, dispatcher.Submit("https://hooks.slack.com/1", func(ctx context.Context) ([]byte, error) {
+		<-release
+		return []byte(`{"blocks":[]}`), nil
+	}))
+	require.NoError(t, dispatcher.Submit("https://hooks.slack.com/2", func(ctx context.Context) ([]byte, error) {
+		return []byte(`{"blocks":[]}`), nil
+	}))
+	close(release)
+	dispatcher.Stop(ctx)
+	assert.Equal(t, `{"blocks":[]}`, receive(t, sender).body)
+	assert.Equal(t, `{"blocks":[]}`, receive(t, sender).body)
+}
+
+func TestDispatcherStopAnswersDroppedJobs(t *testing.T) {
+	sender := newMockSender()
+	dispatcher := NewDispatcher(sender, 1, 2)
+	started := make(chan bool)
+	require.NoError(t, dispatcher.Submit("https://hooks.slack.com/1", func(ctx context.Context) ([]byte, error) {
+		started <- true
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+	<-started
+	require.NoError(t, dispatcher.Submit("https://hooks.slack.com/2", func(ctx context.Context) ([]byte, error) {
+		return []byte(`{"blocks":[]}`), nil
+	}))
+	stopCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	dispatcher.Stop(stopCtx)
+	first := receive(t, sender)
+	assert.Equal(t, "https://hooks.slack.com/1", first.url)
+	assert.Contains(t, first.body, CommandErrorText)
+	second := receive(t, sender)
+	assert.Equal(t, "https://hooks.slack.com/2", second.url)
+	assert.Contains(t, second.body, CommandErrorText)
+}
+
+func TestBusyResponse(t *testing.T) {
+	byt, err := BusyResponse()
+	require.NoError(t, err)
+	assert.Contains(t, string(byt), BusyText)
+}
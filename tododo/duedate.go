@@ -0,0 +1,160 @@
+package tododo
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDueHour is the hour of the day set to due dates given without time, e.g. "due friday".
+const DefaultDueHour = 17
+
+var (
+	isoDateRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	clockRegexp   = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	weekdays      = map[string]time.Weekday{
+		"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+		"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+)
+
+// ParseDueDate splits the text of /tododo-add into title and due date.
+// The due date follows the last word "due" - "Ship release notes due friday 5pm".
+// Supported dates: today, tomorrow, a weekday, next [weekday], in [N] minutes/hours/days/weeks, YYYY-MM-DD, optionally followed by a time like 5pm, 5:30pm or 17:00.
+// Dates are relative to now and in its location, which should be the timezone of the user.
+// A weekday or a time alone which already passed today means the next week or the next day.
+// Returns the whole text as title and nil due date if there is no due date or it can't be parsed.
+func ParseDueDate(text string, now time.Time) (string, *time.Time) {
+	words := strings.Fields(text)
+	for i := len(words) - 2; i > 0; i-- {
+		if !strings.EqualFold(words[i], "due") {
+			continue
+		}
+		due, ok := parseDueExpression(words[i+1:], now)
+		if !ok {
+			return text, nil
+		}
+		return strings.Join(words[:i], " "), &due
+	}
+	return text, nil
+}
+
+func parseDueExpression(words []string, now time.Time) (time.Time, bool) {
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	if len(words) == 3 && words[0] == "in" {
+		n, err := strconv.Atoi(words[1])
+		if err != nil || n < 1 {
+			return time.Time{}, false
+		}
+		switch strings.TrimSuffix(words[2], "s") {
+		case "minute", "min":
+			return now.Add(time.Duration(n) * time.Minute).Truncate(time.Minute), true
+		case "hour":
+			return now.Add(time.Duration(n) * time.Hour).Truncate(time.Minute), true
+		case "day":
+			return atHour(today.AddDate(0, 0, n), DefaultDueHour, 0), true
+		case "week":
+			return atHour(today.AddDate(0, 0, 7*n), DefaultDueHour, 0), true
+		}
+		return time.Time{}, false
+	}
+
+	var day time.Time
+	rest := words
+	weekdayOnly, timeOnly := false, false
+	switch {
+	case words[0] == "today":
+		day, rest = today, words[1:]
+	case words[0] == "tomorrow":
+		day, rest = today.AddDate(0, 0, 1), words[1:]
+	case words[0] == "next" && len(words) > 1:
+		weekday, ok := weekdays[words[1]]
+		if !ok {
+			return time.Time{}, false
+		}
+		day, rest = nextWeekday(today, weekday).AddDate(0, 0, 7), words[2:]
+	case isoDateRegexp.MatchString(words[0]):
+		date, err := time.ParseInLocation("2006-01-02", words[0], loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+		day, rest = date, words[1:]
+	default:
+		if weekday, ok := weekdays[words[0]]; ok {
+			day, rest = nextWeekday(today, weekday), words[1:]
+			weekdayOnly = true
+		} else {
+			day = today
+			timeOnly = true
+		}
+	}
+
+	if len(rest) > 0 && rest[0] == "at" {
+		rest = rest[1:]
+	}
+	hour, min := DefaultDueHour, 0
+	switch len(rest) {
+	case 0:
+	case 1:
+		var ok bool
+		hour, min, ok = parseClock(rest[0])
+		if !ok {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+	due := atHour(day, hour, min)
+	if weekdayOnly && due.Before(now) {
+		due = due.AddDate(0, 0, 7)
+	}
+	if timeOnly && due.Before(now) {
+		due = due.AddDate(0, 0, 1)
+	}
+	return due, true
+}
+
+// nextWeekday returns today if it is weekday, otherwise the first following day that is weekday.
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday) - int(today.Weekday()) + 7) % 7
+	return today.AddDate(0, 0, days)
+}
+
+// parseClock accepts 5pm, 5:30pm, 12am and 17:00.
+func parseClock(word string) (int, int, bool) {
+	match := clockRegexp.FindStringSubmatch(word)
+	if match == nil || match[2] == "" && match[3] == "" {
+		return 0, 0, false
+	}
+	hour, _ := strconv.Atoi(match[1])
+	min := 0
+	if match[2] != "" {
+		min, _ = strconv.Atoi(match[2])
+	}
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour = hour % 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || min > 59 {
+		return 0, 0, false
+	}
+	return hour, min, true
+}
+
+func atHour(day time.Time, hour int, min int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, min, 0, 0, day.Location())
+}
@@ -0,0 +1,74 @@
+package tododo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseDueDate(t *testing.T) {
+	sofia, _ := time.LoadLocation("Europe/Sofia")
+	// Wednesday
+	now := time.Date(2026, 10, 14, 10, 30, 0, 0, sofia)
+	at := func(month time.Month, day int, hour int, min int) *time.Time {
+		due := time.Date(2026, month, day, hour, min, 0, 0, sofia)
+		return &due
+	}
+	tests := []struct {
+		text  string
+		title string
+		due   *time.Time
+	}{
+		{"Ship release notes due friday 5pm", "Ship release notes", at(10, 16, 17, 0)},
+		{"Ship release notes due Friday", "Ship release notes", at(10, 16, DefaultDueHour, 0)},
+		{"Ship release notes due fri at 9:30am", "Ship release notes", at(10, 16, 9, 30)},
+		{"Call bank due tomorrow", "Call bank", at(10, 15, DefaultDueHour, 0)},
+		{"Call bank due tomorrow 12am", "Call bank", at(10, 15, 0, 0)},
+		{"Call bank due today 18:45", "Call bank", at(10, 14, 18, 45)},
+		{"Call bank due 3pm", "Call bank", at(10, 14, 15, 0)},
+		{"Call bank due 9am", "Call bank", at(10, 15, 9, 0)},
+		{"Call bank due at 10:30", "Call bank", at(10, 14, 10, 30)},
+		{"Call bank due today 9am", "Call bank", at(10, 14, 9, 0)},
+		{"Renew domain due in 3 days", "Renew domain", at(10, 17, DefaultDueHour, 0)},
+		{"Renew domain due in 1 day", "Renew domain", at(10, 15, DefaultDueHour, 0)},
+		{"Renew domain due in 2 weeks", "Renew domain", at(10, 28, DefaultDueHour, 0)},
+		{"Renew domain due in 2 hours", "Renew domain", at(10, 14, 12, 30)},
+		{"Renew domain due in 45 minutes", "Renew domain", at(10, 14, 11, 15)},
+		{"Plan sprint due 2026-11-02", "Plan sprint", at(11, 2, DefaultDueHour, 0)},
+		{"Plan sprint due 2026-11-02 9am", "Plan sprint", at(11, 2, 9, 0)},
+		{"Plan sprint due wednesday", "Plan sprint", at(10, 14, DefaultDueHour, 0)},
+		{"Plan sprint due wednesday 9am", "Plan sprint", at(10, 21, 9, 0)},
+		{"Plan sprint due next monday", "Plan sprint", at(10, 26, DefaultDueHour, 0)},
+		{"Pay dues due monday", "Pay dues", at(10, 19, DefaultDueHour, 0)},
+		{"Review due process due monday", "Review due process", at(10, 19, DefaultDueHour, 0)},
+		{"Pay dues", "Pay dues", nil},
+		{"Review due process", "Review due process", nil},
+		{"due tomorrow", "due tomorrow", nil},
+		{"Ship it due", "Ship it due", nil},
+		{"Ship it due someday", "Ship it due someday", nil},
+		{"Ship it due friday 25pm", "Ship it due friday 25pm", nil},
+		{"Ship it due 2026-13-40", "Ship it due 2026-13-40", nil},
+		{"Ship it due in many days", "Ship it due in many days", nil},
+		{"Ship it due in 0 days", "Ship it due in 0 days", nil},
+		{"Ship it due friday 5pm sharp", "Ship it due friday 5pm sharp", nil},
+		{"Ship it due 17", "Ship it due 17", nil},
+	}
+	for _, tc := range tests {
+		title, due := ParseDueDate(tc.text, now)
+		assert.Equal(t, tc.title, title, tc.text)
+		if tc.due == nil {
+			assert.Nil(t, due, tc.text)
+		} else if assert.NotNil(t, due, tc.text) {
+			assert.True(t, tc.due.Equal(*due), "%s: expected %s, got %s", tc.text, tc.due, due)
+		}
+	}
+}
+
+func TestParseDueDateKeepsLocation(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	now := time.Date(2026, 10, 14, 23, 0, 0, 0, time.UTC)
+	_, due := ParseDueDate("Call bank due tomorrow 9am", now.In(tokyo))
+	if assert.NotNil(t, due) {
+		assert.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), due.UTC())
+	}
+}
@@ -0,0 +1,196 @@
+package tododo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event payload and event types handled by the bot.
+const (
+	EventURLVerification = "url_verification"
+	EventCallback        = "event_callback"
+	EventReactionAdded   = "reaction_added"
+	EventItemMessage     = "message"
+	EventAppHomeOpened   = "app_home_opened"
+)
+
+// DefaultEventTTL is how long EventDeduplicator remembers an event. Slack retries a delivery which is not acknowledged within 3 seconds
+// after about 1 minute and 5 minutes, refer to https://api.slack.com/apis/connections/events-api#retries.
+const DefaultEventTTL = 10 * time.Minute
+
+// EventPayload is the part of the body of an Events API request used by the bot.
+// Challenge is set for url_verification, EventID and Event for event_callback.
+// Refer to https://api.slack.com/apis/connections/events-api for details.
+type EventPayload struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	EventID   string `json:"event_id"`
+	Event     *Event `json:"event"`
+}
+
+// Event is an event the app is subscribed to. Refer to https://api.slack.com/events/reaction_added for details.
+// User is the user who reacted, Reaction the name of the emoji without colons, e.g. white_check_mark.
+// For app_home_opened User is the user who opened the App Home and Tab the opened tab, refer to https://api.slack.com/events/app_home_opened.
+type Event struct {
+	Type     string    `json:"type"`
+	User     string    `json:"user"`
+	Tab      string    `json:"tab"`
+	Reaction string    `json:"reaction"`
+	Item     EventItem `json:"item"`
+}
+
+// EventItem is the item a reaction was added to. A message is identified by its channel and timestamp.
+type EventItem struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// ParseEventPayload reads the json body of an Events API request.
+func ParseEventPayload(r *http.Request) (*EventPayload, error) {
+	var payload EventPayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// ChallengeResponse constructs the answer to the url_verification request Slack sends when the request URL of events is set.
+func ChallengeResponse(payload *EventPayload) ([]byte, error) {
+	return json.Marshal(map[string]string{"challenge": payload.Challenge})
+}
+
+// HandleEvent handles an event_callback. A reaction added to a bot message showing a task, refer to respondTask,
+// with the emoji of a state of the workflow of the channel moves the task to this state like /tododo-move,
+// e.g. :white_check_mark: marks it Done and :hourglass_flowing_sand: starts it in the default workflow.
+// Other reactions, messages not showing a task, denied changes and moves the workflow doesn't allow are ignored.
+// Opening the Home tab of the App Home publishes the tasks assigned to the user, refer to PublishHome.
+func (handler *CommandHandler) HandleEvent(ctx context.Context, payload *EventPayload) error {
+	if payload.Type != EventCallback || payload.Event == nil {
+		return fmt.Errorf("Can't handle event %s", payload.Type)
+	}
+	event := payload.Event
+	if event.Type == EventAppHomeOpened {
+		if event.Tab != HomeTab {
+			return nil
+		}
+		return handler.PublishHome(ctx, event.User)
+	}
+	if event.Type != EventReactionAdded || event.Item.Type != EventItemMessage {
+		return nil
+	}
+	task, err := handler.Repository.GetTaskByMessage(ctx, event.Item.Channel, event.Item.TS)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	workflow, err := handler.channelWorkflow(ctx, task.ChannelID)
+	if err != nil {
+		return err
+	}
+	state := reactionState(workflow, event.Reaction)
+	if state == nil || state.Name == task.Status {
+		return nil
+	}
+	err = handler.moveTask(ctx, workflow, task.ChannelID, task.Number, state, event.User)
+	if err == mysql.ErrNoRowOrMoreThanOne {
+		return nil
+	} else if denied, ok := err.(*PermissionError); ok {
+		log.Printf("[INFO] Reaction of %s ignored: %s", event.User, denied.Reason)
+		return nil
+	} else if transition, ok := err.(*TransitionError); ok {
+		log.Printf("[INFO] Reaction of %s ignored: %s", event.User, transitionText(transition))
+		return nil
+	}
+	return err
+}
+
+// reactionState returns the state of the workflow with the emoji of reaction, a name without colons. Returns nil if there is none.
+func reactionState(workflow *mysql.Workflow, reaction string) *mysql.WorkflowState {
+	for _, state := range workflow.States {
+		if state.Emoji == ":"+reaction+":" {
+			return state
+		}
+	}
+	return nil
+}
+
+// EventDeduplicator remembers the IDs of events for TTL, so an event Slack delivers again is handled once.
+// Events are kept in the order they were seen, so expired ones are dropped from the front without scanning all of them.
+type EventDeduplicator struct {
+	TTL time.Duration
+	// Now returns the current time, time.Now if not set
+	Now   func() time.Time
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	order []seenEvent
+}
+
+// seenEvent is an event ID and when it was recorded.
+type seenEvent struct {
+	id   string
+	seen time.Time
+}
+
+// NewEventDeduplicator constructs a deduplicator remembering events for DefaultEventTTL.
+func NewEventDeduplicator() *EventDeduplicator {
+	dedupe := EventDeduplicator{}
+	dedupe.TTL = DefaultEventTTL
+	dedupe.Now = time.Now
+	dedupe.seen = make(map[string]time.Time)
+	return &dedupe
+}
+
+// Seen records the event with eventID and reports whether it was already recorded within TTL.
+// Events older than TTL are forgotten. An event without ID is never recorded, so it is always handled.
+func (dedupe *EventDeduplicator) Seen(eventID string) bool {
+	if eventID == "" {
+		return false
+	}
+	dedupe.mu.Lock()
+	defer dedupe.mu.Unlock()
+	now := dedupe.now()
+	dedupe.expire(now)
+	_, ok := dedupe.seen[eventID]
+	if !ok {
+		dedupe.seen[eventID] = now
+		dedupe.order = append(dedupe.order, seenEvent{id: eventID, seen: now})
+	}
+	return ok
+}
+
+// expire forgets the events recorded TTL or longer before now, the oldest ones are at the front of order.
+// An event forgotten and recorded again in the meantime is kept until its new record expires.
+func (dedupe *EventDeduplicator) expire(now time.Time) {
+	i := 0
+	for ; i < len(dedupe.order) && now.Sub(dedupe.order[i].seen) >= dedupe.TTL; i++ {
+		event := dedupe.order[i]
+		if seen, ok := dedupe.seen[event.id]; ok && seen.Equal(event.seen) {
+			delete(dedupe.seen, event.id)
+		}
+	}
+	dedupe.order = dedupe.order[i:]
+}
+
+// Forget removes the event with eventID, so it is handled when Slack delivers it again, e.g. when it couldn't be queued.
+func (dedupe *EventDeduplicator) Forget(eventID string) {
+	dedupe.mu.Lock()
+	defer dedupe.mu.Unlock()
+	delete(dedupe.seen, eventID)
+}
+
+func (dedupe *EventDeduplicator) now() time.Time {
+	if dedupe.Now == nil {
+		return time.Now()
+	}
+	return dedupe.Now()
+}
@@ -0,0 +1,153 @@
+package tododo
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/slackapi"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/slacktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSlackHandler(t *testing.T) (*CommandHandler, *slacktest.Server) {
+	server := slacktest.NewServer()
+	server.AddConversation(&slackapi.Conversation{ID: "CH1", Name: "general"})
+	handler := newMockHandler()
+	handler.Slack = server.NewClient()
+	return handler, server
+}
+
+func newReaction(reaction string, ts string) *EventPayload {
+	return &EventPayload{
+		Type:    EventCallback,
+		EventID: "Ev1",
+		Event:   &Event{Type: EventReactionAdded, User: "U5", Reaction: reaction, Item: EventItem{Type: EventItemMessage, Channel: "CH1", TS: ts}},
+	}
+}
+
+func TestParseEventPayload(t *testing.T) {
+	body := `{"type":"event_callback","event_id":"Ev1","event":{"type":"reaction_added","user":"U5","reaction":"white_check_mark","item":{"type":"message","channel":"CH1","ts":"1700000000.000001"}}}`
+	payload, err := ParseEventPayload(httptest.NewRequest("POST", "/tododo/events", strings.NewReader(body)))
+	require.NoError(t, err)
+	assert.Equal(t, newReaction("white_check_mark", "1700000000.000001"), payload)
+}
+
+func TestChallengeResponse(t *testing.T) {
+	body := `{"token":"Jhj5dZrVaK7ZwHHjRyZWjbDl","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`
+	payload, err := ParseEventPayload(httptest.NewRequest("POST", "/tododo/events", strings.NewReader(body)))
+	require.NoError(t, err)
+	assert.Equal(t, EventURLVerification, payload.Type)
+	result, err := ChallengeResponse(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`, string(result))
+}
+
+func TestRespondTaskPostsMessage(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	result, err := mockHandler.HandleDoneCommand(ctx, "1", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "CH1", messages[0].Channel)
+	assert.Equal(t, UpdateHeader+"\nStatus: MockTitle - Open", messages[0].Text)
+	task, err := mockHandler.Repository.GetTaskByMessage(ctx, "CH1", messages[0].TS)
+	require.NoError(t, err)
+	assert.Equal(t, 1, task.Number)
+}
+
+func TestRespondTaskFallsBackToResponseURL(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	mockHandler.Channels.SetVisibility(ctx, "CH1", mysql.VisibilityPrivate)
+	result, err := mockHandler.HandleDoneCommand(ctx, "1", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "Status: MockTitle - Open")
+	assert.Contains(t, string(result), ResponseEphemeral)
+	assert.Empty(t, server.Messages())
+
+	mockHandler.Channels.SetVisibility(ctx, "CH1", mysql.VisibilityPublic)
+	server.Close()
+	result, err = mockHandler.HandleDoneCommand(ctx, "1", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "Status: MockTitle - Open")
+	assert.Contains(t, string(result), ResponseInChannel)
+}
+
+func TestHandleEventReaction(t *testing.T) {
+	tests := []struct {
+		reaction string
+		status   string
+		terminal bool
+	}{
+		{"white_check_mark", mysql.StatusDone, true},
+		{"hourglass_flowing_sand", mysql.StatusInProgress, false},
+		{"tada", "", false},
+	}
+	for _, tc := range tests {
+		mockHandler := newMockHandler()
+		repo := mockHandler.Repository.(*MockRepo)
+		repo.AddTaskMessage(ctx, "CH1", 1, "1700000000.000001")
+		err := mockHandler.HandleEvent(ctx, newReaction(tc.reaction, "1700000000.000001"))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.status, repo.status, tc.reaction)
+		assert.Equal(t, tc.terminal, repo.terminal, tc.reaction)
+		if tc.status != "" {
+			assert.Equal(t, "U5", repo.actor)
+		}
+	}
+}
+
+func TestHandleEventIgnored(t *testing.T) {
+	mockHandler, repo := newReviewHandler(t)
+	repo.AddTaskMessage(ctx, "CH1", 1, "1700000000.000001")
+	assert.NoError(t, mockHandler.HandleEvent(ctx, newReaction("white_check_mark", "1700000000.000001")))
+	assert.NoError(t, mockHandler.HandleEvent(ctx, newReaction("white_check_mark", "1700000000.000002")))
+	removed := newReaction("white_check_mark", "1700000000.000001")
+	removed.Event.Type = "reaction_removed"
+	assert.NoError(t, mockHandler.HandleEvent(ctx, removed))
+	assert.Equal(t, "", repo.status)
+
+	assert.NoError(t, mockHandler.HandleEvent(ctx, newReaction("eyes", "1700000000.000001")))
+	assert.Equal(t, "In Review", repo.status)
+
+	assert.Error(t, mockHandler.HandleEvent(ctx, &EventPayload{Type: EventURLVerification}))
+}
+
+func TestEventDeduplicator(t *testing.T) {
+	now := mockNow
+	dedupe := NewEventDeduplicator()
+	dedupe.Now = func() time.Time { return now }
+	assert.False(t, dedupe.Seen("Ev1"))
+	assert.True(t, dedupe.Seen("Ev1"))
+	assert.False(t, dedupe.Seen("Ev2"))
+	now = now.Add(DefaultEventTTL)
+	assert.False(t, dedupe.Seen("Ev1"))
+	dedupe.Forget("Ev1")
+	assert.False(t, dedupe.Seen("Ev1"))
+	assert.False(t, dedupe.Seen(""))
+	assert.False(t, dedupe.Seen(""))
+}
+
+func TestEventDeduplicatorExpiresInOrder(t *testing.T) {
+	now := mockNow
+	dedupe := NewEventDeduplicator()
+	dedupe.Now = func() time.Time { return now }
+	assert.False(t, dedupe.Seen("Ev1"))
+	now = now.Add(DefaultEventTTL / 2)
+	assert.False(t, dedupe.Seen("Ev2"))
+	dedupe.Forget("Ev1")
+	assert.False(t, dedupe.Seen("Ev1"))
+	now = now.Add(DefaultEventTTL / 2)
+	assert.False(t, dedupe.Seen("Ev3"))
+	assert.True(t, dedupe.Seen("Ev1"))
+	assert.True(t, dedupe.Seen("Ev2"))
+	assert.Len(t, dedupe.order, 3)
+	now = now.Add(DefaultEventTTL / 2)
+	assert.False(t, dedupe.Seen("Ev2"))
+	assert.Len(t, dedupe.seen, 2)
+}
@@ -0,0 +1,136 @@
+package tododo
+
+import (
+	"context"
+	"database/sql"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistorySize is the number of latest changes shown by /tododo-history, so the response stays within MaxBlocks.
+const HistorySize = 40
+
+// HandleHistoryCommand handles /tododo-history and returns the changes of the task with the number in text, oldest first.
+// Every change is a context block with the time in the timezone of the user and a sentence about who changed what.
+func (handler *CommandHandler) HandleHistoryCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if !ValidateStatusText(text) {
+		return textResponse(HistoryHeader, PlainTextType, HistoryBadArgsText)
+	}
+	id, _ := strconv.Atoi(text)
+	task, err := handler.Repository.GetTask(ctx, channelID, id)
+	if err == sql.ErrNoRows {
+		return textResponse(HistoryHeader, PlainTextType, NoSuchTaskIDText)
+	} else if err != nil {
+		return nil, err
+	}
+	events, err := handler.Repository.GetTaskEvents(ctx, channelID, id)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := handler.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	header := NewHeaderBlock(HistoryHeader)
+	div := NewDividerBlock()
+	blocks := []*Block{header, div, NewSectionTextBlock(MarkdownType, "*"+strconv.Itoa(task.Number)+"*: "+task.Title)}
+	if len(events) > HistorySize {
+		hidden := len(events) - HistorySize
+		blocks = append(blocks, NewContextBlock(&BlockText{Type: PlainTextType, Text: strconv.Itoa(hidden) + " earlier changes not shown"}))
+		events = events[hidden:]
+	}
+	for _, event := range events {
+		when := &BlockText{Type: MarkdownType, Text: formatDate(event.CreatedAt, loc)}
+		what := &BlockText{Type: MarkdownType, Text: describeEvent(event)}
+		blocks = append(blocks, NewContextBlock(when, what))
+	}
+	if len(events) == 0 {
+		blocks = append(blocks, NewSectionTextBlock(PlainTextType, NoHistoryText))
+	}
+	resp := NewResponse(blocks...)
+	err = resp.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return handler.respond(ctx, resp, channelID, ResponseEphemeral)
+}
+
+// describeEvent renders a change of a task as a sentence, e.g. "<@U1> changed the status from Open to Done".
+func describeEvent(event *mysql.TaskEvent) string {
+	actor := FormatUserMention(event.ActorID)
+	switch event.Field {
+	case mysql.FieldCreated:
+		return actor + " added the task *" + event.NewValue + "*"
+	case mysql.FieldStatus:
+		return actor + " changed the status from " + getStatusName(event.OldValue) + " to " + getStatusName(event.NewValue)
+	case mysql.FieldAssignee:
+		if event.NewValue == "" {
+			return actor + " unassigned the task"
+		}
+		return actor + " assigned the task to " + FormatUserMention(event.NewValue)
+	case mysql.FieldTitle:
+		return actor + " renamed the task from *" + event.OldValue + "* to *" + event.NewValue + "*"
+	case mysql.FieldPriority:
+		oldPriority, _ := strconv.Atoi(event.OldValue)
+		newPriority, _ := strconv.Atoi(event.NewValue)
+		if newPriority == mysql.PriorityNone {
+			return actor + " removed the priority"
+		}
+		if oldPriority == mysql.PriorityNone {
+			return actor + " set the priority to " + getPriorityName(newPriority)
+		}
+		return actor + " changed the priority from " + getPriorityName(oldPriority) + " to " + getPriorityName(newPriority)
+	case mysql.FieldLabels:
+		oldLabels := strings.Fields(event.OldValue)
+		newLabels := strings.Fields(event.NewValue)
+		added := labelsNotIn(newLabels, oldLabels)
+		removed := labelsNotIn(oldLabels, newLabels)
+		if len(removed) == 0 {
+			return actor + " labeled the task " + formatLabels(added)
+		}
+		if len(added) == 0 {
+			return actor + " removed the labels " + formatLabels(removed)
+		}
+		return actor + " labeled the task " + formatLabels(added) + " and removed " + formatLabels(removed)
+	case mysql.FieldDescription:
+		if event.NewValue == "" {
+			return actor + " removed the description"
+		}
+		if event.OldValue == "" {
+			return actor + " added a description"
+		}
+		return actor + " changed the description"
+	case mysql.FieldDue:
+		newDue, err := time.Parse(time.RFC3339, event.NewValue)
+		if err != nil {
+			return actor + " removed the due date"
+		}
+		if event.OldValue == "" {
+			return actor + " set the due date to " + formatDate(newDue, time.UTC)
+		}
+		return actor + " changed the due date to " + formatDate(newDue, time.UTC)
+	case mysql.FieldDeleted:
+		if event.NewValue == "1" {
+			return actor + " deleted the task"
+		}
+		return actor + " restored the task"
+	}
+	return actor + " changed " + event.Field
+}
+
+// labelsNotIn returns the labels which are not in other
+func labelsNotIn(labels []string, other []string) []string {
+	res := make([]string, 0)
+	for _, label := range labels {
+		found := false
+		for _, o := range other {
+			found = found || o == label
+		}
+		if !found {
+			res = append(res, label)
+		}
+	}
+	return res
+}
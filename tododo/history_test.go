@@ -0,0 +1,95 @@
+package tododo
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestHandleHistoryCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleHistoryCommand(ctx, "1", "CH1", "U2")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, HistoryHeader)
+	assert.Contains(t, stringRes, "*1*: MockTitle")
+	assert.Contains(t, stringRes, `"type":"context"`)
+	assert.Contains(t, stringRes, `\u003c@U0AAA\u003e added the task *MockTitle*`)
+	assert.Contains(t, stringRes, `\u003c@U0AAA\u003e assigned the task to \u003c@U1ABC\u003e`)
+	assert.Contains(t, stringRes, `\u003c@U1ABC\u003e changed the status from Open to Done`)
+	assert.Contains(t, stringRes, `\u003c@U0BBB\u003e deleted the task`)
+	assert.Contains(t, stringRes, "Mon Oct 12 06:00 EDT")
+	assert.Contains(t, stringRes, `"response_type":"ephemeral"`)
+	assert.True(t, strings.Index(stringRes, "added the task") < strings.Index(stringRes, "deleted the task"))
+}
+
+func TestHandleHistoryCommandBadArgs(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleHistoryCommand(ctx, "one", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), HistoryBadArgsText)
+	result, err = mockHandler.HandleHistoryCommand(ctx, "2", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoSuchTaskIDText)
+}
+
+func TestHandleHistoryCommandNoEvents(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleHistoryCommand(ctx, "1", "CH3", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoHistoryText)
+}
+
+func TestHandleHistoryCommandLatest(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleHistoryCommand(ctx, "1", "CH4", "U1")
+	require.NoError(t, err)
+	stringRes := string(result)
+	assert.Contains(t, stringRes, "5 earlier changes not shown")
+	assert.NotContains(t, stringRes, "from *Title4* to *Title5*")
+	assert.Contains(t, stringRes, "from *Title5* to *Title6*")
+	assert.Contains(t, stringRes, "from *Title44* to *Title45*")
+}
+
+func TestDescribeEvent(t *testing.T) {
+	tests := []struct {
+		event    mysql.TaskEvent
+		expected string
+	}{
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldAssignee, OldValue: "U0BBB"}, "<@U0AAA> unassigned the task"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldTitle, OldValue: "draft", NewValue: "final"}, "<@U0AAA> renamed the task from *draft* to *final*"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldStatus, OldValue: mysql.StatusDone, NewValue: mysql.StatusInProgress}, "<@U0AAA> changed the status from Done to In progress"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldDeleted, OldValue: "1", NewValue: "0"}, "<@U0AAA> restored the task"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldPriority, OldValue: "0", NewValue: "2"}, "<@U0AAA> set the priority to P2"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldPriority, OldValue: "2", NewValue: "1"}, "<@U0AAA> changed the priority from P2 to P1"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldPriority, OldValue: "1", NewValue: "0"}, "<@U0AAA> removed the priority"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldLabels, OldValue: "", NewValue: "infra q4"}, "<@U0AAA> labeled the task #infra #q4"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldLabels, OldValue: "infra q4", NewValue: "infra"}, "<@U0AAA> removed the labels #q4"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldLabels, OldValue: "infra q4", NewValue: "infra security"}, "<@U0AAA> labeled the task #security and removed #q4"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldDescription, OldValue: "", NewValue: "Steps in the wiki"}, "<@U0AAA> added a description"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldDescription, OldValue: "Steps in the wiki", NewValue: "See runbook"}, "<@U0AAA> changed the description"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldDescription, OldValue: "See runbook", NewValue: ""}, "<@U0AAA> removed the description"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldDue, OldValue: "", NewValue: "2026-11-02T17:00:00Z"}, "<@U0AAA> set the due date to <!date^1793638800^{date_short_pretty} {time}|Mon Nov 2 17:00 UTC>"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldDue, OldValue: "2026-11-02T17:00:00Z", NewValue: "2026-11-06T17:00:00Z"}, "<@U0AAA> changed the due date to <!date^1793984400^{date_short_pretty} {time}|Fri Nov 6 17:00 UTC>"},
+		{mysql.TaskEvent{ActorID: "U0AAA", Field: mysql.FieldDue, OldValue: "2026-11-06T17:00:00Z", NewValue: ""}, "<@U0AAA> removed the due date"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, describeEvent(&tc.event))
+	}
+}
+
+func TestCommandsPassActor(t *testing.T) {
+	mockHandler := newMockHandler()
+	repo := mockHandler.Repository.(*MockRepo)
+	_, err := mockHandler.HandleAddCommand(ctx, "MockTitle", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Equal(t, "U5", repo.persisted.CreatorID)
+	_, err = mockHandler.HandleDoneCommand(ctx, "1", "CH1", "U6")
+	assert.NoError(t, err)
+	assert.Equal(t, "U6", repo.actor)
+	_, err = mockHandler.HandleInteraction(ctx, newMockPayload(ActionStart, "1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "U7", repo.actor)
+}
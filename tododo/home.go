@@ -0,0 +1,221 @@
+package tododo
+
+import (
+	"context"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HomeTab is the tab of the App Home the home view is published to, sent in the app_home_opened event.
+const HomeTab = "home"
+
+// channelTasks is the tasks of a channel with the workflow of the channel.
+type channelTasks struct {
+	ChannelID string
+	Workflow  *mysql.Workflow
+	Tasks     []*mysql.Task
+}
+
+// stateTasks is the tasks in a state of a workflow.
+type stateTasks struct {
+	Status string
+	Tasks  []*mysql.Task
+}
+
+// PublishHome publishes the App Home tab of the user with userID with views.publish - the unfinished tasks assigned to the user in all channels,
+// grouped by channel and by state in the order of the workflow of the channel, refer to homeView. Does nothing without Slack.
+func (handler *CommandHandler) PublishHome(ctx context.Context, userID string) error {
+	if handler.Slack == nil {
+		return nil
+	}
+	tasks, err := handler.Repository.GetAllAssignedTo(ctx, userID)
+	if err != nil {
+		return err
+	}
+	loc, err := handler.userLocation(ctx, userID)
+	if err != nil {
+		return err
+	}
+	channels, err := handler.groupByChannel(ctx, tasks)
+	if err != nil {
+		return err
+	}
+	view := homeView(channels, loc, handler.now())
+	err = view.Validate()
+	if err != nil {
+		return err
+	}
+	return handler.Slack.PublishView(ctx, userID, view)
+}
+
+// groupByChannel splits tasks ordered by channel into the tasks of each channel, in the same order, with the workflow of the channel.
+func (handler *CommandHandler) groupByChannel(ctx context.Context, tasks []*mysql.Task) ([]*channelTasks, error) {
+	channels := make([]*channelTasks, 0)
+	for _, t := range tasks {
+		if len(channels) == 0 || channels[len(channels)-1].ChannelID != t.ChannelID {
+			workflow, err := handler.channelWorkflow(ctx, t.ChannelID)
+			if err != nil {
+				return nil, err
+			}
+			channels = append(channels, &channelTasks{ChannelID: t.ChannelID, Workflow: workflow})
+		}
+		group := channels[len(channels)-1]
+		group.Tasks = append(group.Tasks, t)
+	}
+	return channels, nil
+}
+
+// groupByState splits the unfinished tasks into the tasks of each state of workflow, in the order of the states.
+// States without tasks are left out. Tasks in a state which isn't in the workflow follow, by state in the order they first appear.
+func groupByState(workflow *mysql.Workflow, tasks []*mysql.Task) []*stateTasks {
+	groups := make([]*stateTasks, 0)
+	byStatus := make(map[string]*stateTasks)
+	for _, state := range workflow.States {
+		group := &stateTasks{Status: state.Name}
+		groups = append(groups, group)
+		byStatus[state.Name] = group
+	}
+	for _, t := range tasks {
+		if isTerminal(workflow, t.Status) {
+			continue
+		}
+		group, ok := byStatus[t.Status]
+		if !ok {
+			group = &stateTasks{Status: t.Status}
+			groups = append(groups, group)
+			byStatus[t.Status] = group
+		}
+		group.Tasks = append(group.Tasks, t)
+	}
+	nonEmpty := make([]*stateTasks, 0)
+	for _, group := range groups {
+		if len(group.Tasks) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
+
+// homeView constructs the home view with the unfinished tasks of channels, each task shown like in /tododo-show with the buttons of homeActionsBlock.
+// Every channel starts with a mention of it and every state with its emoji and name. Tasks which don't fit in MaxViewBlocks are left out
+// and their count is shown at the end.
+func homeView(channels []*channelTasks, loc *time.Location, now time.Time) *View {
+	blocks := []*Block{NewHeaderBlock(HomeHeader), NewContextBlock(&BlockText{Type: MarkdownType, Text: HomeIntroText})}
+	states := make([][]*stateTasks, len(channels))
+	total := 0
+	for i, channel := range channels {
+		states[i] = groupByState(channel.Workflow, channel.Tasks)
+		for _, state := range states[i] {
+			total += len(state.Tasks)
+		}
+	}
+	if total == 0 {
+		blocks = append(blocks, NewSectionTextBlock(PlainTextType, NoAssignedTasksText))
+		return NewHomeView(blocks...)
+	}
+	shown := 0
+	for i, channel := range channels {
+		for j, state := range states[i] {
+			for k, t := range state.Tasks {
+				// a task needs its section and its buttons, the first one of a state or a channel also their headings
+				needed := 2
+				if k == 0 {
+					needed++
+				}
+				if j == 0 && k == 0 {
+					needed += 2
+				}
+				// one block is kept for the count of the tasks left out
+				if len(blocks)+needed > MaxViewBlocks-1 {
+					more := fmt.Sprintf(MoreTasksText, total-shown)
+					return NewHomeView(append(blocks, NewContextBlock(&BlockText{Type: MarkdownType, Text: more}))...)
+				}
+				if j == 0 && k == 0 {
+					blocks = append(blocks, NewDividerBlock(), NewSectionTextBlock(MarkdownType, "*<#"+channel.ChannelID+">*"))
+				}
+				if k == 0 {
+					name := strings.TrimSpace(stateEmoji(channel.Workflow, state.Status) + " *" + getStatusName(state.Status) + "*")
+					blocks = append(blocks, NewContextBlock(&BlockText{Type: MarkdownType, Text: name}))
+				}
+				blocks = append(blocks, taskBlock(t, channel.Workflow, loc, now), homeActionsBlock(t, channel.Workflow))
+				shown++
+			}
+		}
+	}
+	return NewHomeView(blocks...)
+}
+
+// homeActionsBlock constructs the buttons under a task in the App Home tab - Start and Done like in /tododo-show, refer to taskActionsBlock,
+// and Edit opening the task modal. Home actions have no channel, so the value of the buttons is the channel and the number of the task.
+func homeActionsBlock(t *mysql.Task, workflow *mysql.Workflow) *Block {
+	number := strconv.Itoa(t.Number)
+	value := t.ChannelID + " " + number
+	buttons := make([]*BlockElement, 0)
+	start := startState(workflow)
+	if start != nil && t.Status == workflow.States[0].Name && canMove(workflow, t.Status, start.Name) {
+		buttons = append(buttons, NewButton(StartButtonText, ActionHomeStart, value))
+	}
+	done := doneState(workflow)
+	if !isTerminal(workflow, t.Status) && canMove(workflow, t.Status, done.Name) {
+		button := NewButton(DoneButtonText, ActionHomeDone, value)
+		button.Style = "primary"
+		buttons = append(buttons, button)
+	}
+	buttons = append(buttons, NewButton(EditButtonText, ActionHomeEdit, value))
+	return NewActionsBlock("task_"+t.ChannelID+"_"+number, buttons...)
+}
+
+// IsModalAction reports whether the interaction opens the task modal, a click on the Edit button of the App Home tab.
+// Trigger IDs expire 3 seconds after the click, so these interactions should be handled before the interaction is acknowledged.
+func IsModalAction(payload *InteractionPayload) bool {
+	return payload.Type == InteractionBlockActions && len(payload.Actions) == 1 && payload.Actions[0].ActionID == ActionHomeEdit
+}
+
+// handleHomeAction handles a click on the buttons of the App Home tab. Start and Done move the task like /tododo-start and /tododo-done
+// and publish the App Home tab again, Edit opens the task modal like /tododo-edit.
+// Home actions have no response_url, so denied changes, moves the workflow doesn't allow and deleted tasks are only logged.
+func (handler *CommandHandler) handleHomeAction(ctx context.Context, payload *InteractionPayload, action *InteractionAction) ([]byte, error) {
+	value := strings.Fields(action.Value)
+	if len(value) != 2 {
+		return nil, fmt.Errorf("Bad task %s", action.Value)
+	}
+	channelID := value[0]
+	number, err := strconv.Atoi(value[1])
+	if err != nil || number < 1 {
+		return nil, fmt.Errorf("Bad task number %s", action.Value)
+	}
+	userID := payload.User.ID
+	if action.ActionID == ActionHomeEdit {
+		resp, err := handler.OpenTaskModal(ctx, payload.TriggerID, channelID, userID, number)
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			log.Printf("[INFO] Can't open task %d in %s from the App Home of %s", number, channelID, userID)
+		}
+		return nil, nil
+	}
+	workflow, err := handler.channelWorkflow(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	state := doneState(workflow)
+	if action.ActionID == ActionHomeStart {
+		state = startState(workflow)
+	}
+	if state != nil {
+		err = handler.moveTask(ctx, workflow, channelID, number, state, userID)
+	}
+	if denied, ok := err.(*PermissionError); ok {
+		log.Printf("[INFO] Home action of %s ignored: %s", userID, denied.Reason)
+	} else if transition, ok := err.(*TransitionError); ok {
+		log.Printf("[INFO] Home action of %s ignored: %s", userID, transitionText(transition))
+	} else if err != nil && err != mysql.ErrNoRowOrMoreThanOne {
+		return nil, err
+	}
+	return nil, handler.PublishHome(ctx, userID)
+}
@@ -0,0 +1,187 @@
+package tododo
+
+import (
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newHomeOpened(userID string, tab string) *EventPayload {
+	return &EventPayload{Type: EventCallback, EventID: "Ev2", Event: &Event{Type: EventAppHomeOpened, User: userID, Tab: tab}}
+}
+
+func newHomeAction(actionID string, value string) *InteractionPayload {
+	return &InteractionPayload{
+		Type:      InteractionBlockActions,
+		TriggerID: "T123",
+		User:      InteractionUser{ID: "U1ABC"},
+		Actions:   []*InteractionAction{{ActionID: actionID, Value: value}},
+	}
+}
+
+func TestHandleAppHomeOpened(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	assert.NoError(t, mockHandler.HandleEvent(ctx, newHomeOpened("U1ABC", HomeTab)))
+	published := server.Published()
+	require.Len(t, published, 1)
+	assert.Equal(t, "U1ABC", published[0].UserID)
+	view := string(published[0].View)
+	assert.Contains(t, view, `"type":"home"`)
+	assert.NotContains(t, view, "MockDone")
+	assert.Contains(t, view, OverdueText)
+	order := []string{HomeHeader, "#CH1", StatusOpenText, "MockTitle", StatusInProgressText, "MockStarted", "#CH2", "MockOtherChannel"}
+	last := -1
+	for _, text := range order {
+		i := strings.Index(view, text)
+		assert.True(t, i > last, "%s out of order", text)
+		last = i
+	}
+
+	assert.NoError(t, mockHandler.HandleEvent(ctx, newHomeOpened("U1ABC", "messages")))
+	assert.Len(t, server.Published(), 1)
+}
+
+func TestHandleAppHomeOpenedNoTasks(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	assert.NoError(t, mockHandler.HandleEvent(ctx, newHomeOpened("U5", HomeTab)))
+	published := server.Published()
+	require.Len(t, published, 1)
+	assert.Contains(t, string(published[0].View), NoAssignedTasksText)
+}
+
+func TestHandleAppHomeOpenedNoSlack(t *testing.T) {
+	mockHandler := newMockHandler()
+	assert.NoError(t, mockHandler.HandleEvent(ctx, newHomeOpened("U1ABC", HomeTab)))
+}
+
+func TestHomeView(t *testing.T) {
+	mockHandler := newMockHandler()
+	tasks, err := mockHandler.Repository.GetAllAssignedTo(ctx, "U1ABC")
+	require.NoError(t, err)
+	channels, err := mockHandler.groupByChannel(ctx, tasks)
+	require.NoError(t, err)
+	require.Len(t, channels, 2)
+	assert.Equal(t, "CH1", channels[0].ChannelID)
+	assert.Len(t, channels[0].Tasks, 3)
+	view := homeView(channels, time.UTC, mockNow)
+	assert.NoError(t, view.Validate())
+	assert.Nil(t, view.Title)
+	types := make([]string, 0)
+	for _, block := range view.Blocks {
+		types = append(types, block.Type)
+	}
+	assert.Equal(t, []string{"header", "context",
+		"divider", "section", "context", "section", "actions", "context", "section", "actions",
+		"divider", "section", "context", "section", "actions"}, types)
+	assert.Equal(t, "*<#CH1>*", view.Blocks[3].BText.Text)
+	assert.Equal(t, StatusOpenEmoji+" *"+StatusOpenText+"*", view.Blocks[4].ContextTexts[0].Text)
+	assert.Equal(t, "task_CH1_1", view.Blocks[6].BlockID)
+}
+
+func TestHomeViewBlockLimit(t *testing.T) {
+	mockHandler := newMockHandler()
+	tasks, err := mockHandler.Repository.GetAllAssignedTo(ctx, "U9MANY")
+	require.NoError(t, err)
+	channels, err := mockHandler.groupByChannel(ctx, tasks)
+	require.NoError(t, err)
+	view := homeView(channels, time.UTC, mockNow)
+	assert.NoError(t, view.Validate())
+	shown := 0
+	for _, block := range view.Blocks {
+		if block.Type == "actions" {
+			shown++
+		}
+	}
+	last := view.Blocks[len(view.Blocks)-1]
+	assert.Equal(t, fmt.Sprintf(MoreTasksText, mockPagedTasks-shown), last.ContextTexts[0].Text)
+}
+
+func TestGroupByState(t *testing.T) {
+	workflow, err := ParseWorkflow(reviewWorkflow)
+	require.NoError(t, err)
+	tasks := []*mysql.Task{
+		{Number: 1, Status: "Blocked"},
+		{Number: 2, Status: "Open"},
+		{Number: 3, Status: "Done"},
+		{Number: 4, Status: "Legacy"},
+		{Number: 5, Status: "Blocked"},
+	}
+	groups := groupByState(workflow, tasks)
+	require.Len(t, groups, 3)
+	assert.Equal(t, "Open", groups[0].Status)
+	assert.Equal(t, "Blocked", groups[1].Status)
+	assert.Len(t, groups[1].Tasks, 2)
+	assert.Equal(t, "Legacy", groups[2].Status)
+}
+
+func TestHomeActionsBlock(t *testing.T) {
+	block := homeActionsBlock(&mysql.Task{Number: 1, Status: mysql.StatusOpen, ChannelID: "CH1"}, DefaultWorkflow())
+	assert.Equal(t, "task_CH1_1", block.BlockID)
+	require.Len(t, block.Elements, 3)
+	for i, actionID := range []string{ActionHomeStart, ActionHomeDone, ActionHomeEdit} {
+		assert.Equal(t, actionID, block.Elements[i].ActionID)
+		assert.Equal(t, "CH1 1", block.Elements[i].Value)
+	}
+	block = homeActionsBlock(&mysql.Task{Number: 2, Status: mysql.StatusInProgress, ChannelID: "CH2"}, DefaultWorkflow())
+	require.Len(t, block.Elements, 2)
+	assert.Equal(t, ActionHomeDone, block.Elements[0].ActionID)
+}
+
+func TestHandleHomeDone(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	result, err := mockHandler.HandleInteraction(ctx, newHomeAction(ActionHomeDone, "CH1 1"))
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	repo := mockHandler.Repository.(*MockRepo)
+	assert.Equal(t, mysql.StatusDone, repo.status)
+	assert.Equal(t, "U1ABC", repo.actor)
+	published := server.Published()
+	require.Len(t, published, 1)
+	assert.Equal(t, "U1ABC", published[0].UserID)
+}
+
+func TestHandleHomeStartDeletedTask(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	result, err := mockHandler.HandleInteraction(ctx, newHomeAction(ActionHomeStart, "CH2 9"))
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, "", mockHandler.Repository.(*MockRepo).status)
+	assert.Len(t, server.Published(), 1)
+}
+
+func TestHandleHomeDoneNotAllowed(t *testing.T) {
+	mockHandler, repo := newReviewHandler(t)
+	result, err := mockHandler.HandleInteraction(ctx, newHomeAction(ActionHomeDone, "CH1 1"))
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, "", repo.status)
+}
+
+func TestHandleHomeEdit(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	result, err := mockHandler.HandleInteraction(ctx, newHomeAction(ActionHomeEdit, "CH1 1"))
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	views := server.Views()
+	require.Len(t, views, 1)
+	assert.Equal(t, "T123", views[0].TriggerID)
+	assert.Equal(t, "CH1 1", openedModal(t, views[0].View).PrivateMetadata)
+	assert.Empty(t, server.Published())
+}
+
+func TestHandleHomeActionBadValue(t *testing.T) {
+	mockHandler := newMockHandler()
+	for _, value := range []string{"1", "CH1 x", "CH1 0"} {
+		_, err := mockHandler.HandleInteraction(ctx, newHomeAction(ActionHomeDone, value))
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,224 @@
+package tododo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Interaction payload types handled by the bot.
+const (
+	InteractionBlockActions   = "block_actions"
+	InteractionMessageAction  = "message_action"
+	InteractionViewSubmission = "view_submission"
+)
+
+// InteractionPayload is the part of Slack interaction payload used by the bot.
+// Refer to https://api.slack.com/reference/interaction-payloads/block-actions for details.
+// CallbackID, Team and Message are set for message shortcuts, refer to https://api.slack.com/reference/interaction-payloads/shortcuts.
+// View is set for submissions of modals, refer to https://api.slack.com/reference/interaction-payloads/views.
+type InteractionPayload struct {
+	Type        string               `json:"type"`
+	CallbackID  string               `json:"callback_id"`
+	TriggerID   string               `json:"trigger_id"`
+	ResponseURL string               `json:"response_url"`
+	Team        InteractionTeam      `json:"team"`
+	User        InteractionUser      `json:"user"`
+	Channel     InteractionChannel   `json:"channel"`
+	Message     *InteractionMessage  `json:"message"`
+	Actions     []*InteractionAction `json:"actions"`
+	View        *InteractionView     `json:"view"`
+}
+
+// InteractionTeam is the workspace of the interaction. Domain is the subdomain of its url, e.g. acme for acme.slack.com.
+type InteractionTeam struct {
+	ID     string `json:"id"`
+	Domain string `json:"domain"`
+}
+
+// InteractionUser is the user who clicked the element.
+type InteractionUser struct {
+	ID   string `json:"id"`
+	Name string `json:"username"`
+}
+
+// InteractionChannel is the channel of the message with the element.
+type InteractionChannel struct {
+	ID string `json:"id"`
+}
+
+// InteractionMessage is the message a shortcut was used on. ThreadTS is set for messages in threads.
+type InteractionMessage struct {
+	Text     string `json:"text"`
+	User     string `json:"user"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts"`
+}
+
+// InteractionView is the submitted modal. State has the values of its inputs by block id and action id.
+type InteractionView struct {
+	ID              string    `json:"id"`
+	CallbackID      string    `json:"callback_id"`
+	PrivateMetadata string    `json:"private_metadata"`
+	State           ViewState `json:"state"`
+}
+
+// ViewState is the state of the inputs of a modal.
+type ViewState struct {
+	Values map[string]map[string]*ViewValue `json:"values"`
+}
+
+// ViewValue is the value of an input of a modal - Value of plain_text_input, SelectedUser of users_select,
+// SelectedDate in format YYYY-MM-DD of datepicker or SelectedOption of static_select. Empty inputs have no value.
+type ViewValue struct {
+	Type           string  `json:"type"`
+	Value          string  `json:"value"`
+	SelectedUser   string  `json:"selected_user"`
+	SelectedDate   string  `json:"selected_date"`
+	SelectedOption *Option `json:"selected_option"`
+}
+
+// InteractionAction is a click on an interactive element. Value is the value of the button.
+type InteractionAction struct {
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+	Value    string `json:"value"`
+}
+
+// ParseInteractionPayload reads the url encoded "payload" field of an interaction request.
+func ParseInteractionPayload(r *http.Request) (*InteractionPayload, error) {
+	err := r.ParseForm()
+	if err != nil {
+		return nil, err
+	}
+	var payload InteractionPayload
+	err = json.Unmarshal([]byte(r.PostForm.Get("payload")), &payload)
+	if err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// HandleInteraction handles a click on the buttons of /tododo-show - Start, Done, Assign to me, Previous and Next page, the message shortcut Add to ToDo,
+// refer to HandleAddFromMessage, and the buttons of the App Home tab, refer to handleHomeAction.
+// Start and Done move the task like /tododo-start and /tododo-done.
+// Returns the updated task list, filtered by the query of the original message, to replace it through response_url.
+// A denied change or a move the workflow doesn't allow is shown in a new message visible only to the user and the list is left as it is.
+func (handler *CommandHandler) HandleInteraction(ctx context.Context, payload *InteractionPayload) ([]byte, error) {
+	if payload.Type == InteractionMessageAction && payload.CallbackID == ShortcutAddToDo {
+		return handler.HandleAddFromMessage(ctx, payload)
+	}
+	if payload.Type != InteractionBlockActions || len(payload.Actions) != 1 {
+		return nil, fmt.Errorf("Can't handle interaction %s", payload.Type)
+	}
+	action := payload.Actions[0]
+	if action.ActionID == ActionHomeStart || action.ActionID == ActionHomeDone || action.ActionID == ActionHomeEdit {
+		return handler.handleHomeAction(ctx, payload, action)
+	}
+	if action.ActionID == ActionPreviousPage || action.ActionID == ActionNextPage {
+		return handler.replaceShowResponse(ctx, action.Value, payload)
+	}
+	value := strings.SplitN(action.Value, " ", 2)
+	query := ""
+	if len(value) == 2 {
+		query = value[1]
+	}
+	number, err := strconv.Atoi(value[0])
+	if err != nil || number < 1 {
+		return nil, fmt.Errorf("Bad task number %s", action.Value)
+	}
+	switch action.ActionID {
+	case ActionStart, ActionDone:
+		var workflow *mysql.Workflow
+		workflow, err = handler.channelWorkflow(ctx, payload.Channel.ID)
+		if err != nil {
+			return nil, err
+		}
+		state := doneState(workflow)
+		if action.ActionID == ActionStart {
+			state = startState(workflow)
+		}
+		if state == nil {
+			return textResponse(UpdateHeader, PlainTextType, NoStartStateText)
+		}
+		err = handler.moveTask(ctx, workflow, payload.Channel.ID, number, state, payload.User.ID)
+	case ActionAssignMe:
+		err = handler.Repository.AssignTaskTo(ctx, payload.Channel.ID, number, payload.User.ID, payload.User.ID)
+	default:
+		return nil, fmt.Errorf("Can't handle action %s", action.ActionID)
+	}
+	if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	} else if transition, ok := err.(*TransitionError); ok {
+		return textResponse(UpdateHeader, PlainTextType, transitionText(transition))
+	} else if err != nil && err != mysql.ErrNoRowOrMoreThanOne {
+		return nil, err
+	}
+	return handler.replaceShowResponse(ctx, query, payload)
+}
+
+// replaceShowResponse constructs the task list filtered by query to replace the message with the clicked button.
+func (handler *CommandHandler) replaceShowResponse(ctx context.Context, query string, payload *InteractionPayload) ([]byte, error) {
+	var resp *Response
+	workflow, err := handler.channelWorkflow(ctx, payload.Channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := ParseShowFilter(query, payload.Channel.ID, payload.User.ID, handler.now(), workflow)
+	if err != nil {
+		resp = newTextResponse(ShowHeader, PlainTextType, err.Error()+". "+ShowBadArgsText)
+	} else {
+		resp, err = handler.showResponse(ctx, query, filter, payload.User.ID, workflow)
+		if err != nil {
+			return nil, err
+		}
+	}
+	resp.ResponseType = ""
+	resp.ReplaceOriginal = true
+	byt, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return byt, nil
+}
+
+// taskActionsBlock constructs the buttons under a task in /tododo-show. Start is shown for tasks in the state of new tasks, Done for unfinished tasks,
+// each of them only if the workflow allows the move, refer to startState and doneState.
+// The value of the buttons is the task number followed by the query of /tododo-show, so the list is refreshed with the same filter.
+func taskActionsBlock(t *mysql.Task, query string, workflow *mysql.Workflow) *Block {
+	number := strconv.Itoa(t.Number)
+	value := number
+	if query != "" {
+		value += " " + query
+	}
+	buttons := make([]*BlockElement, 0)
+	start := startState(workflow)
+	if start != nil && t.Status == workflow.States[0].Name && canMove(workflow, t.Status, start.Name) {
+		buttons = append(buttons, NewButton(StartButtonText, ActionStart, value))
+	}
+	done := doneState(workflow)
+	if !isTerminal(workflow, t.Status) && canMove(workflow, t.Status, done.Name) {
+		button := NewButton(DoneButtonText, ActionDone, value)
+		button.Style = "primary"
+		buttons = append(buttons, button)
+	}
+	buttons = append(buttons, NewButton(AssignMeButtonText, ActionAssignMe, value))
+	return NewActionsBlock("task_"+number, buttons...)
+}
+
+// pageActionsBlock constructs the buttons to the previous and the next page of /tododo-show.
+// The value of the buttons is the query of the page.
+func pageActionsBlock(query string, page int, hasNext bool) *Block {
+	buttons := make([]*BlockElement, 0)
+	if page > 1 {
+		buttons = append(buttons, NewButton(PreviousButtonText, ActionPreviousPage, pageQuery(query, page-1)))
+	}
+	if hasNext {
+		buttons = append(buttons, NewButton(NextButtonText, ActionNextPage, pageQuery(query, page+1)))
+	}
+	return NewActionsBlock("pages", buttons...)
+}
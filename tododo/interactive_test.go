@@ -0,0 +1,100 @@
+package tododo
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newMockPayload(actionID string, value string) *InteractionPayload {
+	return &InteractionPayload{
+		Type:        InteractionBlockActions,
+		ResponseURL: "https://hooks.slack.com/actions/T1/1/x",
+		User:        InteractionUser{ID: "U7"},
+		Channel:     InteractionChannel{ID: "CH1"},
+		Actions:     []*InteractionAction{{ActionID: actionID, BlockID: "task_" + value, Value: value}},
+	}
+}
+
+func TestParseInteractionPayload(t *testing.T) {
+	payload := `{"type":"block_actions","user":{"id":"U7","username":"hb"},"channel":{"id":"CH1"},"response_url":"https://hooks.slack.com/actions/T1/1/x","actions":[{"action_id":"tododo_done","block_id":"task_1","value":"1"}]}`
+	form := url.Values{"payload": {payload}}
+	req := httptest.NewRequest(http.MethodPost, "/tododo/interactive", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := ParseInteractionPayload(req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, newMockPayload(ActionDone, "1").Actions, res.Actions)
+		assert.Equal(t, "U7", res.User.ID)
+		assert.Equal(t, "CH1", res.Channel.ID)
+		assert.Equal(t, "https://hooks.slack.com/actions/T1/1/x", res.ResponseURL)
+	}
+}
+
+func TestParseInteractionPayloadBadJSON(t *testing.T) {
+	form := url.Values{"payload": {"{"}}
+	req := httptest.NewRequest(http.MethodPost, "/tododo/interactive", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err := ParseInteractionPayload(req)
+	assert.Error(t, err)
+}
+
+func TestHandleInteraction(t *testing.T) {
+	mockHandler := newMockHandler()
+	for _, actionID := range []string{ActionStart, ActionDone, ActionAssignMe} {
+		result, err := mockHandler.HandleInteraction(ctx, newMockPayload(actionID, "1"))
+		stringRes := string(result)
+		assert.NoError(t, err)
+		assert.Contains(t, stringRes, `"replace_original":true`)
+		assert.NotContains(t, stringRes, "response_type")
+		assert.Contains(t, stringRes, ShowHeader)
+		assert.Contains(t, stringRes, "MockTitle")
+	}
+}
+
+func TestHandleInteractionKeepsFilter(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleInteraction(ctx, newMockPayload(ActionAssignMe, "1 mine sort:due"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), `"value":"1 mine sort:due"`)
+	filter := mockHandler.Repository.(*MockRepo).filter
+	assert.Equal(t, "U7", filter.AsigneeID)
+	assert.Equal(t, mysql.SortDue, filter.Sort)
+}
+
+func TestHandleInteractionPage(t *testing.T) {
+	mockHandler := newMockHandler()
+	payload := newMockPayload(ActionNextPage, "sort:due page 2")
+	payload.Channel.ID = "CH4"
+	result, err := mockHandler.HandleInteraction(ctx, payload)
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, `"replace_original":true`)
+	assert.Contains(t, stringRes, "MockPaged21")
+	assert.Contains(t, stringRes, `"value":"21 sort:due page 2"`)
+	assert.Contains(t, stringRes, `"value":"sort:due page 1"`)
+	filter := mockHandler.Repository.(*MockRepo).filter
+	assert.Equal(t, ShowPageSize, filter.Offset)
+}
+
+func TestHandleInteractionNoSuchTask(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleInteraction(ctx, newMockPayload(ActionDone, "2"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), ShowHeader)
+}
+
+func TestHandleInteractionBadAction(t *testing.T) {
+	mockHandler := newMockHandler()
+	_, err := mockHandler.HandleInteraction(ctx, newMockPayload("unknown", "1"))
+	assert.Error(t, err)
+	_, err = mockHandler.HandleInteraction(ctx, newMockPayload(ActionDone, "one"))
+	assert.Error(t, err)
+	payload := newMockPayload(ActionDone, "1")
+	payload.Type = "view_submission"
+	_, err = mockHandler.HandleInteraction(ctx, payload)
+	assert.Error(t, err)
+}
@@ -0,0 +1,178 @@
+package tododo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of the labels of a task, so the labels of a task fit in the history of its changes.
+const (
+	MaxLabelLength = 24
+	MaxTaskLabels  = 10
+)
+
+var (
+	labelRegexp          = regexp.MustCompile(`^\p{L}[\p{L}\p{N}_-]*$`)
+	channelMentionRegexp = regexp.MustCompile(`^<#C[A-Z0-9]+\|([^<>|]+)>$`)
+)
+
+// ParseLabels splits the text of /tododo-add into title and labels.
+// Every word # followed by a label - "Update TLS certs #infra #q4" is a label, the words are removed from the title.
+// Labels are lowercase, start with a letter and contain letters, digits, _ and -, so "Fix issue #123" keeps #123 in the title.
+// Returns the whole text as title and no labels if there are none.
+func ParseLabels(text string) (string, []string) {
+	words := strings.Fields(text)
+	title := make([]string, 0)
+	labels := make([]string, 0)
+	for _, word := range words {
+		if strings.HasPrefix(word, "#") {
+			label, ok := parseLabel(word)
+			if ok {
+				labels = append(labels, label)
+				continue
+			}
+		}
+		title = append(title, word)
+	}
+	if len(labels) == 0 {
+		return text, nil
+	}
+	return strings.Join(title, " "), labels
+}
+
+// parseLabel parses a label with an optional # in front, case insensitive - #infra, Infra.
+// A channel mention <#C123ABC|infra> is parsed as the name of the channel, Slack sends #infra so when a channel with this name exists.
+// Returns false if text is not a label.
+func parseLabel(text string) (string, bool) {
+	match := channelMentionRegexp.FindStringSubmatch(text)
+	if match != nil {
+		text = match[1]
+	}
+	label := strings.ToLower(strings.TrimPrefix(text, "#"))
+	if !labelRegexp.MatchString(label) {
+		return "", false
+	}
+	return label, true
+}
+
+// ValidateLabels returns error if a label is longer than MaxLabelLength or there are more than MaxTaskLabels labels.
+func ValidateLabels(labels []string) error {
+	for _, label := range labels {
+		if utf8.RuneCountInString(label) > MaxLabelLength {
+			return fmt.Errorf("Label #%s is longer than %d characters", label, MaxLabelLength)
+		}
+	}
+	if len(mysql.ApplyLabels(nil, labels, nil)) > MaxTaskLabels {
+		return fmt.Errorf("A task can have at most %d labels", MaxTaskLabels)
+	}
+	return nil
+}
+
+// HandleTagCommand handles /tododo-tag and returns proper response or error.
+// The text is the task number followed by labels to add with + and to remove with -, e.g. "12 +security -q4".
+func (handler *CommandHandler) HandleTagCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	id, added, removed, ok := parseTagText(text)
+	if !ok {
+		return textResponse(UpdateHeader, PlainTextType, TagBadArgsText)
+	}
+	task, err := handler.Repository.GetTask(ctx, channelID, id)
+	if err == sql.ErrNoRows || (err == nil && task.Deleted) {
+		return textResponse(UpdateHeader, PlainTextType, NoSuchTaskIDText)
+	} else if err != nil {
+		return nil, err
+	}
+	err = ValidateLabels(mysql.ApplyLabels(task.Labels, added, removed))
+	if err != nil {
+		return textResponse(UpdateHeader, PlainTextType, err.Error())
+	}
+	err = handler.Repository.UpdateLabels(ctx, channelID, id, added, removed, userID)
+	if err == mysql.ErrNoRowOrMoreThanOne {
+		return textResponse(UpdateHeader, PlainTextType, NoSuchTaskIDText)
+	} else if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	} else if err != nil {
+		return nil, err
+	}
+	task, err = handler.Repository.GetTask(ctx, channelID, id)
+	if err != nil {
+		return nil, err
+	}
+	return handler.respondTask(ctx, newTextResponse(UpdateHeader, MarkdownType, "*Labels*: "+task.Title+" - "+formatLabels(task.Labels)), channelID, id)
+}
+
+// HandleLabelsCommand handles /tododo-labels and returns the labels used in the channel with the number of tasks having each of them.
+func (handler *CommandHandler) HandleLabelsCommand(ctx context.Context, channelID string) ([]byte, error) {
+	counts, err := handler.Repository.GetLabelCounts(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return handler.respond(ctx, newTextResponse(LabelsHeader, PlainTextType, NoLabelsText), channelID, ResponseEphemeral)
+	}
+	lines := make([]string, 0)
+	for _, count := range counts {
+		tasks := " tasks"
+		if count.Count == 1 {
+			tasks = " task"
+		}
+		lines = append(lines, "*#"+count.Label+"* - "+strconv.Itoa(count.Count)+tasks)
+	}
+	header := NewHeaderBlock(LabelsHeader)
+	div := NewDividerBlock()
+	list := NewSectionTextBlock(MarkdownType, strings.Join(lines, "\n"))
+	hint := NewContextBlock(&BlockText{Type: MarkdownType, Text: LabelsHintText})
+	return handler.respond(ctx, NewResponse(header, div, list, hint), channelID, ResponseEphemeral)
+}
+
+// ValidateTagText validates the args of /tododo-tag are a positive integer followed by at least one label with + or - in front. Return true if the text is valid.
+func ValidateTagText(text string) bool {
+	_, _, _, ok := parseTagText(text)
+	return ok
+}
+
+// parseTagText parses the args of /tododo-tag into the task number, the labels to add and the labels to remove.
+func parseTagText(text string) (int, []string, []string, bool) {
+	args := strings.Fields(text)
+	if len(args) < 2 {
+		return 0, nil, nil, false
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 1 {
+		return 0, nil, nil, false
+	}
+	added := make([]string, 0)
+	removed := make([]string, 0)
+	for _, arg := range args[1:] {
+		if len(arg) < 2 || (arg[0] != '+' && arg[0] != '-') {
+			return 0, nil, nil, false
+		}
+		label, ok := parseLabel(arg[1:])
+		if !ok {
+			return 0, nil, nil, false
+		}
+		if arg[0] == '+' {
+			added = append(added, label)
+		} else {
+			removed = append(removed, label)
+		}
+	}
+	return id, added, removed, true
+}
+
+// formatLabels renders the labels as hashtags separated by spaces - "#infra #q4", or NoLabelsText.
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return NoLabelsText
+	}
+	tags := make([]string, 0)
+	for _, label := range labels {
+		tags = append(tags, "#"+label)
+	}
+	return strings.Join(tags, " ")
+}
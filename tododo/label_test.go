@@ -0,0 +1,103 @@
+package tododo
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		text   string
+		title  string
+		labels []string
+	}{
+		{"Update TLS certs #infra #q4", "Update TLS certs", []string{"infra", "q4"}},
+		{"#Infra Update TLS certs due friday", "Update TLS certs due friday", []string{"infra"}},
+		{"Fix issue #123", "Fix issue #123", nil},
+		{"Fix # and #-dash", "Fix # and #-dash", nil},
+		{"Update TLS certs", "Update TLS certs", nil},
+	}
+	for _, tc := range tests {
+		title, labels := ParseLabels(tc.text)
+		assert.Equal(t, tc.title, title, tc.text)
+		assert.Equal(t, tc.labels, labels, tc.text)
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	assert.NoError(t, ValidateLabels([]string{"infra", "q4"}))
+	assert.EqualError(t, ValidateLabels([]string{strings.Repeat("a", MaxLabelLength+1)}), "Label #"+strings.Repeat("a", MaxLabelLength+1)+" is longer than 24 characters")
+	many := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+	assert.EqualError(t, ValidateLabels(many), "A task can have at most 10 labels")
+	assert.NoError(t, ValidateLabels(append(many[:10], "a")))
+}
+
+func TestValidateTagText(t *testing.T) {
+	assert.True(t, ValidateTagText("12 +security -q4"))
+	assert.True(t, ValidateTagText("1 +#infra"))
+	assert.True(t, ValidateTagText("1 -<#C1ABC|infra>"))
+	assert.False(t, ValidateTagText("1"))
+	assert.False(t, ValidateTagText("0 +infra"))
+	assert.False(t, ValidateTagText("1 infra"))
+	assert.False(t, ValidateTagText("1 +"))
+	assert.False(t, ValidateTagText("1 +123"))
+}
+
+func TestHandleAddCommandLabels(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleAddCommand(ctx, "Update TLS certs #infra #q4", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "*Labels*: #infra #q4")
+	persisted := mockHandler.Repository.(*MockRepo).persisted
+	assert.Equal(t, "Update TLS certs", persisted.Title)
+	assert.Equal(t, []string{"infra", "q4"}, persisted.Labels)
+	result, err = mockHandler.HandleAddCommand(ctx, "Update TLS certs #"+strings.Repeat("a", MaxLabelLength+1), "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "is longer than 24 characters")
+}
+
+func TestHandleTagCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleTagCommand(ctx, "1 +security -q4", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "*Labels*: MockTitle")
+	assert.Contains(t, string(result), `"response_type":"in_channel"`)
+	assert.Equal(t, "U5", mockHandler.Repository.(*MockRepo).actor)
+	result, err = mockHandler.HandleTagCommand(ctx, "2 +security", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoSuchTaskIDText)
+	result, err = mockHandler.HandleTagCommand(ctx, "1 security", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), TagBadArgsText)
+	result, err = mockHandler.HandleTagCommand(ctx, "1 +a +b +c +d +e +f +g +h +i +j +k", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "A task can have at most 10 labels")
+}
+
+func TestHandleTagCommandDenied(t *testing.T) {
+	handler, repo := newPolicyHandler(mysql.PolicyAdmins)
+	result, err := handler.HandleTagCommand(ctx, "1 +security", "CH1", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), DeniedAdminsText)
+	assert.Equal(t, "", repo.actor)
+}
+
+func TestHandleLabelsCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleLabelsCommand(ctx, "CH1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, LabelsHeader)
+	assert.Contains(t, stringRes, `*#infra* - 2 tasks\n*#q4* - 1 task`)
+	assert.Contains(t, stringRes, `"response_type":"ephemeral"`)
+	result, err = mockHandler.HandleLabelsCommand(ctx, "CH3")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoLabelsText)
+}
+
+func TestFormatLabels(t *testing.T) {
+	assert.Equal(t, "#infra #q4", formatLabels([]string{"infra", "q4"}))
+	assert.Equal(t, NoLabelsText, formatLabels(nil))
+}
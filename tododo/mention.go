@@ -0,0 +1,33 @@
+package tododo
+
+import (
+	"regexp"
+)
+
+var (
+	userMentionRegexp = regexp.MustCompile(`^<@([UW][A-Z0-9]{2,})(?:\|[^<>|]*)?>$`)
+	userIDRegexp      = regexp.MustCompile(`^[UW][A-Z0-9]{2,}$`)
+)
+
+// ParseUserMention returns the user ID of a user mention in the escaped format Slack sends to slash commands - <@U123ABC|bob> or <@U123ABC>.
+// Returns false if text is not a user mention, e.g. @bob, bob, a channel <#C123|general> or a special mention <!here>.
+// Slack escapes mentions only if "Escape channels, users, and links sent to your app" is enabled for the command.
+func ParseUserMention(text string) (string, bool) {
+	match := userMentionRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// FormatUserMention returns the mention of userID shown by Slack as a link to the user.
+// Empty userID is shown as not assigned, a value that is not a user ID is shown as it is.
+func FormatUserMention(userID string) string {
+	if userID == "" {
+		return NotAssignedText
+	}
+	if !userIDRegexp.MatchString(userID) {
+		return userID
+	}
+	return "<@" + userID + ">"
+}
@@ -0,0 +1,32 @@
+package tododo
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseUserMention(t *testing.T) {
+	byt, err := ioutil.ReadFile("testdata/mentions.json")
+	require.NoError(t, err)
+	var fixtures []struct {
+		Input  string `json:"input"`
+		UserID string `json:"userID"`
+		OK     bool   `json:"ok"`
+	}
+	require.NoError(t, json.Unmarshal(byt, &fixtures))
+	require.NotEmpty(t, fixtures)
+	for _, fixture := range fixtures {
+		userID, ok := ParseUserMention(fixture.Input)
+		assert.Equal(t, fixture.OK, ok, fixture.Input)
+		assert.Equal(t, fixture.UserID, userID, fixture.Input)
+	}
+}
+
+func TestFormatUserMention(t *testing.T) {
+	assert.Equal(t, "<@U123ABC>", FormatUserMention("U123ABC"))
+	assert.Equal(t, NotAssignedText, FormatUserMention(""))
+	assert.Equal(t, "@bob", FormatUserMention("@bob"))
+}
@@ -0,0 +1,106 @@
+package tododo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"strings"
+)
+
+// HandleMineCommand handles /tododo-mine and returns proper response or error.
+// Lists the tasks assigned to the user in all channels, grouped by channel and shown like in /tododo-show with their state, priority and due date.
+// The optional status is one of the statuses of /tododo-show, refer to ParseShowFilter, applied to the workflow of each channel;
+// unfinished tasks are shown if it is omitted. Channels without the state of state:name have no tasks in the list.
+// Tasks which don't fit in MaxBlocks are left out and their count is shown at the end.
+// The list has tasks of other channels, so it is visible only to the user whatever the visibility of the channel.
+func (handler *CommandHandler) HandleMineCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if !ValidateMineText(text) {
+		return textResponse(MineHeader, PlainTextType, MineBadArgsText)
+	}
+	tasks, err := handler.Repository.GetAllAssignedTo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := handler.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	channels, err := handler.groupByChannel(ctx, tasks)
+	if err != nil {
+		return nil, err
+	}
+	now := handler.now()
+	total := 0
+	for _, channel := range channels {
+		filter, err := ParseShowFilter(text, channel.ChannelID, userID, now, channel.Workflow)
+		if err != nil {
+			channel.Tasks = nil
+			continue
+		}
+		channel.Tasks = filterStatuses(channel.Tasks, filter.Statuses)
+		total += len(channel.Tasks)
+	}
+	blocks := []*Block{NewHeaderBlock(MineHeader), NewDividerBlock()}
+	shown := 0
+	for _, channel := range channels {
+		for i, t := range channel.Tasks {
+			needed := 1
+			if i == 0 {
+				needed++
+			}
+			// one block is kept for the count of the tasks left out
+			if len(blocks)+needed > MaxBlocks-1 {
+				break
+			}
+			if i == 0 {
+				blocks = append(blocks, NewSectionTextBlock(MarkdownType, "*<#"+channel.ChannelID+">*"))
+			}
+			blocks = append(blocks, taskBlock(t, channel.Workflow, loc, now))
+			shown++
+		}
+	}
+	if total == 0 {
+		blocks = append(blocks, NewSectionTextBlock(PlainTextType, NoTasksText))
+	} else if shown < total {
+		blocks = append(blocks, NewContextBlock(&BlockText{Type: MarkdownType, Text: fmt.Sprintf(MineMoreTasksText, total-shown)}))
+	}
+	resp := NewResponse(blocks...)
+	err = resp.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(resp)
+}
+
+// ValidateMineText validates the args of /tododo-mine are empty or one status - open, started, done, all or state:name. Return true if the text is valid.
+func ValidateMineText(text string) bool {
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) == 0 {
+		return true
+	}
+	if len(words) > 1 {
+		return false
+	}
+	switch word := words[0]; {
+	case word == "open" || word == "started" || word == "progress" || word == "in-progress" || word == "done" || word == "all":
+		return true
+	case strings.HasPrefix(word, "state:"):
+		return len(word) > len("state:")
+	}
+	return false
+}
+
+// filterStatuses returns the tasks in one of statuses, in the same order. All tasks are returned if statuses is empty.
+func filterStatuses(tasks []*mysql.Task, statuses []string) []*mysql.Task {
+	if len(statuses) == 0 {
+		return tasks
+	}
+	filtered := make([]*mysql.Task, 0)
+	for _, t := range tasks {
+		if containsString(statuses, t.Status) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,111 @@
+package tododo
+
+import (
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestHandleMineCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleCommand(ctx, &slack.SlashCommand{Command: "/tododo-mine", ChannelID: "CH3", UserID: "U1ABC"})
+	assert.NoError(t, err)
+	stringRes := string(result)
+	assert.Contains(t, stringRes, MineHeader)
+	assert.Contains(t, stringRes, `"response_type":"ephemeral"`)
+	assert.NotContains(t, stringRes, "MockDone")
+	assert.Contains(t, stringRes, OverdueText)
+	assert.Contains(t, stringRes, StatusInProgressText)
+	order := []string{"#CH1", "MockTitle", "MockStarted", "#CH2", "MockOtherChannel"}
+	last := -1
+	for _, text := range order {
+		i := strings.Index(stringRes, text)
+		assert.True(t, i > last, "%s out of order", text)
+		last = i
+	}
+}
+
+func TestHandleMineCommandPublicChannel(t *testing.T) {
+	mockHandler := newMockHandler()
+	mockHandler.Channels.SetVisibility(ctx, "CH3", mysql.VisibilityPublic)
+	result, err := mockHandler.HandleMineCommand(ctx, "", "CH3", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), `"response_type":"ephemeral"`)
+	assert.NotContains(t, string(result), ResponseInChannel)
+}
+
+func TestHandleMineCommandStatus(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleMineCommand(ctx, "done", "CH3", "U1ABC")
+	assert.NoError(t, err)
+	stringRes := string(result)
+	assert.Contains(t, stringRes, "MockDone")
+	assert.NotContains(t, stringRes, "MockTitle")
+	assert.NotContains(t, stringRes, "#CH2")
+
+	result, err = mockHandler.HandleMineCommand(ctx, "all", "CH3", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "MockDone")
+	assert.Contains(t, string(result), "MockOtherChannel")
+}
+
+func TestHandleMineCommandWorkflowState(t *testing.T) {
+	mockHandler, _ := newReviewHandler(t)
+	result, err := mockHandler.HandleMineCommand(ctx, "state:blocked", "CH3", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoTasksText)
+
+	result, err = mockHandler.HandleMineCommand(ctx, "open", "CH3", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "MockTitle")
+	assert.Contains(t, string(result), "MockOtherChannel")
+	assert.NotContains(t, string(result), "MockStarted")
+}
+
+func TestHandleMineCommandNoTasks(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleMineCommand(ctx, "", "CH3", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoTasksText)
+}
+
+func TestHandleMineCommandBlockLimit(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleMineCommand(ctx, "", "CH3", "U9MANY")
+	assert.NoError(t, err)
+	stringRes := string(result)
+	assert.Contains(t, stringRes, "MockPaged42")
+	assert.NotContains(t, stringRes, "MockPaged43")
+	assert.Contains(t, stringRes, fmt.Sprintf(MineMoreTasksText, 3))
+}
+
+func TestHandleMineCommandBadArgs(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleMineCommand(ctx, "sort:due", "CH3", "U1ABC")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), MineBadArgsText)
+}
+
+func TestValidateMineText(t *testing.T) {
+	tests := []struct {
+		text  string
+		valid bool
+	}{
+		{"", true},
+		{"open", true},
+		{" Started ", true},
+		{"done", true},
+		{"all", true},
+		{"state:in-review", true},
+		{"state:", false},
+		{"mine", false},
+		{"open done", false},
+		{"#infra", false},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.valid, ValidateMineText(tc.text), tc.text)
+	}
+}
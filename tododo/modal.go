@@ -0,0 +1,262 @@
+package tododo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Response actions of an answer to a view_submission. Refer to https://api.slack.com/surfaces/modals#responses for details.
+const (
+	ResponseActionErrors = "errors"
+	ResponseActionUpdate = "update"
+)
+
+// modalDateLayout is the format of the dates of datepicker elements
+const modalDateLayout = "2006-01-02"
+
+// ViewResponse answers a view_submission. ResponseActionErrors keeps the modal open and shows Errors, messages by block id, under the inputs.
+// ResponseActionUpdate replaces the modal with View.
+type ViewResponse struct {
+	ResponseAction string            `json:"response_action"`
+	Errors         map[string]string `json:"errors,omitempty"`
+	View           *View             `json:"view,omitempty"`
+}
+
+// OpenTaskModal opens the task modal with views.open for /tododo-add without text if number is 0,
+// otherwise for /tododo-edit with only a task ID, pre-filled with the task with this number in the channel.
+// The modal is submitted to HandleViewSubmission. Returns nil after the modal is opened, there is nothing to send to response_url.
+// Without Slack or a trigger ID the modal can't be opened and the usage of the command is returned.
+func (handler *CommandHandler) OpenTaskModal(ctx context.Context, triggerID string, channelID string, userID string, number int) ([]byte, error) {
+	header, usage := AddHeader, AddBadArgsText
+	if number != 0 {
+		header, usage = UpdateHeader, EditBadArgsText
+	}
+	if handler.Slack == nil || triggerID == "" {
+		return textResponse(header, PlainTextType, usage)
+	}
+	loc, err := handler.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	task := mysql.NewTask("", channelID, userID)
+	if number != 0 {
+		task, err = handler.Repository.GetTask(ctx, channelID, number)
+		if err == sql.ErrNoRows || (err == nil && task.Deleted) {
+			return textResponse(header, PlainTextType, NoSuchTaskIDText)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	view := taskModal(task, loc)
+	err = view.Validate()
+	if err != nil {
+		return nil, err
+	}
+	_, err = handler.Slack.OpenView(ctx, triggerID, view)
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// taskModal constructs the modal adding the task if it has no number, otherwise editing it. The due date is shown in loc.
+// The channel and the number of the task are kept in the private metadata of the modal.
+func taskModal(task *mysql.Task, loc *time.Location) *View {
+	title, submit := AddModalTitle, AddModalSubmitText
+	if task.Number != 0 {
+		title, submit = EditModalTitle, EditModalSubmitText
+	}
+	due := ""
+	if task.DueDate != nil {
+		due = task.DueDate.In(loc).Format(modalDateLayout)
+	}
+	options := []*Option{NewOption(NoPriorityText, strconv.Itoa(mysql.PriorityNone))}
+	var priority *Option
+	for p := mysql.PriorityP1; p <= mysql.PriorityP4; p++ {
+		options = append(options, NewOption(getPriorityName(p), strconv.Itoa(p)))
+		if p == task.Priority {
+			priority = options[len(options)-1]
+		}
+	}
+	view := NewModal(CallbackTaskModal, title, submit,
+		NewInputBlock(InputTitle, TitleLabel, false, NewPlainTextInput(InputTitle, task.Title, mysql.MaxTitleLength, false)),
+		NewInputBlock(InputAssignee, AssigneeLabel, true, NewUsersSelect(InputAssignee, task.AsigneeID).WithPlaceholder(NotAssignedText)),
+		NewInputBlock(InputDue, DueDateLabel, true, NewDatePicker(InputDue, due)),
+		NewInputBlock(InputPriority, PriorityLabel, true, NewStaticSelect(InputPriority, priority, options...).WithPlaceholder(NoPriorityText)),
+		NewInputBlock(InputDescription, DescriptionLabel, true, NewPlainTextInput(InputDescription, task.Description, mysql.MaxDescriptionLength, true)),
+	)
+	view.Close = &BlockText{Type: PlainTextType, Text: ModalCloseText}
+	view.PrivateMetadata = task.ChannelID + " " + strconv.Itoa(task.Number)
+	return view
+}
+
+// HandleViewSubmission handles the submission of the task modal and returns the answer to the view_submission or nil to close the modal.
+// Invalid inputs, a denied change and a task deleted in the meantime keep the modal open with the error under the input.
+// The task is added in the first state of the workflow of the channel, an edited task gets only the changed fields.
+// The confirmation is posted like the one of /tododo-add, refer to respondTask. If it can't be posted, it replaces the modal.
+func (handler *CommandHandler) HandleViewSubmission(ctx context.Context, payload *InteractionPayload) ([]byte, error) {
+	view := payload.View
+	if payload.Type != InteractionViewSubmission || view == nil || view.CallbackID != CallbackTaskModal {
+		return nil, fmt.Errorf("Can't handle view submission")
+	}
+	metadata := strings.Fields(view.PrivateMetadata)
+	if len(metadata) != 2 {
+		return nil, fmt.Errorf("Bad private metadata %s", view.PrivateMetadata)
+	}
+	channelID := metadata[0]
+	number, err := strconv.Atoi(metadata[1])
+	if err != nil || number < 0 {
+		return nil, fmt.Errorf("Bad private metadata %s", view.PrivateMetadata)
+	}
+	userID := payload.User.ID
+	loc, err := handler.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	task := mysql.NewTask("", channelID, userID)
+	if number != 0 {
+		task, err = handler.Repository.GetTask(ctx, channelID, number)
+		if err == sql.ErrNoRows || (err == nil && task.Deleted) {
+			return errorsResponse(map[string]string{InputTitle: NoSuchTaskIDText})
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	submitted, errs := handler.parseTaskModal(view, task, loc)
+	if len(errs) > 0 {
+		return errorsResponse(errs)
+	}
+	if number == 0 {
+		return handler.addFromModal(ctx, submitted, loc)
+	}
+	err = handler.applyTaskModal(ctx, task, submitted, userID)
+	if err == mysql.ErrNoRowOrMoreThanOne {
+		return errorsResponse(map[string]string{InputTitle: NoSuchTaskIDText})
+	} else if denied, ok := err.(*PermissionError); ok {
+		return errorsResponse(map[string]string{InputTitle: denied.Reason})
+	} else if err != nil {
+		return nil, err
+	}
+	return handler.confirmModal(ctx, EditModalTitle, taskResponse(UpdateHeader, "*Task updated*: ", submitted, loc), channelID, number)
+}
+
+// parseTaskModal returns a copy of task with the values of the inputs of the submitted modal and the errors of invalid inputs by block id.
+// The due date is a date in loc at DefaultDueHour. It can't be in the past unless the task already has it, then its time is kept.
+func (handler *CommandHandler) parseTaskModal(view *InteractionView, task *mysql.Task, loc *time.Location) (*mysql.Task, map[string]string) {
+	submitted := *task
+	errs := make(map[string]string)
+	submitted.Title = strings.Join(strings.Fields(viewValue(view, InputTitle).Value), " ")
+	if submitted.Title == "" {
+		errs[InputTitle] = TitleRequiredText
+	} else if utf8.RuneCountInString(submitted.Title) > mysql.MaxTitleLength {
+		errs[InputTitle] = fmt.Sprintf(TitleTooLongText, mysql.MaxTitleLength)
+	}
+	submitted.AsigneeID = viewValue(view, InputAssignee).SelectedUser
+	submitted.Description = strings.TrimSpace(viewValue(view, InputDescription).Value)
+	if utf8.RuneCountInString(submitted.Description) > mysql.MaxDescriptionLength {
+		errs[InputDescription] = fmt.Sprintf(DescriptionTooLongText, mysql.MaxDescriptionLength)
+	}
+	submitted.DueDate = nil
+	if date := viewValue(view, InputDue).SelectedDate; date != "" {
+		now := handler.now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		day, err := time.ParseInLocation(modalDateLayout, date, loc)
+		if err != nil {
+			errs[InputDue] = BadDueDateText
+		} else if task.DueDate != nil && task.DueDate.In(loc).Format(modalDateLayout) == date {
+			submitted.DueDate = task.DueDate
+		} else if day.Before(today) {
+			errs[InputDue] = PastDueDateText
+		} else {
+			due := atHour(day, DefaultDueHour, 0).UTC()
+			submitted.DueDate = &due
+		}
+	}
+	submitted.Priority = mysql.PriorityNone
+	if option := viewValue(view, InputPriority).SelectedOption; option != nil {
+		priority, err := strconv.Atoi(option.Value)
+		if err != nil || priority < mysql.PriorityNone || priority > mysql.PriorityP4 {
+			errs[InputPriority] = BadPriorityText
+		}
+		submitted.Priority = priority
+	}
+	return &submitted, errs
+}
+
+// viewValue returns the value of the input with this block id, the action id is the same. Returns an empty value if the input is empty.
+func viewValue(view *InteractionView, blockID string) *ViewValue {
+	value, ok := view.State.Values[blockID][blockID]
+	if !ok || value == nil {
+		return &ViewValue{}
+	}
+	return value
+}
+
+// addFromModal adds the task submitted with the task modal in the first state of the workflow of its channel.
+func (handler *CommandHandler) addFromModal(ctx context.Context, task *mysql.Task, loc *time.Location) ([]byte, error) {
+	workflow, err := handler.channelWorkflow(ctx, task.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	task.Status = workflow.States[0].Name
+	err = handler.Repository.PersistTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	return handler.confirmModal(ctx, AddModalTitle, addResponse(task, loc), task.ChannelID, task.Number)
+}
+
+// applyTaskModal changes the fields of task which differ in submitted with one mysql.TaskUpdate, so either all of them change or none,
+// e.g. if one of them is denied with a *PermissionError. Nothing is saved if no field differs.
+func (handler *CommandHandler) applyTaskModal(ctx context.Context, task *mysql.Task, submitted *mysql.Task, actorID string) error {
+	update := mysql.TaskUpdate{}
+	if submitted.Title != task.Title {
+		update.Title = &submitted.Title
+	}
+	if submitted.AsigneeID != task.AsigneeID {
+		update.AsigneeID = &submitted.AsigneeID
+	}
+	if mysql.FormatDue(submitted.DueDate) != mysql.FormatDue(task.DueDate) {
+		update.SetDue = true
+		update.DueDate = submitted.DueDate
+	}
+	if submitted.Priority != task.Priority {
+		update.Priority = &submitted.Priority
+	}
+	if submitted.Description != task.Description {
+		update.Description = &submitted.Description
+	}
+	if len(update.Changes(task)) == 0 {
+		return nil
+	}
+	return handler.Repository.UpdateTask(ctx, task.ChannelID, task.Number, &update, actorID)
+}
+
+// confirmModal posts the confirmation of the submitted task modal, refer to respondTask, and closes the modal.
+// A view_submission has no response_url, so a confirmation which isn't posted, e.g. in a private channel, replaces the modal with this title instead.
+func (handler *CommandHandler) confirmModal(ctx context.Context, title string, resp *Response, channelID string, number int) ([]byte, error) {
+	byt, err := handler.respondTask(ctx, resp, channelID, number)
+	if err != nil || byt == nil {
+		return nil, err
+	}
+	view := View{Type: "modal", Title: &BlockText{Type: PlainTextType, Text: title}, Close: &BlockText{Type: PlainTextType, Text: ModalCloseText}, Blocks: resp.Blocks}
+	err = view.Validate()
+	if err != nil {
+		log.Printf("[ERROR] Can't show confirmation of task %d in %s: %s", number, channelID, err)
+		return nil, nil
+	}
+	return json.Marshal(&ViewResponse{ResponseAction: ResponseActionUpdate, View: &view})
+}
+
+// errorsResponse constructs the answer to a view_submission showing errs, messages by block id, under the inputs of the modal.
+func errorsResponse(errs map[string]string) ([]byte, error) {
+	return json.Marshal(&ViewResponse{ResponseAction: ResponseActionErrors, Errors: errs})
+}
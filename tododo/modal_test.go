@@ -0,0 +1,260 @@
+package tododo
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newViewSubmission(metadata string, title string, assignee string, due string, priority string, description string) *InteractionPayload {
+	values := map[string]map[string]*ViewValue{
+		InputTitle:       {InputTitle: {Type: "plain_text_input", Value: title}},
+		InputAssignee:    {InputAssignee: {Type: "users_select", SelectedUser: assignee}},
+		InputDue:         {InputDue: {Type: "datepicker", SelectedDate: due}},
+		InputPriority:    {InputPriority: {Type: "static_select"}},
+		InputDescription: {InputDescription: {Type: "plain_text_input", Value: description}},
+	}
+	if priority != "" {
+		values[InputPriority][InputPriority].SelectedOption = NewOption("P"+priority, priority)
+	}
+	return &InteractionPayload{
+		Type: InteractionViewSubmission,
+		User: InteractionUser{ID: "U5"},
+		View: &InteractionView{ID: "V1", CallbackID: CallbackTaskModal, PrivateMetadata: metadata, State: ViewState{Values: values}},
+	}
+}
+
+func openedModal(t *testing.T, raw []byte) *View {
+	var view View
+	require.NoError(t, json.Unmarshal(raw, &view))
+	return &view
+}
+
+func TestOpenTaskModalAdd(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	result, err := mockHandler.HandleCommand(ctx, &slack.SlashCommand{Command: "/tododo-add", ChannelID: "CH1", UserID: "U5", TriggerID: "T123"})
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	views := server.Views()
+	require.Len(t, views, 1)
+	assert.Equal(t, "T123", views[0].TriggerID)
+	view := openedModal(t, views[0].View)
+	assert.Equal(t, CallbackTaskModal, view.CallbackID)
+	assert.Equal(t, "CH1 0", view.PrivateMetadata)
+	assert.Equal(t, AddModalTitle, view.Title.Text)
+	require.Len(t, view.Blocks, 5)
+	for i, blockID := range []string{InputTitle, InputAssignee, InputDue, InputPriority, InputDescription} {
+		assert.Equal(t, blockID, view.Blocks[i].BlockID)
+		assert.Equal(t, blockID, view.Blocks[i].Element.ActionID)
+	}
+	assert.False(t, view.Blocks[0].Optional)
+	assert.Equal(t, mysql.MaxTitleLength, view.Blocks[0].Element.MaxLength)
+	assert.Equal(t, "", view.Blocks[1].Element.InitialUser)
+	assert.Len(t, view.Blocks[3].Element.Options, 5)
+	assert.Nil(t, view.Blocks[3].Element.InitialOption)
+	assert.True(t, view.Blocks[4].Element.Multiline)
+}
+
+func TestOpenTaskModalEdit(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	result, err := mockHandler.HandleCommand(ctx, &slack.SlashCommand{Command: "/tododo-edit", Text: "1", ChannelID: "CH1", UserID: "U5", TriggerID: "T123"})
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	views := server.Views()
+	require.Len(t, views, 1)
+	view := openedModal(t, views[0].View)
+	assert.Equal(t, "CH1 1", view.PrivateMetadata)
+	assert.Equal(t, EditModalTitle, view.Title.Text)
+	assert.Equal(t, EditModalSubmitText, view.Submit.Text)
+	assert.Equal(t, "MockTitle", view.Blocks[0].Element.InitialValue)
+	assert.Equal(t, "U1ABC", view.Blocks[1].Element.InitialUser)
+	assert.Equal(t, "", view.Blocks[2].Element.InitialDate)
+
+	result, err = mockHandler.HandleCommand(ctx, &slack.SlashCommand{Command: "/tododo-edit", Text: "2", ChannelID: "CH1", UserID: "U5", TriggerID: "T124"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoSuchTaskIDText)
+	assert.Len(t, server.Views(), 1)
+}
+
+func TestTaskModalPrefilled(t *testing.T) {
+	due := time.Date(2026, 11, 2, 22, 0, 0, 0, time.UTC)
+	task := &mysql.Task{Number: 3, Title: "Rotate certs", ChannelID: "CH1", AsigneeID: "U1", Priority: mysql.PriorityP2, DueDate: &due, Description: "Steps in the wiki"}
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	view := taskModal(task, loc)
+	assert.NoError(t, view.Validate())
+	assert.Equal(t, "2026-11-03", view.Blocks[2].Element.InitialDate)
+	assert.Equal(t, NewOption("P2", "2"), view.Blocks[3].Element.InitialOption)
+	assert.Equal(t, "Steps in the wiki", view.Blocks[4].Element.InitialValue)
+}
+
+func TestOpenTaskModalWithoutSlack(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleCommand(ctx, &slack.SlashCommand{Command: "/tododo-add", Text: " ", ChannelID: "CH1", UserID: "U5", TriggerID: "T123"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), AddBadArgsText)
+	assert.Nil(t, mockHandler.Repository.(*MockRepo).persisted)
+	result, err = mockHandler.HandleCommand(ctx, &slack.SlashCommand{Command: "/tododo-edit", Text: "1", ChannelID: "CH1", UserID: "U5"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), EditBadArgsText)
+}
+
+func TestIsModalCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		text    string
+		modal   bool
+	}{
+		{"/tododo-add", "", true},
+		{"/tododo-add", " ", true},
+		{"/tododo-add", "Buy milk", false},
+		{"/tododo-edit", "2", true},
+		{"/tododo-edit", "2 New title", false},
+		{"/tododo-edit", "x", false},
+		{"/tododo-show", "", false},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.modal, IsModalCommand(&slack.SlashCommand{Command: tc.command, Text: tc.text}), tc.command+" "+tc.text)
+	}
+}
+
+func TestIsModalAction(t *testing.T) {
+	assert.True(t, IsModalAction(newHomeAction(ActionHomeEdit, "CH1 1")))
+	assert.False(t, IsModalAction(newHomeAction(ActionHomeDone, "CH1 1")))
+	assert.False(t, IsModalAction(&InteractionPayload{Type: InteractionViewSubmission}))
+}
+
+func TestParseViewSubmission(t *testing.T) {
+	payload := `{"type":"view_submission","user":{"id":"U5","username":"hb"},"view":{"id":"V1","callback_id":"tododo_task_modal","private_metadata":"CH1 0","state":{"values":{"tododo_title":{"tododo_title":{"type":"plain_text_input","value":"Rotate certs"}},"tododo_assignee":{"tododo_assignee":{"type":"users_select","selected_user":"U1"}},"tododo_due":{"tododo_due":{"type":"datepicker","selected_date":"2026-10-20"}},"tododo_priority":{"tododo_priority":{"type":"static_select","selected_option":{"text":{"type":"plain_text","text":"P1"},"value":"1"}}},"tododo_description":{"tododo_description":{"type":"plain_text_input","value":"Steps in the wiki"}}}}}}`
+	form := url.Values{"payload": {payload}}
+	req := httptest.NewRequest(http.MethodPost, "/tododo/interactive", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := ParseInteractionPayload(req)
+	require.NoError(t, err)
+	expected := newViewSubmission("CH1 0", "Rotate certs", "U1", "2026-10-20", "1", "Steps in the wiki")
+	expected.User.Name = "hb"
+	expected.View.State.Values[InputPriority][InputPriority].SelectedOption.Text.Text = "P1"
+	assert.Equal(t, expected, res)
+}
+
+func TestHandleViewSubmissionAdd(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleViewSubmission(ctx, newViewSubmission("CH1 0", "  Rotate   certs ", "U1ABC", "2026-10-20", "1", "Steps in the wiki"))
+	assert.NoError(t, err)
+	persisted := mockHandler.Repository.(*MockRepo).persisted
+	require.NotNil(t, persisted)
+	assert.Equal(t, "Rotate certs", persisted.Title)
+	assert.Equal(t, "CH1", persisted.ChannelID)
+	assert.Equal(t, "U5", persisted.CreatorID)
+	assert.Equal(t, "U1ABC", persisted.AsigneeID)
+	assert.Equal(t, mysql.StatusOpen, persisted.Status)
+	assert.Equal(t, mysql.PriorityP1, persisted.Priority)
+	assert.Equal(t, "Steps in the wiki", persisted.Description)
+	require.NotNil(t, persisted.DueDate)
+	assert.Equal(t, time.Date(2026, 10, 20, DefaultDueHour, 0, 0, 0, time.UTC), *persisted.DueDate)
+
+	var resp ViewResponse
+	require.NoError(t, json.Unmarshal(result, &resp))
+	assert.Equal(t, ResponseActionUpdate, resp.ResponseAction)
+	require.NotNil(t, resp.View)
+	assert.Equal(t, AddModalTitle, resp.View.Title.Text)
+	assert.Nil(t, resp.View.Submit)
+	require.Len(t, resp.View.Blocks, 7)
+	assert.Equal(t, "*Assignee*: <@U1ABC>", resp.View.Blocks[3].BText.Text)
+	assert.Equal(t, "*Description*: Steps in the wiki", resp.View.Blocks[6].BText.Text)
+}
+
+func TestHandleViewSubmissionPostsConfirmation(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	result, err := mockHandler.HandleViewSubmission(ctx, newViewSubmission("CH1 0", "Rotate certs", "", "", "", ""))
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0].Text, "*Task added*: 0 - Rotate certs")
+	assert.Nil(t, mockHandler.Repository.(*MockRepo).persisted.DueDate)
+}
+
+func TestHandleViewSubmissionErrors(t *testing.T) {
+	tests := []struct {
+		payload *InteractionPayload
+		errs    map[string]string
+	}{
+		{newViewSubmission("CH1 0", " ", "", "", "", ""), map[string]string{InputTitle: TitleRequiredText}},
+		{newViewSubmission("CH1 0", strings.Repeat("a", mysql.MaxTitleLength+1), "", "", "", ""), map[string]string{InputTitle: fmt.Sprintf(TitleTooLongText, mysql.MaxTitleLength)}},
+		{newViewSubmission("CH1 0", "Rotate certs", "", "2026-10-13", "7", strings.Repeat("a", mysql.MaxDescriptionLength+1)), map[string]string{InputDue: PastDueDateText, InputPriority: BadPriorityText, InputDescription: fmt.Sprintf(DescriptionTooLongText, mysql.MaxDescriptionLength)}},
+		{newViewSubmission("CH1 0", "Rotate certs", "", "20.10.2026", "", ""), map[string]string{InputDue: BadDueDateText}},
+		{newViewSubmission("CH1 2", "Rotate certs", "", "", "", ""), map[string]string{InputTitle: NoSuchTaskIDText}},
+	}
+	for _, tc := range tests {
+		mockHandler := newMockHandler()
+		result, err := mockHandler.HandleViewSubmission(ctx, tc.payload)
+		assert.NoError(t, err)
+		var resp ViewResponse
+		require.NoError(t, json.Unmarshal(result, &resp))
+		assert.Equal(t, ResponseActionErrors, resp.ResponseAction)
+		assert.Equal(t, tc.errs, resp.Errors)
+		assert.Nil(t, mockHandler.Repository.(*MockRepo).persisted)
+	}
+}
+
+func TestHandleViewSubmissionToday(t *testing.T) {
+	mockHandler := newMockHandler()
+	_, err := mockHandler.HandleViewSubmission(ctx, newViewSubmission("CH1 0", "Rotate certs", "", "2026-10-14", "", ""))
+	assert.NoError(t, err)
+	persisted := mockHandler.Repository.(*MockRepo).persisted
+	require.NotNil(t, persisted)
+	assert.Equal(t, time.Date(2026, 10, 14, DefaultDueHour, 0, 0, 0, time.UTC), *persisted.DueDate)
+}
+
+func TestHandleViewSubmissionEdit(t *testing.T) {
+	mockHandler := newMockHandler()
+	repo := mockHandler.Repository.(*MockRepo)
+	result, err := mockHandler.HandleViewSubmission(ctx, newViewSubmission("CH1 1", "MockTitle", "U1ABC", "2026-10-20", "2", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{mysql.FieldDue, mysql.FieldPriority}, repo.changes)
+	assert.Equal(t, "U5", repo.actor)
+	assert.Nil(t, repo.persisted)
+	var resp ViewResponse
+	require.NoError(t, json.Unmarshal(result, &resp))
+	assert.Equal(t, EditModalTitle, resp.View.Title.Text)
+	assert.Equal(t, UpdateHeader, resp.View.Blocks[0].BText.Text)
+	assert.Equal(t, "*Task updated*: 1 - MockTitle", resp.View.Blocks[2].BText.Text)
+
+	repo.changes = nil
+	_, err = mockHandler.HandleViewSubmission(ctx, newViewSubmission("CH1 1", "Renamed", "", "", "", "Steps in the wiki"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{mysql.FieldTitle, mysql.FieldAssignee, mysql.FieldDescription}, repo.changes)
+}
+
+func TestHandleViewSubmissionDenied(t *testing.T) {
+	mockHandler, repo := newPolicyHandler(mysql.PolicyAdmins, "U0ADM")
+	result, err := mockHandler.HandleViewSubmission(ctx, newViewSubmission("CH1 1", "Renamed", "U1ABC", "", "", ""))
+	assert.NoError(t, err)
+	var resp ViewResponse
+	require.NoError(t, json.Unmarshal(result, &resp))
+	assert.Equal(t, map[string]string{InputTitle: DeniedAdminsText}, resp.Errors)
+	assert.Empty(t, repo.changes)
+}
+
+func TestHandleViewSubmissionBadPayload(t *testing.T) {
+	mockHandler := newMockHandler()
+	payload := newViewSubmission("CH1", "Rotate certs", "", "", "", "")
+	_, err := mockHandler.HandleViewSubmission(ctx, payload)
+	assert.Error(t, err)
+	payload.View.CallbackID = "other"
+	_, err = mockHandler.HandleViewSubmission(ctx, payload)
+	assert.Error(t, err)
+}
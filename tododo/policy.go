@@ -0,0 +1,327 @@
+package tododo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PermissionError is returned when a user may not change a task or the permissions of a channel.
+// Reason is shown to the user together with the task admins of the channel.
+type PermissionError struct {
+	Reason string
+	Admins []string
+}
+
+func (e *PermissionError) Error() string {
+	return "tododo: permission denied: " + e.Reason
+}
+
+// Rules are the permission rules of a channel - the policy set with /tododo-policy and the task admins added with /tododo-admin.
+type Rules struct {
+	Policy string
+	Admins []string
+}
+
+// LoadRules returns the permission rules of the channel.
+func LoadRules(ctx context.Context, channels mysql.ChannelSettingRepositoryInterface, channelID string) (*Rules, error) {
+	policy, err := channels.GetPolicy(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	admins, err := channels.GetAdmins(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	return &Rules{Policy: policy, Admins: admins}, nil
+}
+
+// IsAdmin reports whether the user is a task admin of the channel.
+func (rules *Rules) IsAdmin(userID string) bool {
+	for _, admin := range rules.Admins {
+		if admin == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns a *PermissionError if the user may not change field of the task to value, refer to mysql.TaskEvent for fields and values.
+// Task admins may change every task. With mysql.PolicyOpen everybody may change every task.
+// With mysql.PolicyAssignee the assignee and the creator may change the task and anybody may take an unassigned task.
+// With mysql.PolicyAdmins only task admins may change tasks.
+func (rules *Rules) Check(task *mysql.Task, userID string, field string, value string) error {
+	if rules.IsAdmin(userID) {
+		return nil
+	}
+	switch rules.Policy {
+	case mysql.PolicyAssignee:
+		if userID == task.AsigneeID || userID == task.CreatorID {
+			return nil
+		}
+		if field == mysql.FieldAssignee && task.AsigneeID == "" && value == userID {
+			return nil
+		}
+		return &PermissionError{Reason: DeniedAssigneeText, Admins: rules.Admins}
+	case mysql.PolicyAdmins:
+		return &PermissionError{Reason: DeniedAdminsText, Admins: rules.Admins}
+	}
+	return nil
+}
+
+// CheckSettings returns a *PermissionError if the user may not change the policy, the workflow or the task admins of the channel.
+// Only task admins may change them, anybody may while the channel has no task admins.
+func (rules *Rules) CheckSettings(userID string) error {
+	if len(rules.Admins) == 0 || rules.IsAdmin(userID) {
+		return nil
+	}
+	return &PermissionError{Reason: DeniedSettingsText, Admins: rules.Admins}
+}
+
+// PolicyRepository enforces the permission rules of the channel on changes of tasks, refer to Rules.Check.
+// Reads and allowed changes are passed to the wrapped repository, denied changes return a *PermissionError.
+type PolicyRepository struct {
+	mysql.TaskRepositoryInterface
+	Channels mysql.ChannelSettingRepositoryInterface
+}
+
+// NewPolicyRepository wraps repo to check the permission rules kept in channels.
+func NewPolicyRepository(repo mysql.TaskRepositoryInterface, channels mysql.ChannelSettingRepositoryInterface) *PolicyRepository {
+	return &PolicyRepository{TaskRepositoryInterface: repo, Channels: channels}
+}
+
+// AssignTaskTo assigns the task if the actor may change its assignee.
+func (repo *PolicyRepository) AssignTaskTo(ctx context.Context, channelID string, number int, assigneeID string, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldAssignee, assigneeID)
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.AssignTaskTo(ctx, channelID, number, assigneeID, actorID)
+}
+
+// SetStatus sets the status of the task if the actor may change it.
+func (repo *PolicyRepository) SetStatus(ctx context.Context, channelID string, number int, status string, terminal bool, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldStatus, status)
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.SetStatus(ctx, channelID, number, status, terminal, actorID)
+}
+
+// UpdateTitle sets the title of the task if the actor may change it.
+func (repo *PolicyRepository) UpdateTitle(ctx context.Context, channelID string, number int, title string, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldTitle, title)
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.UpdateTitle(ctx, channelID, number, title, actorID)
+}
+
+// SetPriority sets the priority of the task if the actor may change it.
+func (repo *PolicyRepository) SetPriority(ctx context.Context, channelID string, number int, priority int, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldPriority, strconv.Itoa(priority))
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.SetPriority(ctx, channelID, number, priority, actorID)
+}
+
+// UpdateLabels changes the labels of the task if the actor may change them.
+func (repo *PolicyRepository) UpdateLabels(ctx context.Context, channelID string, number int, added []string, removed []string, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldLabels, strings.Join(added, " "))
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.UpdateLabels(ctx, channelID, number, added, removed, actorID)
+}
+
+// UpdateDescription sets the description of the task if the actor may change it.
+func (repo *PolicyRepository) UpdateDescription(ctx context.Context, channelID string, number int, description string, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldDescription, description)
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.UpdateDescription(ctx, channelID, number, description, actorID)
+}
+
+// SetDueDate sets the due date of the task if the actor may change it.
+func (repo *PolicyRepository) SetDueDate(ctx context.Context, channelID string, number int, due *time.Time, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldDue, mysql.FormatDue(due))
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.SetDueDate(ctx, channelID, number, due, actorID)
+}
+
+// UpdateTask changes the fields of the task only if the actor may change all of them, so a denied field leaves the task as it was.
+// A user taking an unassigned task may change its other fields with it, like its assignee.
+func (repo *PolicyRepository) UpdateTask(ctx context.Context, channelID string, number int, update *mysql.TaskUpdate, actorID string) error {
+	task, rules, err := repo.load(ctx, channelID, number)
+	if err != nil {
+		return err
+	}
+	checked := *task
+	for _, c := range update.Changes(task) {
+		err = rules.Check(&checked, actorID, c.Field, c.NewValue)
+		if err != nil {
+			return err
+		}
+		if c.Field == mysql.FieldAssignee && c.NewValue == actorID {
+			checked.AsigneeID = actorID
+		}
+	}
+	return repo.TaskRepositoryInterface.UpdateTask(ctx, channelID, number, update, actorID)
+}
+
+// DeleteTask deletes the task if the actor may change it.
+func (repo *PolicyRepository) DeleteTask(ctx context.Context, channelID string, number int, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldDeleted, "1")
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.DeleteTask(ctx, channelID, number, actorID)
+}
+
+// RestoreTask restores the task if the actor may change it.
+func (repo *PolicyRepository) RestoreTask(ctx context.Context, channelID string, number int, actorID string) error {
+	err := repo.check(ctx, channelID, number, actorID, mysql.FieldDeleted, "0")
+	if err != nil {
+		return err
+	}
+	return repo.TaskRepositoryInterface.RestoreTask(ctx, channelID, number, actorID)
+}
+
+// check loads the task and the rules of the channel and checks the change. Returns mysql.ErrNoRowOrMoreThanOne if there is no such task.
+func (repo *PolicyRepository) check(ctx context.Context, channelID string, number int, actorID string, field string, value string) error {
+	task, rules, err := repo.load(ctx, channelID, number)
+	if err != nil {
+		return err
+	}
+	return rules.Check(task, actorID, field, value)
+}
+
+// load returns the task and the rules of the channel. Returns mysql.ErrNoRowOrMoreThanOne if there is no such task.
+func (repo *PolicyRepository) load(ctx context.Context, channelID string, number int) (*mysql.Task, *Rules, error) {
+	task, err := repo.GetTask(ctx, channelID, number)
+	if err == sql.ErrNoRows {
+		return nil, nil, mysql.ErrNoRowOrMoreThanOne
+	} else if err != nil {
+		return nil, nil, err
+	}
+	rules, err := LoadRules(ctx, repo.Channels, channelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return task, rules, nil
+}
+
+// HandlePolicyCommand handles /tododo-policy. Shows the permission policy and the task admins of the channel if text is empty, otherwise sets the policy.
+// Only task admins may set the policy, refer to Rules.CheckSettings.
+func (handler *CommandHandler) HandlePolicyCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if handler.Channels == nil {
+		return nil, fmt.Errorf("Channel settings are not available")
+	}
+	rules, err := LoadRules(ctx, handler.Channels, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return textResponse(PolicyHeader, MarkdownType, "*Policy*: "+rules.Policy+"\n*Task admins*: "+formatAdmins(rules.Admins))
+	}
+	if !ValidatePolicyText(text) {
+		return textResponse(PolicyHeader, PlainTextType, PolicyBadArgsText)
+	}
+	err = rules.CheckSettings(userID)
+	if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	}
+	err = handler.Channels.SetPolicy(ctx, channelID, text)
+	if err != nil {
+		return nil, err
+	}
+	return handler.respond(ctx, newTextResponse(PolicyHeader, MarkdownType, "*Policy set*: "+text), channelID, ResponseInChannel)
+}
+
+// HandleAdminCommand handles /tododo-admin. Shows the task admins of the channel if text is empty,
+// otherwise adds or removes the mentioned user, e.g. "add <@U123ABC|bob>". Only task admins may change them, refer to Rules.CheckSettings.
+func (handler *CommandHandler) HandleAdminCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if handler.Channels == nil {
+		return nil, fmt.Errorf("Channel settings are not available")
+	}
+	rules, err := LoadRules(ctx, handler.Channels, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return textResponse(AdminHeader, MarkdownType, "*Task admins*: "+formatAdmins(rules.Admins))
+	}
+	args := strings.Split(text, " ")
+	if len(args) != 2 || (args[0] != "add" && args[0] != "remove") {
+		return textResponse(AdminHeader, PlainTextType, AdminBadArgsText)
+	}
+	adminID, ok := ParseUserMention(args[1])
+	if !ok {
+		return textResponse(AdminHeader, PlainTextType, AdminBadArgsText)
+	}
+	err = rules.CheckSettings(userID)
+	if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	}
+	if args[0] == "add" {
+		err = handler.Channels.AddAdmin(ctx, channelID, adminID)
+	} else {
+		err = handler.Channels.RemoveAdmin(ctx, channelID, adminID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := "*Task admin added*: "
+	if args[0] == "remove" {
+		result = "*Task admin removed*: "
+	}
+	return handler.respond(ctx, newTextResponse(AdminHeader, MarkdownType, result+FormatUserMention(adminID)), channelID, ResponseInChannel)
+}
+
+// ValidatePolicyText validates the arg of /tododo-policy is exactly 1 - open, assignee or admins. Return true if the text is valid.
+func ValidatePolicyText(text string) bool {
+	switch text {
+	case mysql.PolicyOpen, mysql.PolicyAssignee, mysql.PolicyAdmins:
+		return true
+	}
+	return false
+}
+
+// deniedResponse constructs the message shown only to the user whose change was denied, with the task admins to ask for help.
+func deniedResponse(denied *PermissionError) ([]byte, error) {
+	blocks := []*Block{NewHeaderBlock(PermissionDeniedHeader), NewDividerBlock(), NewSectionTextBlock(PlainTextType, denied.Reason)}
+	if len(denied.Admins) > 0 {
+		blocks = append(blocks, NewSectionTextBlock(MarkdownType, "*Task admins*: "+formatMentions(denied.Admins)))
+	}
+	byt, err := json.Marshal(NewResponse(blocks...))
+	if err != nil {
+		return nil, err
+	}
+	return byt, nil
+}
+
+// formatAdmins returns the mentions of the task admins or NoAdminsText if there are none.
+func formatAdmins(admins []string) string {
+	if len(admins) == 0 {
+		return NoAdminsText
+	}
+	return formatMentions(admins)
+}
+
+// formatMentions returns the mentions of the users separated by commas.
+func formatMentions(userIDs []string) string {
+	mentions := make([]string, 0)
+	for _, userID := range userIDs {
+		mentions = append(mentions, FormatUserMention(userID))
+	}
+	return strings.Join(mentions, ", ")
+}
@@ -0,0 +1,155 @@
+package tododo
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newPolicyHandler(policy string, admins ...string) (*CommandHandler, *MockRepo) {
+	repo := &MockRepo{}
+	channels := newMockChannels()
+	channels.policies["CH1"] = policy
+	channels.admins["CH1"] = admins
+	handler := newMockHandler()
+	handler.Repository = NewPolicyRepository(repo, channels)
+	handler.Channels = channels
+	return handler, repo
+}
+
+func TestRulesCheck(t *testing.T) {
+	assigned := &mysql.Task{Number: 1, AsigneeID: "U1ABC", CreatorID: "U0AAA"}
+	unassigned := &mysql.Task{Number: 2, CreatorID: "U0AAA"}
+	tests := []struct {
+		policy  string
+		task    *mysql.Task
+		userID  string
+		field   string
+		value   string
+		allowed bool
+	}{
+		{mysql.PolicyOpen, assigned, "U0OTH", mysql.FieldStatus, mysql.StatusDone, true},
+		{mysql.PolicyOpen, assigned, "U0OTH", mysql.FieldDeleted, "1", true},
+		{mysql.PolicyAssignee, assigned, "U1ABC", mysql.FieldStatus, mysql.StatusDone, true},
+		{mysql.PolicyAssignee, assigned, "U0AAA", mysql.FieldTitle, "renamed", true},
+		{mysql.PolicyAssignee, assigned, "U1ABC", mysql.FieldAssignee, "U0OTH", true},
+		{mysql.PolicyAssignee, assigned, "U0ADM", mysql.FieldDeleted, "1", true},
+		{mysql.PolicyAssignee, assigned, "U0OTH", mysql.FieldStatus, mysql.StatusDone, false},
+		{mysql.PolicyAssignee, assigned, "U0OTH", mysql.FieldAssignee, "U0OTH", false},
+		{mysql.PolicyAssignee, unassigned, "U0OTH", mysql.FieldAssignee, "U0OTH", true},
+		{mysql.PolicyAssignee, unassigned, "U0OTH", mysql.FieldAssignee, "U1ABC", false},
+		{mysql.PolicyAssignee, unassigned, "U0OTH", mysql.FieldStatus, mysql.StatusInProgress, false},
+		{mysql.PolicyAdmins, assigned, "U0ADM", mysql.FieldStatus, mysql.StatusDone, true},
+		{mysql.PolicyAdmins, assigned, "U1ABC", mysql.FieldStatus, mysql.StatusDone, false},
+		{mysql.PolicyAdmins, assigned, "U0AAA", mysql.FieldDeleted, "1", false},
+	}
+	for _, tc := range tests {
+		rules := &Rules{Policy: tc.policy, Admins: []string{"U0ADM"}}
+		err := rules.Check(tc.task, tc.userID, tc.field, tc.value)
+		if tc.allowed {
+			assert.NoError(t, err, "%s: %s changes %s of task %d", tc.policy, tc.userID, tc.field, tc.task.Number)
+		} else if assert.IsType(t, &PermissionError{}, err, "%s: %s changes %s of task %d", tc.policy, tc.userID, tc.field, tc.task.Number) {
+			assert.Equal(t, []string{"U0ADM"}, err.(*PermissionError).Admins)
+		}
+	}
+}
+
+func TestRulesCheckSettings(t *testing.T) {
+	assert.NoError(t, (&Rules{Policy: mysql.PolicyAdmins}).CheckSettings("U0OTH"))
+	rules := &Rules{Policy: mysql.PolicyOpen, Admins: []string{"U0ADM"}}
+	assert.NoError(t, rules.CheckSettings("U0ADM"))
+	err := rules.CheckSettings("U0OTH")
+	if assert.IsType(t, &PermissionError{}, err) {
+		assert.Equal(t, DeniedSettingsText, err.(*PermissionError).Reason)
+	}
+}
+
+func TestPolicyRepository(t *testing.T) {
+	handler, repo := newPolicyHandler(mysql.PolicyAssignee)
+	err := handler.Repository.SetStatus(ctx, "CH1", 1, mysql.StatusDone, true, "U0OTH")
+	assert.IsType(t, &PermissionError{}, err)
+	assert.Equal(t, "", repo.actor)
+	assert.NoError(t, handler.Repository.SetStatus(ctx, "CH1", 1, mysql.StatusDone, true, "U1ABC"))
+	assert.Equal(t, "U1ABC", repo.actor)
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, handler.Repository.DeleteTask(ctx, "CH1", 2, "U1ABC"))
+	task, err := handler.Repository.GetTask(ctx, "CH1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "MockTitle", task.Title)
+}
+
+func TestPolicyRepositoryUpdateTask(t *testing.T) {
+	handler, repo := newPolicyHandler(mysql.PolicyAssignee)
+	title, assignee, description := "Renamed", "U0OTH", "Steps in the wiki"
+	err := handler.Repository.UpdateTask(ctx, "CH1", 1, &mysql.TaskUpdate{Title: &title, Description: &description}, "U0OTH")
+	assert.IsType(t, &PermissionError{}, err)
+	assert.Equal(t, "", repo.actor)
+	assert.Empty(t, repo.changes)
+
+	err = handler.Repository.UpdateTask(ctx, "CH1", 1, &mysql.TaskUpdate{AsigneeID: &assignee, Description: &description}, "U1ABC")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{mysql.FieldAssignee, mysql.FieldDescription}, repo.changes)
+	assert.Equal(t, mysql.ErrNoRowOrMoreThanOne, handler.Repository.UpdateTask(ctx, "CH1", 2, &mysql.TaskUpdate{Title: &title}, "U1ABC"))
+}
+
+func TestHandleDoneCommandDenied(t *testing.T) {
+	handler, repo := newPolicyHandler(mysql.PolicyAdmins, "U0ADM")
+	result, err := handler.HandleDoneCommand(ctx, "1", "CH1", "U1ABC")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, PermissionDeniedHeader)
+	assert.Contains(t, stringRes, DeniedAdminsText)
+	assert.Contains(t, stringRes, `\u003c@U0ADM\u003e`)
+	assert.Contains(t, stringRes, `"response_type":"ephemeral"`)
+	assert.Equal(t, "", repo.actor)
+}
+
+func TestHandleInteractionDenied(t *testing.T) {
+	handler, _ := newPolicyHandler(mysql.PolicyAssignee)
+	result, err := handler.HandleInteraction(ctx, newMockPayload(ActionAssignMe, "1"))
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, DeniedAssigneeText)
+	assert.NotContains(t, stringRes, "replace_original")
+}
+
+func TestHandlePolicyCommand(t *testing.T) {
+	handler, _ := newPolicyHandler(mysql.PolicyOpen)
+	result, err := handler.HandlePolicyCommand(ctx, "", "CH1", "U0OTH")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "*Policy*: open")
+	assert.Contains(t, string(result), NoAdminsText)
+	result, err = handler.HandlePolicyCommand(ctx, "everybody", "CH1", "U0OTH")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), PolicyBadArgsText)
+	result, err = handler.HandlePolicyCommand(ctx, mysql.PolicyAssignee, "CH1", "U0OTH")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "*Policy set*: assignee")
+	assert.Contains(t, string(result), `"response_type":"in_channel"`)
+	policy, _ := handler.Channels.GetPolicy(ctx, "CH1")
+	assert.Equal(t, mysql.PolicyAssignee, policy)
+}
+
+func TestHandleAdminCommand(t *testing.T) {
+	handler, _ := newPolicyHandler(mysql.PolicyOpen)
+	result, err := handler.HandleAdminCommand(ctx, "add <@U0ADM|ann>", "CH1", "U0OTH")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), `*Task admin added*: \u003c@U0ADM\u003e`)
+	result, err = handler.HandleAdminCommand(ctx, "add <@U0OTH>", "CH1", "U0OTH")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), DeniedSettingsText)
+	result, err = handler.HandlePolicyCommand(ctx, mysql.PolicyAdmins, "CH1", "U0OTH")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), DeniedSettingsText)
+	result, err = handler.HandleAdminCommand(ctx, "add bob", "CH1", "U0ADM")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), AdminBadArgsText)
+	result, err = handler.HandleAdminCommand(ctx, "", "CH1", "U0OTH")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), `*Task admins*: \u003c@U0ADM\u003e`)
+	result, err = handler.HandleAdminCommand(ctx, "remove <@U0ADM>", "CH1", "U0ADM")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "*Task admin removed*")
+	admins, _ := handler.Channels.GetAdmins(ctx, "CH1")
+	assert.Empty(t, admins)
+}
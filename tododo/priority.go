@@ -0,0 +1,111 @@
+package tododo
+
+import (
+	"context"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"strconv"
+	"strings"
+)
+
+// ParsePriority splits the text of /tododo-add into title and priority.
+// The priority is the first word !p1 to !p4 - "!p1 Fix prod login", the word is removed from the title.
+// Returns the whole text as title and mysql.PriorityNone if there is no priority.
+func ParsePriority(text string) (string, int) {
+	words := strings.Fields(text)
+	for i, word := range words {
+		if !strings.HasPrefix(word, "!") {
+			continue
+		}
+		priority, ok := parsePriorityName(strings.TrimPrefix(word, "!"))
+		if ok && priority != mysql.PriorityNone {
+			title := strings.Join(append(append([]string{}, words[:i]...), words[i+1:]...), " ")
+			return title, priority
+		}
+	}
+	return text, mysql.PriorityNone
+}
+
+// parsePriorityName parses p1 to p4 or none, case insensitive. Returns false if name is not a priority.
+func parsePriorityName(name string) (int, bool) {
+	switch strings.ToLower(name) {
+	case "p1":
+		return mysql.PriorityP1, true
+	case "p2":
+		return mysql.PriorityP2, true
+	case "p3":
+		return mysql.PriorityP3, true
+	case "p4":
+		return mysql.PriorityP4, true
+	case "none":
+		return mysql.PriorityNone, true
+	}
+	return 0, false
+}
+
+// HandlePriorityCommand handles /tododo-priority and returns proper response or error.
+// The priority is p1 to p4, p1 is the highest, or none to remove it.
+func (handler *CommandHandler) HandlePriorityCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if !ValidatePriorityText(text) {
+		return textResponse(UpdateHeader, PlainTextType, PriorityBadArgsText)
+	}
+	args := strings.Split(text, " ")
+	id, _ := strconv.Atoi(args[0])
+	priority, _ := parsePriorityName(args[1])
+	err := handler.Repository.SetPriority(ctx, channelID, id, priority, userID)
+	if err == mysql.ErrNoRowOrMoreThanOne {
+		return textResponse(UpdateHeader, PlainTextType, NoSuchTaskIDText)
+	} else if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	} else if err != nil {
+		return nil, err
+	}
+	task, err := handler.Repository.GetTask(ctx, channelID, id)
+	if err != nil {
+		return nil, err
+	}
+	return handler.respondTask(ctx, newTextResponse(UpdateHeader, MarkdownType, "*Priority*: "+task.Title+" - "+formatPriority(task.Priority)), channelID, id)
+}
+
+// ValidatePriorityText validates the args of /tododo-priority are exactly 2 - positive integer and p1 to p4 or none. Return true if the text is valid.
+func ValidatePriorityText(text string) bool {
+	args := strings.Split(text, " ")
+	if len(args) != 2 {
+		return false
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 1 {
+		return false
+	}
+	_, ok := parsePriorityName(args[1])
+	return ok
+}
+
+// formatPriority renders the priority as its emoji and name, e.g. ":red_circle: P1", or NoPriorityText.
+func formatPriority(priority int) string {
+	if priority == mysql.PriorityNone {
+		return NoPriorityText
+	}
+	return getPriorityEmoji(priority) + " " + getPriorityName(priority)
+}
+
+func getPriorityEmoji(priority int) string {
+	switch priority {
+	case mysql.PriorityP1:
+		return PriorityP1Emoji
+	case mysql.PriorityP2:
+		return PriorityP2Emoji
+	case mysql.PriorityP3:
+		return PriorityP3Emoji
+	case mysql.PriorityP4:
+		return PriorityP4Emoji
+	default:
+		return ""
+	}
+}
+
+func getPriorityName(priority int) string {
+	if priority == mysql.PriorityNone {
+		return NoPriorityText
+	}
+	return "P" + strconv.Itoa(priority)
+}
@@ -0,0 +1,79 @@
+package tododo
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		text     string
+		title    string
+		priority int
+	}{
+		{"!p1 Fix prod login", "Fix prod login", mysql.PriorityP1},
+		{"Fix prod login !P2", "Fix prod login", mysql.PriorityP2},
+		{"Ship !p4 release notes due friday", "Ship release notes due friday", mysql.PriorityP4},
+		{"Fix prod login", "Fix prod login", mysql.PriorityNone},
+		{"!p5 is not a priority", "!p5 is not a priority", mysql.PriorityNone},
+		{"!none stays", "!none stays", mysql.PriorityNone},
+	}
+	for _, tc := range tests {
+		title, priority := ParsePriority(tc.text)
+		assert.Equal(t, tc.title, title, tc.text)
+		assert.Equal(t, tc.priority, priority, tc.text)
+	}
+}
+
+func TestValidatePriorityText(t *testing.T) {
+	assert.True(t, ValidatePriorityText("1 p1"))
+	assert.True(t, ValidatePriorityText("12 P4"))
+	assert.True(t, ValidatePriorityText("3 none"))
+	assert.False(t, ValidatePriorityText("1"))
+	assert.False(t, ValidatePriorityText("0 p1"))
+	assert.False(t, ValidatePriorityText("1 p5"))
+	assert.False(t, ValidatePriorityText("1 p1 p2"))
+}
+
+func TestHandleAddCommandPriority(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleAddCommand(ctx, "!p1 Fix prod login due tomorrow", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, "Fix prod login")
+	assert.NotContains(t, stringRes, "!p1")
+	assert.Contains(t, stringRes, "*Priority*: "+PriorityP1Emoji+" P1")
+	persisted := mockHandler.Repository.(*MockRepo).persisted
+	assert.Equal(t, "Fix prod login", persisted.Title)
+	assert.Equal(t, mysql.PriorityP1, persisted.Priority)
+	assert.NotNil(t, persisted.DueDate)
+}
+
+func TestHandlePriorityCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandlePriorityCommand(ctx, "1 p2", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "*Priority*: MockTitle")
+	assert.Equal(t, "U5", mockHandler.Repository.(*MockRepo).actor)
+	result, err = mockHandler.HandlePriorityCommand(ctx, "2 p2", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoSuchTaskIDText)
+	result, err = mockHandler.HandlePriorityCommand(ctx, "1 urgent", "CH1", "U5")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), PriorityBadArgsText)
+}
+
+func TestHandlePriorityCommandDenied(t *testing.T) {
+	handler, repo := newPolicyHandler(mysql.PolicyAssignee)
+	result, err := handler.HandlePriorityCommand(ctx, "1 p1", "CH1", "U0OTH")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), DeniedAssigneeText)
+	assert.Equal(t, "", repo.actor)
+}
+
+func TestFormatPriority(t *testing.T) {
+	assert.Equal(t, PriorityP1Emoji+" P1", formatPriority(mysql.PriorityP1))
+	assert.Equal(t, PriorityP4Emoji+" P4", formatPriority(mysql.PriorityP4))
+	assert.Equal(t, NoPriorityText, formatPriority(mysql.PriorityNone))
+}
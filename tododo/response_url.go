@@ -0,0 +1,42 @@
+package tododo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ResponseSenderInterface sends a message body to response_url of a slash command or interaction.
+type ResponseSenderInterface interface {
+	Send(ctx context.Context, responseURL string, body []byte) error
+}
+
+// ResponseSender implements ResponseSenderInterface with an http client
+type ResponseSender struct {
+	Client *http.Client
+}
+
+// NewResponseSender constructs a sender with 10 seconds timeout.
+func NewResponseSender() *ResponseSender {
+	return &ResponseSender{Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send posts body as json to responseURL. Returns error if Slack does not respond with 200 OK.
+func (sender *ResponseSender) Send(ctx context.Context, responseURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	resp, err := sender.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response_url returned %s", resp.Status)
+	}
+	return nil
+}
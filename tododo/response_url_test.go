@@ -0,0 +1,31 @@
+package tododo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		byt, _ := ioutil.ReadAll(r.Body)
+		received = string(byt)
+		assert.Equal(t, "application/json; charset=utf-8", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+	err := NewResponseSender().Send(ctx, server.URL, []byte(`{"blocks":[]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"blocks":[]}`, received)
+}
+
+func TestSendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	err := NewResponseSender().Send(ctx, server.URL, []byte(`{"blocks":[]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package tododo
+
+import (
+	"context"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// HandleAddFromMessage handles the message shortcut Add to ToDo and returns proper response or error.
+// The text of the message becomes the title of a task in the channel of the message, in the first state of the workflow of the channel.
+// The text is shortened to mysql.MaxTitleLength, refer to truncateTitle. The task links back to the message and the confirmation is the one of /tododo-add.
+func (handler *CommandHandler) HandleAddFromMessage(ctx context.Context, payload *InteractionPayload) ([]byte, error) {
+	channelID := payload.Channel.ID
+	if payload.Message == nil || strings.TrimSpace(payload.Message.Text) == "" {
+		return textResponse(AddHeader, PlainTextType, NoMessageTextText)
+	}
+	loc, err := handler.userLocation(ctx, payload.User.ID)
+	if err != nil {
+		return nil, err
+	}
+	workflow, err := handler.channelWorkflow(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	title := truncateTitle(strings.Join(strings.Fields(payload.Message.Text), " "), mysql.MaxTitleLength)
+	task := mysql.NewTask(title, channelID, payload.User.ID)
+	task.Status = workflow.States[0].Name
+	task.Permalink = handler.messagePermalink(ctx, payload)
+	err = handler.Repository.PersistTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	return handler.respondTask(ctx, addResponse(task, loc), channelID, task.Number)
+}
+
+// truncateTitle shortens text like Truncate, but never within a <...> token of mrkdwn, e.g. a mention <@U123>, a channel <#C123> or a link <url|text>.
+// A token which doesn't fit is left out whole.
+func truncateTitle(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	cut := max - 1
+	open := -1
+	for i := 0; i < cut; i++ {
+		switch runes[i] {
+		case '<':
+			open = i
+		case '>':
+			open = -1
+		}
+	}
+	if open < 0 {
+		return string(runes[:cut]) + truncatedTextEllipsis
+	}
+	return strings.TrimRight(string(runes[:open]), " ") + truncatedTextEllipsis
+}
+
+// messagePermalink returns the link to the message of the shortcut. It is asked from Slack if Slack is set,
+// otherwise or if Slack fails it is built from the domain of the workspace, refer to https://api.slack.com/methods/chat.getPermalink.
+func (handler *CommandHandler) messagePermalink(ctx context.Context, payload *InteractionPayload) string {
+	channelID := payload.Channel.ID
+	msg := payload.Message
+	if handler.Slack != nil {
+		link, err := handler.Slack.GetPermalink(ctx, channelID, msg.TS)
+		if err == nil {
+			return link
+		}
+		log.Printf("[ERROR] Can't get permalink of message %s in %s: %s", msg.TS, channelID, err)
+	}
+	link := "https://" + payload.Team.Domain + ".slack.com/archives/" + channelID + "/p" + strings.Replace(msg.TS, ".", "", 1)
+	if msg.ThreadTS != "" && msg.ThreadTS != msg.TS {
+		link += "?thread_ts=" + msg.ThreadTS + "&cid=" + channelID
+	}
+	return link
+}
+
+// sourceLink returns a link to the message the task was added from, empty if it was added with a command.
+func sourceLink(t *mysql.Task) string {
+	if t.Permalink == "" {
+		return ""
+	}
+	return " <" + t.Permalink + "|:link:>"
+}
@@ -0,0 +1,121 @@
+package tododo
+
+import (
+	"encoding/json"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newShortcutPayload(text string) *InteractionPayload {
+	return &InteractionPayload{
+		Type:        InteractionMessageAction,
+		CallbackID:  ShortcutAddToDo,
+		ResponseURL: "https://hooks.slack.com/app/T1/1/x",
+		Team:        InteractionTeam{ID: "T1", Domain: "acme"},
+		User:        InteractionUser{ID: "U7"},
+		Channel:     InteractionChannel{ID: "CH1"},
+		Message:     &InteractionMessage{Text: text, User: "U1ABC", TS: "1700000000.000100"},
+	}
+}
+
+func TestParseShortcutPayload(t *testing.T) {
+	payload := `{"type":"message_action","callback_id":"tododo_add_to_todo","team":{"id":"T1","domain":"acme"},"user":{"id":"U7","username":"hb"},"channel":{"id":"CH1"},"response_url":"https://hooks.slack.com/app/T1/1/x","message":{"type":"message","text":"Please rotate the TLS certs","user":"U1ABC","ts":"1700000000.000100"}}`
+	form := url.Values{"payload": {payload}}
+	req := httptest.NewRequest(http.MethodPost, "/tododo/interactive", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := ParseInteractionPayload(req)
+	require.NoError(t, err)
+	expected := newShortcutPayload("Please rotate the TLS certs")
+	expected.User.Name = "hb"
+	assert.Equal(t, expected, res)
+}
+
+func TestHandleAddFromMessage(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleInteraction(ctx, newShortcutPayload("Please rotate\nthe TLS certs  before Friday"))
+	assert.NoError(t, err)
+	persisted := mockHandler.Repository.(*MockRepo).persisted
+	require.NotNil(t, persisted)
+	assert.Equal(t, "Please rotate the TLS certs before Friday", persisted.Title)
+	assert.Equal(t, "CH1", persisted.ChannelID)
+	assert.Equal(t, "U7", persisted.CreatorID)
+	assert.Equal(t, mysql.StatusOpen, persisted.Status)
+	assert.Equal(t, "https://acme.slack.com/archives/CH1/p1700000000000100", persisted.Permalink)
+	var resp Response
+	require.NoError(t, json.Unmarshal(result, &resp))
+	assert.Equal(t, ResponseInChannel, resp.ResponseType)
+	require.Len(t, resp.Blocks, 4)
+	assert.Equal(t, AddHeader, resp.Blocks[0].BText.Text)
+	assert.Contains(t, resp.Blocks[2].BText.Text, "Please rotate the TLS certs before Friday")
+	assert.Equal(t, "*Source*: <https://acme.slack.com/archives/CH1/p1700000000000100|"+SourceLinkText+">", resp.Blocks[3].BText.Text)
+}
+
+func TestHandleAddFromMessageLongText(t *testing.T) {
+	mockHandler := newMockHandler()
+	_, err := mockHandler.HandleInteraction(ctx, newShortcutPayload(strings.Repeat("word ", 40)))
+	assert.NoError(t, err)
+	title := mockHandler.Repository.(*MockRepo).persisted.Title
+	assert.Equal(t, mysql.MaxTitleLength, len([]rune(title)))
+	assert.True(t, strings.HasSuffix(title, truncatedTextEllipsis))
+}
+
+func TestHandleAddFromMessageLongTextMention(t *testing.T) {
+	mockHandler := newMockHandler()
+	text := strings.Repeat("a", 55) + " <@U012ABCDEF> please"
+	_, err := mockHandler.HandleInteraction(ctx, newShortcutPayload(text))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 55)+truncatedTextEllipsis, mockHandler.Repository.(*MockRepo).persisted.Title)
+}
+
+func TestTruncateTitle(t *testing.T) {
+	tests := []struct {
+		text     string
+		max      int
+		expected string
+	}{
+		{"Ping <@U1ABC>", 20, "Ping <@U1ABC>"},
+		{"Ping <@U1ABC> about certs", 12, "Ping" + truncatedTextEllipsis},
+		{"Ping <@U1ABC> about certs", 16, "Ping <@U1ABC> a" + truncatedTextEllipsis},
+		{"See <https://wiki.acme.com/certs|the wiki> now", 20, "See" + truncatedTextEllipsis},
+		{"In <#C123|general> and <#C456|infra>", 25, "In <#C123|general> and" + truncatedTextEllipsis},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, truncateTitle(tc.text, tc.max), tc.text)
+	}
+}
+
+func TestHandleAddFromMessageNoText(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleInteraction(ctx, newShortcutPayload(" "))
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoMessageTextText)
+	assert.Nil(t, mockHandler.Repository.(*MockRepo).persisted)
+}
+
+func TestHandleAddFromMessageWorkflow(t *testing.T) {
+	mockHandler, repo := newReviewHandler(t)
+	_, err := mockHandler.HandleInteraction(ctx, newShortcutPayload("Review the release notes"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Open", repo.persisted.Status)
+}
+
+func TestMessagePermalink(t *testing.T) {
+	mockHandler, server := newSlackHandler(t)
+	defer server.Close()
+	payload := newShortcutPayload("Reply")
+	assert.Equal(t, "https://slacktest.slack.com/archives/CH1/p1700000000000100", mockHandler.messagePermalink(ctx, payload))
+	payload.Channel.ID = "CH404"
+	payload.Message.ThreadTS = "1700000000.000050"
+	assert.Equal(t, "https://acme.slack.com/archives/CH404/p1700000000000100?thread_ts=1700000000.000050&cid=CH404", mockHandler.messagePermalink(ctx, payload))
+}
+
+func TestShowSourceLink(t *testing.T) {
+	assert.Equal(t, "", sourceLink(&mysql.Task{}))
+	assert.Equal(t, " <https://acme.slack.com/archives/CH1/p1|:link:>", sourceLink(&mysql.Task{Permalink: "https://acme.slack.com/archives/CH1/p1"}))
+}
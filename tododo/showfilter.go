@@ -0,0 +1,166 @@
+package tododo
+
+import (
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShowPageSize is the number of tasks on a page of /tododo-show.
+// Every task takes 2 blocks, so a page fits in the 50 blocks Slack allows in a message.
+const ShowPageSize = 20
+
+var periodRegexp = regexp.MustCompile(`^(\d+)([hdw])$`)
+
+// ParseShowFilter parses the text of /tododo-show into a filter of the tasks in the channel.
+// The text is a list of words in any order, statuses are the states of the workflow of the channel:
+// open, started, done or all - tasks in the state of new tasks, in the other unfinished states, in terminal states or all of them, unfinished tasks if omitted;
+// state:name - tasks in the state, e.g. state:in-review, refer to matchState;
+// mine or @user - the assignee, the user must be a mention, refer to ParseUserMention;
+// #label - tasks with the label, tasks with all of the labels if there are more;
+// last [N]h/d/w - tasks finished in the last hours, days or weeks, implies done;
+// sort:priority, sort:number or sort:due - the order, highest priority first if omitted;
+// page [N] - the page of ShowPageSize tasks, the first one if omitted.
+// Returns error describing the first word that can't be parsed.
+func ParseShowFilter(text string, channelID string, userID string, now time.Time, workflow *mysql.Workflow) (*mysql.TaskFilter, error) {
+	filter := mysql.TaskFilter{ChannelID: channelID, Sort: mysql.SortPriority, Limit: ShowPageSize}
+	all := false
+	page := 0
+	words := strings.Fields(text)
+	for i := 0; i < len(words); i++ {
+		word := strings.ToLower(words[i])
+		switch {
+		case word == "open":
+			filter.Statuses = append(filter.Statuses, workflow.States[0].Name)
+		case word == "started" || word == "progress" || word == "in-progress":
+			for _, state := range workflow.States[1:] {
+				if !state.Terminal {
+					filter.Statuses = append(filter.Statuses, state.Name)
+				}
+			}
+		case word == "done":
+			filter.Statuses = append(filter.Statuses, terminalStates(workflow, true)...)
+		case strings.HasPrefix(word, "state:"):
+			state := matchState(workflow, strings.TrimPrefix(word, "state:"))
+			if state == nil {
+				return nil, fmt.Errorf("Unknown state %s, the states are %s", words[i], strings.Join(stateNames(workflow.States), ", "))
+			}
+			filter.Statuses = append(filter.Statuses, state.Name)
+		case word == "all":
+			all = true
+		case word == "mine" || strings.HasPrefix(word, "@") || strings.HasPrefix(word, "<@"):
+			if filter.AsigneeID != "" {
+				return nil, fmt.Errorf("Only one assignee can be shown, got %s", words[i])
+			}
+			filter.AsigneeID = userID
+			if word != "mine" {
+				assigneeID, ok := ParseUserMention(words[i])
+				if !ok {
+					return nil, fmt.Errorf("%s is not a user, mention a user from the list Slack suggests after @", words[i])
+				}
+				filter.AsigneeID = assigneeID
+			}
+		case strings.HasPrefix(word, "#") || strings.HasPrefix(word, "<#"):
+			label, ok := parseLabel(words[i])
+			if !ok {
+				return nil, fmt.Errorf("%s is not a label, e.g. #infra", words[i])
+			}
+			filter.Labels = append(filter.Labels, label)
+		case word == "last":
+			if i+1 == len(words) || filter.CompletedSince != nil {
+				return nil, fmt.Errorf("Expected one period after last, e.g. last 7d")
+			}
+			i++
+			period, ok := parsePeriod(words[i])
+			if !ok {
+				return nil, fmt.Errorf("Unknown period %s, e.g. last 12h, last 7d, last 2w", words[i])
+			}
+			since := now.Add(-period)
+			filter.CompletedSince = &since
+		case strings.HasPrefix(word, "sort:"):
+			sort := strings.TrimPrefix(word, "sort:")
+			if sort != mysql.SortPriority && sort != mysql.SortNumber && sort != mysql.SortDue {
+				return nil, fmt.Errorf("Unknown sort %s, use sort:priority, sort:number or sort:due", words[i])
+			}
+			filter.Sort = sort
+		case word == "page":
+			if i+1 == len(words) || page != 0 {
+				return nil, fmt.Errorf("Expected one page number after page, e.g. page 2")
+			}
+			i++
+			n, err := strconv.Atoi(words[i])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("Unknown page %s, pages start from 1", words[i])
+			}
+			page = n
+			filter.Offset = (page - 1) * ShowPageSize
+		default:
+			return nil, fmt.Errorf("Unknown filter %s", words[i])
+		}
+	}
+	if all && len(filter.Statuses) > 0 {
+		return nil, fmt.Errorf("all can't be combined with a status")
+	}
+	if filter.CompletedSince != nil {
+		for _, status := range filter.Statuses {
+			if !isTerminal(workflow, status) {
+				return nil, fmt.Errorf("last shows done tasks and can't be combined with another status")
+			}
+		}
+		if len(filter.Statuses) == 0 {
+			filter.Statuses = terminalStates(workflow, true)
+		}
+	} else if !all && len(filter.Statuses) == 0 {
+		filter.Statuses = terminalStates(workflow, false)
+	}
+	return &filter, nil
+}
+
+// terminalStates returns the names of the terminal states of the workflow if terminal is true, otherwise the names of the other states.
+func terminalStates(workflow *mysql.Workflow, terminal bool) []string {
+	names := make([]string, 0)
+	for _, state := range workflow.States {
+		if state.Terminal == terminal {
+			names = append(names, state.Name)
+		}
+	}
+	return names
+}
+
+// parsePeriod parses a period like 12h, 7d or 2w.
+func parsePeriod(text string) (time.Duration, bool) {
+	match := periodRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	unit := time.Hour
+	switch match[2] {
+	case "d":
+		unit = 24 * time.Hour
+	case "w":
+		unit = 7 * 24 * time.Hour
+	}
+	return time.Duration(n) * unit, true
+}
+
+// pageQuery returns query with the page replaced by page.
+func pageQuery(query string, page int) string {
+	words := strings.Fields(query)
+	res := make([]string, 0)
+	for i := 0; i < len(words); i++ {
+		if strings.EqualFold(words[i], "page") {
+			i++
+			continue
+		}
+		res = append(res, words[i])
+	}
+	res = append(res, "page", strconv.Itoa(page))
+	return strings.Join(res, " ")
+}
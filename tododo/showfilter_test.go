@@ -0,0 +1,85 @@
+package tododo
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseShowFilter(t *testing.T) {
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	unfinished := []string{mysql.StatusOpen, mysql.StatusInProgress}
+	ago := func(d time.Duration) *time.Time {
+		since := now.Add(-d)
+		return &since
+	}
+	tests := []struct {
+		text   string
+		filter mysql.TaskFilter
+	}{
+		{"", mysql.TaskFilter{Statuses: unfinished, Sort: mysql.SortPriority}},
+		{"open", mysql.TaskFilter{Statuses: []string{mysql.StatusOpen}, Sort: mysql.SortPriority}},
+		{"Started", mysql.TaskFilter{Statuses: []string{mysql.StatusInProgress}, Sort: mysql.SortPriority}},
+		{"open done", mysql.TaskFilter{Statuses: []string{mysql.StatusOpen, mysql.StatusDone}, Sort: mysql.SortPriority}},
+		{"all", mysql.TaskFilter{Sort: mysql.SortPriority}},
+		{"mine", mysql.TaskFilter{Statuses: unfinished, AsigneeID: "U1", Sort: mysql.SortPriority}},
+		{"<@U2ABC|alice>", mysql.TaskFilter{Statuses: unfinished, AsigneeID: "U2ABC", Sort: mysql.SortPriority}},
+		{"<@U2ABC> all", mysql.TaskFilter{AsigneeID: "U2ABC", Sort: mysql.SortPriority}},
+		{"done last 7d", mysql.TaskFilter{Statuses: []string{mysql.StatusDone}, CompletedSince: ago(7 * 24 * time.Hour), Sort: mysql.SortPriority}},
+		{"last 12h", mysql.TaskFilter{Statuses: []string{mysql.StatusDone}, CompletedSince: ago(12 * time.Hour), Sort: mysql.SortPriority}},
+		{"last 2w mine", mysql.TaskFilter{Statuses: []string{mysql.StatusDone}, AsigneeID: "U1", CompletedSince: ago(14 * 24 * time.Hour), Sort: mysql.SortPriority}},
+		{"sort:due", mysql.TaskFilter{Statuses: unfinished, Sort: mysql.SortDue}},
+		{"all  sort:number", mysql.TaskFilter{Sort: mysql.SortNumber}},
+		{"sort:priority", mysql.TaskFilter{Statuses: unfinished, Sort: mysql.SortPriority}},
+		{"page 1", mysql.TaskFilter{Statuses: unfinished, Sort: mysql.SortPriority}},
+		{"mine page 3", mysql.TaskFilter{Statuses: unfinished, AsigneeID: "U1", Sort: mysql.SortPriority, Offset: 2 * ShowPageSize}},
+		{"#infra", mysql.TaskFilter{Statuses: unfinished, Labels: []string{"infra"}, Sort: mysql.SortPriority}},
+		{"done #Infra <#C1ABC|q4>", mysql.TaskFilter{Statuses: []string{mysql.StatusDone}, Labels: []string{"infra", "q4"}, Sort: mysql.SortPriority}},
+	}
+	for _, tc := range tests {
+		filter, err := ParseShowFilter(tc.text, "C1", "U1", now, DefaultWorkflow())
+		if assert.NoError(t, err, tc.text) {
+			tc.filter.ChannelID = "C1"
+			tc.filter.Limit = ShowPageSize
+			assert.Equal(t, tc.filter, *filter, tc.text)
+		}
+	}
+}
+
+func TestParseShowFilterErrors(t *testing.T) {
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{"urgent", "Unknown filter urgent"},
+		{"sort:title", "Unknown sort sort:title, use sort:priority, sort:number or sort:due"},
+		{"done last", "Expected one period after last, e.g. last 7d"},
+		{"last 7d last 1d", "Expected one period after last, e.g. last 7d"},
+		{"last week", "Unknown period week, e.g. last 12h, last 7d, last 2w"},
+		{"last 0d", "Unknown period 0d, e.g. last 12h, last 7d, last 2w"},
+		{"open last 7d", "last shows done tasks and can't be combined with another status"},
+		{"all open", "all can't be combined with a status"},
+		{"mine <@U2ABC|bob>", "Only one assignee can be shown, got <@U2ABC|bob>"},
+		{"@bob", "@bob is not a user, mention a user from the list Slack suggests after @"},
+		{"<!here>", "Unknown filter <!here>"},
+		{"#123", "#123 is not a label, e.g. #infra"},
+		{"page", "Expected one page number after page, e.g. page 2"},
+		{"page 2 page 3", "Expected one page number after page, e.g. page 2"},
+		{"page 0", "Unknown page 0, pages start from 1"},
+	}
+	for _, tc := range tests {
+		filter, err := ParseShowFilter(tc.text, "C1", "U1", now, DefaultWorkflow())
+		assert.Nil(t, filter, tc.text)
+		if assert.Error(t, err, tc.text) {
+			assert.Equal(t, tc.err, err.Error(), tc.text)
+		}
+	}
+}
+
+func TestPageQuery(t *testing.T) {
+	assert.Equal(t, "page 2", pageQuery("", 2))
+	assert.Equal(t, "done last 7d page 1", pageQuery("done Page 2 last 7d", 1))
+	assert.Equal(t, "mine page 4", pageQuery("mine page", 4))
+}
@@ -0,0 +1,389 @@
+package tododo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of a workflow, so the names of the states fit in the status of a task and the workflow fits in a message.
+const (
+	MaxWorkflowStates = 10
+	MaxStateLength    = 30
+)
+
+var emojiRegexp = regexp.MustCompile(`^:[a-z0-9_+'-]+:$`)
+
+// TransitionError is returned when the workflow of the channel doesn't allow moving a task from state From to state To.
+// Allowed are the states the task can be moved to instead.
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	return "tododo: can't move a task from " + e.From + " to " + e.To
+}
+
+// DefaultWorkflow returns the workflow of channels without one of their own - Open, In Progress and Done.
+// Tasks can be moved between any two of the states, so a finished task can be reopened.
+func DefaultWorkflow() *mysql.Workflow {
+	states := []*mysql.WorkflowState{
+		{Name: mysql.StatusOpen, Emoji: StatusOpenEmoji},
+		{Name: mysql.StatusInProgress, Emoji: StatusInProgressEmoji},
+		{Name: mysql.StatusDone, Emoji: StatusDoneEmoji, Terminal: true},
+	}
+	transitions := make([]*mysql.WorkflowTransition, 0)
+	for _, from := range states {
+		for _, to := range states {
+			if from != to {
+				transitions = append(transitions, &mysql.WorkflowTransition{From: from.Name, To: to.Name})
+			}
+		}
+	}
+	return &mysql.Workflow{States: states, Transitions: transitions}
+}
+
+// ParseWorkflow parses the text of /tododo-workflow into a workflow. States are separated by |, the first one is the state of new tasks.
+// Every state is a name followed by an emoji, the word terminal if tasks in it are finished and -> with the states a task can be moved to,
+// e.g. "Open :question: -> In Review | In Review :eyes: -> Done, Open | Done :white_check_mark: terminal".
+// Names of the states to move to are case insensitive. Returns error describing the first problem found, refer to ValidateWorkflow.
+func ParseWorkflow(text string) (*mysql.Workflow, error) {
+	workflow := mysql.Workflow{States: make([]*mysql.WorkflowState, 0), Transitions: make([]*mysql.WorkflowTransition, 0)}
+	targets := make(map[*mysql.WorkflowState][]string)
+	for _, segment := range strings.Split(text, "|") {
+		parts := strings.SplitN(segment, "->", 2)
+		words := strings.Fields(parts[0])
+		state := mysql.WorkflowState{}
+		if len(words) > 2 && strings.EqualFold(words[len(words)-1], "terminal") {
+			state.Terminal = true
+			words = words[:len(words)-1]
+		}
+		if len(words) < 2 || !emojiRegexp.MatchString(words[len(words)-1]) {
+			return nil, fmt.Errorf("Every state needs a name followed by an emoji, e.g. In Review :eyes:, got %q", strings.TrimSpace(segment))
+		}
+		state.Emoji = words[len(words)-1]
+		state.Name = strings.Join(words[:len(words)-1], " ")
+		if matchState(&workflow, state.Name) != nil {
+			return nil, fmt.Errorf("State %s is defined twice", state.Name)
+		}
+		workflow.States = append(workflow.States, &state)
+		if len(parts) == 2 {
+			for _, target := range strings.Split(parts[1], ",") {
+				targets[&state] = append(targets[&state], strings.TrimSpace(target))
+			}
+		}
+	}
+	for _, from := range workflow.States {
+		for _, target := range targets[from] {
+			to := matchState(&workflow, target)
+			if to == nil {
+				return nil, fmt.Errorf("%s can't move to unknown state %q", from.Name, target)
+			}
+			if to == from {
+				return nil, fmt.Errorf("%s can't move to itself", from.Name)
+			}
+			if !hasTransition(&workflow, from.Name, to.Name) {
+				workflow.Transitions = append(workflow.Transitions, &mysql.WorkflowTransition{From: from.Name, To: to.Name})
+			}
+		}
+	}
+	err := ValidateWorkflow(&workflow)
+	if err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// ValidateWorkflow returns error if the workflow has less than 2 or more than MaxWorkflowStates states, a name longer than MaxStateLength,
+// starts in a terminal state or has no terminal state.
+func ValidateWorkflow(workflow *mysql.Workflow) error {
+	if len(workflow.States) < 2 || len(workflow.States) > MaxWorkflowStates {
+		return fmt.Errorf("A workflow has from 2 to %d states", MaxWorkflowStates)
+	}
+	for _, state := range workflow.States {
+		if utf8.RuneCountInString(state.Name) > MaxStateLength {
+			return fmt.Errorf("State %s is longer than %d characters", state.Name, MaxStateLength)
+		}
+	}
+	if workflow.States[0].Terminal {
+		return fmt.Errorf("New tasks start in %s, so it can't be terminal", workflow.States[0].Name)
+	}
+	if doneState(workflow) == nil {
+		return fmt.Errorf("A workflow needs a terminal state for finished tasks, e.g. Done :white_check_mark: terminal")
+	}
+	return nil
+}
+
+// HandleWorkflowCommand handles /tododo-workflow. Shows the workflow of the channel if text is empty,
+// resets it to DefaultWorkflow if text is "reset", otherwise sets it, refer to ParseWorkflow.
+// Only task admins may change the workflow, refer to Rules.CheckSettings. The workflow can't drop a state tasks of the channel are in.
+func (handler *CommandHandler) HandleWorkflowCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	if handler.Channels == nil {
+		return nil, fmt.Errorf("Channel settings are not available")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		workflow, err := handler.channelWorkflow(ctx, channelID)
+		if err != nil {
+			return nil, err
+		}
+		return handler.respond(ctx, workflowResponse(WorkflowHeader, workflow), channelID, ResponseEphemeral)
+	}
+	reset := strings.EqualFold(text, "reset")
+	var workflow *mysql.Workflow
+	if reset {
+		workflow = DefaultWorkflow()
+	} else {
+		var err error
+		workflow, err = ParseWorkflow(text)
+		if err != nil {
+			return textResponse(WorkflowHeader, PlainTextType, err.Error()+". "+WorkflowBadArgsText)
+		}
+	}
+	rules, err := LoadRules(ctx, handler.Channels, channelID)
+	if err != nil {
+		return nil, err
+	}
+	err = rules.CheckSettings(userID)
+	if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	}
+	tasks, err := handler.Repository.GetAllInChannel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	missing := make([]string, 0)
+	for _, t := range tasks {
+		if findState(workflow, t.Status) == nil && !containsString(missing, t.Status) {
+			missing = append(missing, t.Status)
+		}
+	}
+	if len(missing) > 0 {
+		return textResponse(WorkflowHeader, PlainTextType, "There are tasks in "+strings.Join(missing, ", ")+". Keep the states in the workflow or move the tasks first")
+	}
+	if reset {
+		err = handler.Channels.SetWorkflow(ctx, channelID, nil)
+	} else {
+		err = handler.Channels.SetWorkflow(ctx, channelID, workflow)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return handler.respond(ctx, workflowResponse(WorkflowSetHeader, workflow), channelID, ResponseInChannel)
+}
+
+// HandleMoveCommand handles /tododo-move and returns proper response or error.
+// The text is the task number followed by the name of a state of the workflow of the channel, e.g. "12 In Review" or "12 in-review", refer to matchState.
+func (handler *CommandHandler) HandleMoveCommand(ctx context.Context, text string, channelID string, userID string) ([]byte, error) {
+	args := strings.SplitN(strings.TrimSpace(text), " ", 2)
+	if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+		return textResponse(UpdateHeader, PlainTextType, MoveBadArgsText)
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 1 {
+		return textResponse(UpdateHeader, PlainTextType, MoveBadArgsText)
+	}
+	workflow, err := handler.channelWorkflow(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	state := matchState(workflow, args[1])
+	if state == nil {
+		return textResponse(UpdateHeader, PlainTextType, "Unknown state "+strings.TrimSpace(args[1])+". The states are "+strings.Join(stateNames(workflow.States), ", "))
+	}
+	return handler.moveResponse(ctx, workflow, channelID, id, state, userID)
+}
+
+// moveResponse moves the task to state and returns the response of /tododo-move, /tododo-start and /tododo-done.
+func (handler *CommandHandler) moveResponse(ctx context.Context, workflow *mysql.Workflow, channelID string, number int, state *mysql.WorkflowState, userID string) ([]byte, error) {
+	err := handler.moveTask(ctx, workflow, channelID, number, state, userID)
+	if err == mysql.ErrNoRowOrMoreThanOne {
+		return textResponse(UpdateHeader, PlainTextType, NoSuchTaskIDText)
+	} else if denied, ok := err.(*PermissionError); ok {
+		return deniedResponse(denied)
+	} else if transition, ok := err.(*TransitionError); ok {
+		return textResponse(UpdateHeader, PlainTextType, transitionText(transition))
+	} else if err != nil {
+		return nil, err
+	}
+	task, err := handler.Repository.GetTask(ctx, channelID, number)
+	if err != nil {
+		return nil, err
+	}
+	return handler.respondTask(ctx, newTextResponse(UpdateHeader, MarkdownType, "Status: "+task.Title+" - "+task.Status), channelID, number)
+}
+
+// moveTask moves the task with this number to state if the workflow allows it, refer to canMove.
+// Returns mysql.ErrNoRowOrMoreThanOne if there is no such task or it is deleted and *TransitionError if the move is not allowed.
+func (handler *CommandHandler) moveTask(ctx context.Context, workflow *mysql.Workflow, channelID string, number int, state *mysql.WorkflowState, userID string) error {
+	task, err := handler.Repository.GetTask(ctx, channelID, number)
+	if err == sql.ErrNoRows || (err == nil && task.Deleted) {
+		return mysql.ErrNoRowOrMoreThanOne
+	} else if err != nil {
+		return err
+	}
+	if task.Status != state.Name && !canMove(workflow, task.Status, state.Name) {
+		return &TransitionError{From: task.Status, To: state.Name, Allowed: allowedStates(workflow, task.Status)}
+	}
+	return handler.Repository.SetStatus(ctx, channelID, number, state.Name, state.Terminal, userID)
+}
+
+// channelWorkflow returns the workflow of the channel, DefaultWorkflow if not set.
+func (handler *CommandHandler) channelWorkflow(ctx context.Context, channelID string) (*mysql.Workflow, error) {
+	if handler.Channels == nil {
+		return DefaultWorkflow(), nil
+	}
+	workflow, err := handler.Channels.GetWorkflow(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if workflow == nil {
+		return DefaultWorkflow(), nil
+	}
+	return workflow, nil
+}
+
+// findState returns the state of the workflow named exactly name, as stored in the status of tasks. Returns nil if there is none.
+func findState(workflow *mysql.Workflow, name string) *mysql.WorkflowState {
+	for _, state := range workflow.States {
+		if state.Name == name {
+			return state
+		}
+	}
+	return nil
+}
+
+// matchState returns the state of the workflow named text ignoring case, - and _ match spaces, so "in-review" matches "In Review".
+// Returns nil if there is none.
+func matchState(workflow *mysql.Workflow, text string) *mysql.WorkflowState {
+	for _, state := range workflow.States {
+		if normalizeState(state.Name) == normalizeState(text) {
+			return state
+		}
+	}
+	return nil
+}
+
+func normalizeState(name string) string {
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(strings.ToLower(name))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// canMove reports whether the workflow allows moving a task from state from to state to.
+// A task in a state the workflow doesn't know, e.g. a restored task, can be moved to any state.
+func canMove(workflow *mysql.Workflow, from string, to string) bool {
+	if findState(workflow, from) == nil {
+		return true
+	}
+	return hasTransition(workflow, from, to)
+}
+
+func hasTransition(workflow *mysql.Workflow, from string, to string) bool {
+	for _, transition := range workflow.Transitions {
+		if transition.From == from && transition.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedStates returns the names of the states a task in state from can be moved to, in the order of the workflow.
+func allowedStates(workflow *mysql.Workflow, from string) []string {
+	allowed := make([]string, 0)
+	for _, state := range workflow.States {
+		if state.Name != from && canMove(workflow, from, state.Name) {
+			allowed = append(allowed, state.Name)
+		}
+	}
+	return allowed
+}
+
+// startState returns the state /tododo-start moves tasks to - the first state after the state of new tasks that is not terminal.
+// Returns nil if there is none.
+func startState(workflow *mysql.Workflow) *mysql.WorkflowState {
+	for _, state := range workflow.States[1:] {
+		if !state.Terminal {
+			return state
+		}
+	}
+	return nil
+}
+
+// doneState returns the state /tododo-done moves tasks to - the first terminal state. Returns nil if there is none.
+func doneState(workflow *mysql.Workflow) *mysql.WorkflowState {
+	for _, state := range workflow.States {
+		if state.Terminal {
+			return state
+		}
+	}
+	return nil
+}
+
+// isTerminal reports whether tasks in the status are finished in the workflow.
+func isTerminal(workflow *mysql.Workflow, status string) bool {
+	state := findState(workflow, status)
+	return state != nil && state.Terminal
+}
+
+// stateEmoji returns the emoji of the status in the workflow, empty if the workflow doesn't know it.
+func stateEmoji(workflow *mysql.Workflow, status string) string {
+	state := findState(workflow, status)
+	if state == nil {
+		return ""
+	}
+	return state.Emoji
+}
+
+// stateNames returns the names of the states.
+func stateNames(states []*mysql.WorkflowState) []string {
+	names := make([]string, 0)
+	for _, state := range states {
+		names = append(names, state.Name)
+	}
+	return names
+}
+
+// workflowResponse constructs a message with a line per state of the workflow - its emoji, name and the states it can move to.
+func workflowResponse(headerText string, workflow *mysql.Workflow) *Response {
+	lines := make([]string, 0)
+	for _, state := range workflow.States {
+		line := state.Emoji + " *" + state.Name + "*"
+		if state.Terminal {
+			line += " (" + TerminalText + ")"
+		}
+		allowed := allowedStates(workflow, state.Name)
+		if len(allowed) > 0 {
+			line += " → " + strings.Join(allowed, ", ")
+		}
+		lines = append(lines, line)
+	}
+	header := NewHeaderBlock(headerText)
+	div := NewDividerBlock()
+	list := NewSectionTextBlock(MarkdownType, strings.Join(lines, "\n"))
+	hint := NewContextBlock(&BlockText{Type: MarkdownType, Text: WorkflowHintText})
+	return NewResponse(header, div, list, hint)
+}
+
+// transitionText describes a move the workflow doesn't allow and the states the task can be moved to instead.
+func transitionText(e *TransitionError) string {
+	if len(e.Allowed) == 0 {
+		return "A task in " + e.From + " can't be moved in the workflow of this channel"
+	}
+	return "A task in " + e.From + " can't be moved to " + e.To + ", only to " + strings.Join(e.Allowed, ", ")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package tododo
+
+import (
+	"github.com/hboyadzhieva/slack-bot-to-do-list/mysql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// reviewWorkflow is the text of a workflow where open tasks can't be finished without a review
+const reviewWorkflow = "Open :question: -> In Review, blocked | In Review :eyes: -> Done, Open | Blocked :no_entry: -> open | Done :white_check_mark: terminal"
+
+func newReviewHandler(t *testing.T) (*CommandHandler, *MockRepo) {
+	handler := newMockHandler()
+	workflow, err := ParseWorkflow(reviewWorkflow)
+	assert.NoError(t, err)
+	handler.Channels.SetWorkflow(ctx, "CH1", workflow)
+	return handler, handler.Repository.(*MockRepo)
+}
+
+func TestParseWorkflow(t *testing.T) {
+	workflow, err := ParseWorkflow(reviewWorkflow)
+	assert.NoError(t, err)
+	assert.Equal(t, []*mysql.WorkflowState{
+		{Name: "Open", Emoji: ":question:"},
+		{Name: "In Review", Emoji: ":eyes:"},
+		{Name: "Blocked", Emoji: ":no_entry:"},
+		{Name: "Done", Emoji: ":white_check_mark:", Terminal: true},
+	}, workflow.States)
+	assert.Equal(t, []*mysql.WorkflowTransition{
+		{From: "Open", To: "In Review"},
+		{From: "Open", To: "Blocked"},
+		{From: "In Review", To: "Done"},
+		{From: "In Review", To: "Open"},
+		{From: "Blocked", To: "Open"},
+	}, workflow.Transitions)
+}
+
+func TestParseWorkflowErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{"Open | Done :white_check_mark: terminal", `Every state needs a name followed by an emoji, e.g. In Review :eyes:, got "Open"`},
+		{":question: | Done :white_check_mark: terminal", `Every state needs a name followed by an emoji, e.g. In Review :eyes:, got ":question:"`},
+		{"Open :question: | open :eyes: | Done :white_check_mark: terminal", "State open is defined twice"},
+		{"Open :question: -> Closed | Done :white_check_mark: terminal", `Open can't move to unknown state "Closed"`},
+		{"Open :question: -> Open | Done :white_check_mark: terminal", "Open can't move to itself"},
+		{"Open :question:", "A workflow has from 2 to 10 states"},
+		{"Waiting on the customer to answer :phone: | Done :white_check_mark: terminal", "State Waiting on the customer to answer is longer than 30 characters"},
+		{"Done :white_check_mark: terminal | Open :question:", "New tasks start in Done, so it can't be terminal"},
+		{"Open :question: | Done :white_check_mark:", "A workflow needs a terminal state for finished tasks, e.g. Done :white_check_mark: terminal"},
+	}
+	for _, tc := range tests {
+		workflow, err := ParseWorkflow(tc.text)
+		assert.Nil(t, workflow, tc.text)
+		assert.EqualError(t, err, tc.err, tc.text)
+	}
+}
+
+func TestDefaultWorkflow(t *testing.T) {
+	workflow := DefaultWorkflow()
+	assert.Equal(t, mysql.StatusInProgress, startState(workflow).Name)
+	assert.Equal(t, mysql.StatusDone, doneState(workflow).Name)
+	assert.True(t, canMove(workflow, mysql.StatusDone, mysql.StatusOpen))
+	assert.Equal(t, []string{mysql.StatusOpen, mysql.StatusInProgress}, allowedStates(workflow, mysql.StatusDone))
+}
+
+func TestMatchState(t *testing.T) {
+	workflow, _ := ParseWorkflow(reviewWorkflow)
+	assert.Equal(t, "In Review", matchState(workflow, "in-review").Name)
+	assert.Equal(t, "In Review", matchState(workflow, " IN_REVIEW").Name)
+	assert.Nil(t, matchState(workflow, "review"))
+	assert.True(t, canMove(workflow, "Waiting on customer", "Done"))
+}
+
+func TestHandleMoveCommand(t *testing.T) {
+	mockHandler, repo := newReviewHandler(t)
+	result, err := mockHandler.HandleMoveCommand(ctx, "1 in review", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, "Status:")
+	assert.Contains(t, stringRes, `"response_type":"in_channel"`)
+	assert.Equal(t, "In Review", repo.status)
+	assert.False(t, repo.terminal)
+	assert.Equal(t, "U1", repo.actor)
+}
+
+func TestHandleMoveCommandNotAllowed(t *testing.T) {
+	mockHandler, repo := newReviewHandler(t)
+	result, err := mockHandler.HandleMoveCommand(ctx, "1 done", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "A task in Open can't be moved to Done, only to In Review, Blocked")
+	assert.Equal(t, "", repo.status)
+}
+
+func TestHandleMoveCommandBadArgs(t *testing.T) {
+	mockHandler, _ := newReviewHandler(t)
+	for _, text := range []string{"", "1", "one done", "0 done"} {
+		result, err := mockHandler.HandleMoveCommand(ctx, text, "CH1", "U1")
+		assert.NoError(t, err)
+		assert.Contains(t, string(result), MoveBadArgsText, text)
+	}
+	result, err := mockHandler.HandleMoveCommand(ctx, "1 Closed", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "Unknown state Closed. The states are Open, In Review, Blocked, Done")
+	result, err = mockHandler.HandleMoveCommand(ctx, "2 done", "CH2", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoSuchTaskIDText)
+}
+
+func TestHandleDoneCommandWorkflow(t *testing.T) {
+	mockHandler, repo := newReviewHandler(t)
+	result, err := mockHandler.HandleDoneCommand(ctx, "1", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "A task in Open can't be moved to Done")
+	result, err = mockHandler.HandleProgressCommand(ctx, "1", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "Status:")
+	assert.Equal(t, "In Review", repo.status)
+}
+
+func TestHandleProgressCommandNoStartState(t *testing.T) {
+	mockHandler := newMockHandler()
+	workflow, _ := ParseWorkflow("Todo :memo: -> Done | Done :white_check_mark: terminal")
+	mockHandler.Channels.SetWorkflow(ctx, "CH1", workflow)
+	result, err := mockHandler.HandleProgressCommand(ctx, "1", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), NoStartStateText)
+}
+
+func TestHandleWorkflowCommand(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleWorkflowCommand(ctx, "", "CH1", "U1")
+	stringRes := string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, WorkflowHeader)
+	assert.Contains(t, stringRes, ":white_check_mark: *Done* (terminal) → Open, In Progress")
+	assert.Contains(t, stringRes, `"response_type":"ephemeral"`)
+	result, err = mockHandler.HandleWorkflowCommand(ctx, reviewWorkflow, "CH1", "U1")
+	stringRes = string(result)
+	assert.NoError(t, err)
+	assert.Contains(t, stringRes, WorkflowSetHeader)
+	assert.Contains(t, stringRes, ":eyes: *In Review* → Open, Done")
+	assert.Contains(t, stringRes, `"response_type":"in_channel"`)
+	workflow, _ := mockHandler.Channels.GetWorkflow(ctx, "CH1")
+	assert.Len(t, workflow.States, 4)
+	result, err = mockHandler.HandleWorkflowCommand(ctx, "reset", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), ":hourglass_flowing_sand: *In Progress*")
+	workflow, _ = mockHandler.Channels.GetWorkflow(ctx, "CH1")
+	assert.Nil(t, workflow)
+}
+
+func TestHandleWorkflowCommandErrors(t *testing.T) {
+	mockHandler := newMockHandler()
+	result, err := mockHandler.HandleWorkflowCommand(ctx, "Open :question:", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "A workflow has from 2 to 10 states. Please enter /tododo-workflow")
+	result, err = mockHandler.HandleWorkflowCommand(ctx, "Todo :memo: -> Done | Done :white_check_mark: terminal", "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "There are tasks in Open. Keep the states in the workflow or move the tasks first")
+	mockHandler.Channels.AddAdmin(ctx, "CH1", "U0ADM")
+	result, err = mockHandler.HandleWorkflowCommand(ctx, reviewWorkflow, "CH1", "U1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), PermissionDeniedHeader)
+	workflow, _ := mockHandler.Channels.GetWorkflow(ctx, "CH1")
+	assert.Nil(t, workflow)
+}
+
+func TestParseShowFilterWorkflow(t *testing.T) {
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	workflow, _ := ParseWorkflow("Open :question: | In Review :eyes: | Blocked :no_entry: | Done :white_check_mark: terminal | Won't Fix :x: terminal")
+	tests := []struct {
+		text     string
+		statuses []string
+	}{
+		{"", []string{"Open", "In Review", "Blocked"}},
+		{"started", []string{"In Review", "Blocked"}},
+		{"done", []string{"Done", "Won't Fix"}},
+		{"state:in-review state:BLOCKED", []string{"In Review", "Blocked"}},
+		{"last 7d", []string{"Done", "Won't Fix"}},
+		{"state:won't_fix last 7d", []string{"Won't Fix"}},
+	}
+	for _, tc := range tests {
+		filter, err := ParseShowFilter(tc.text, "C1", "U1", now, workflow)
+		if assert.NoError(t, err, tc.text) {
+			assert.Equal(t, tc.statuses, filter.Statuses, tc.text)
+		}
+	}
+	_, err := ParseShowFilter("state:review", "C1", "U1", now, workflow)
+	assert.EqualError(t, err, "Unknown state state:review, the states are Open, In Review, Blocked, Done, Won't Fix")
+	_, err = ParseShowFilter("state:blocked last 7d", "C1", "U1", now, workflow)
+	assert.EqualError(t, err, "last shows done tasks and can't be combined with another status")
+}
+
+func TestTaskActionsBlockWorkflow(t *testing.T) {
+	workflow, _ := ParseWorkflow(reviewWorkflow)
+	open := taskActionsBlock(&mysql.Task{Number: 1, Status: "Open"}, "", workflow)
+	assert.Equal(t, []string{ActionStart, ActionAssignMe}, actionIDs(open))
+	review := taskActionsBlock(&mysql.Task{Number: 1, Status: "In Review"}, "", workflow)
+	assert.Equal(t, []string{ActionDone, ActionAssignMe}, actionIDs(review))
+	done := taskActionsBlock(&mysql.Task{Number: 1, Status: "Done"}, "", workflow)
+	assert.Equal(t, []string{ActionAssignMe}, actionIDs(done))
+}
+
+func TestHandleInteractionNotAllowed(t *testing.T) {
+	mockHandler, repo := newReviewHandler(t)
+	result, err := mockHandler.HandleInteraction(ctx, newMockPayload(ActionDone, "1"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), "A task in Open can't be moved to Done")
+	assert.NotContains(t, string(result), "replace_original")
+	assert.Equal(t, "", repo.status)
+}
+
+func actionIDs(block *Block) []string {
+	ids := make([]string, 0)
+	for _, element := range block.Elements {
+		ids = append(ids, element.ActionID)
+	}
+	return ids
+}